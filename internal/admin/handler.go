@@ -0,0 +1,400 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/tashifkhan/bingebeacon/internal/notification"
+	appctx "github.com/tashifkhan/bingebeacon/internal/pkg/context"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/httputil"
+	"github.com/tashifkhan/bingebeacon/internal/scheduler"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := UserFilter{Query: q.Get("q"), Role: q.Get("role")}
+	filter.Limit, filter.Offset = pagination(r)
+	if disabled := q.Get("disabled"); disabled != "" {
+		value, err := strconv.ParseBool(disabled)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, "Invalid disabled filter")
+			return
+		}
+		filter.Disabled = &value
+	}
+
+	page, err := h.svc.ListUsers(r.Context(), filter)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, page)
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	detail, err := h.svc.GetUser(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusOK, detail)
+}
+
+func (h *Handler) SetRole(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var req SetRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	summary, err := h.svc.SetRole(r.Context(), actor, id, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusOK, summary)
+}
+
+func (h *Handler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var req DisableUserRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	if err := h.svc.DisableUser(r.Context(), actor, id, req); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.EnableUser(r.Context(), actor, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteUser(r.Context(), actor, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ResetSessions(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.ResetSessions(r.Context(), actor, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListShows(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := ShowFilter{Query: q.Get("q"), MediaType: q.Get("media_type")}
+	filter.Limit, filter.Offset = pagination(r)
+
+	page, err := h.svc.ListShows(r.Context(), filter)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, page)
+}
+
+func (h *Handler) ImportShow(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	var req ImportShowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	imported, err := h.svc.ImportShow(r.Context(), actor, req)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusCreated, imported)
+}
+
+func (h *Handler) SyncShow(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.SyncShow(r.Context(), actor, id); err != nil {
+		if errors.Is(err, ErrShowNotFound) {
+			writeError(w, err)
+			return
+		}
+		httputil.Error(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "Sync completed"})
+}
+
+func (h *Handler) DeleteShow(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteShow(r.Context(), actor, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) SyncLogs(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	entries, err := h.svc.SyncLogs(r.Context(), limit)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, entries)
+}
+
+func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.svc.ListJobs(r.Context())
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, statuses)
+}
+
+func (h *Handler) JobRuns(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	runs, err := h.svc.JobRuns(r.Context(), mux.Vars(r)["name"], limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusOK, runs)
+}
+
+func (h *Handler) RunJob(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	runID, err := h.svc.RunJob(r.Context(), actor, mux.Vars(r)["name"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusAccepted, map[string]uuid.UUID{"run_id": runID})
+}
+
+func (h *Handler) PauseJob(w http.ResponseWriter, r *http.Request) {
+	h.setJobPaused(w, r, true)
+}
+
+func (h *Handler) ResumeJob(w http.ResponseWriter, r *http.Request) {
+	h.setJobPaused(w, r, false)
+}
+
+func (h *Handler) setJobPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.SetJobPaused(r.Context(), actor, mux.Vars(r)["name"], paused); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.CancelJob(r.Context(), actor, mux.Vars(r)["name"]); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := notification.DeadLetterFilter{ErrorClass: q.Get("error_class")}
+	filter.Limit, filter.Offset = pagination(r)
+	if raw := q.Get("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+		filter.UserID = &id
+	}
+
+	page, err := h.svc.ListDeadLetters(r.Context(), filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusOK, page)
+}
+
+func (h *Handler) ReplayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	h.resolveDeadLetters(w, r, h.svc.ReplayDeadLetters)
+}
+
+func (h *Handler) DiscardDeadLetters(w http.ResponseWriter, r *http.Request) {
+	h.resolveDeadLetters(w, r, h.svc.DiscardDeadLetters)
+}
+
+func (h *Handler) resolveDeadLetters(w http.ResponseWriter, r *http.Request, apply func(context.Context, Actor, DeadLetterRequest) (int64, error)) {
+	actor, ok := actorFrom(w, r)
+	if !ok {
+		return
+	}
+	var req DeadLetterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	count, err := apply(r.Context(), actor, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusOK, map[string]int64{"count": count})
+}
+
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := AuditFilter{Action: q.Get("action"), TargetType: q.Get("target_type"), TargetID: q.Get("target_id")}
+	filter.Limit, filter.Offset = pagination(r)
+	if actor := q.Get("actor_id"); actor != "" {
+		id, err := uuid.Parse(actor)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, "Invalid actor ID")
+			return
+		}
+		filter.ActorID = &id
+	}
+
+	entries, err := h.svc.ListAudit(r.Context(), filter)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, entries)
+}
+
+func actorFrom(w http.ResponseWriter, r *http.Request) (Actor, bool) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return Actor{}, false
+	}
+	return Actor{UserID: &userID, Role: appctx.Role(r.Context()), IPAddress: httputil.ClientIP(r)}, true
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func pagination(r *http.Request) (int, int) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	return limit, offset
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrShowNotFound) || errors.Is(err, scheduler.ErrJobNotFound) {
+		httputil.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, scheduler.ErrJobRunning) || errors.Is(err, scheduler.ErrJobNotRunning) {
+		httputil.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+	httputil.Error(w, http.StatusBadRequest, err.Error())
+}
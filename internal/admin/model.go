@@ -0,0 +1,126 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/show"
+	"gorm.io/datatypes"
+)
+
+// AuditEntry records one privileged action. ActorID is nil for calls made
+// with the internal API key.
+type AuditEntry struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ActorID    *uuid.UUID     `gorm:"type:uuid" json:"actor_id,omitempty"`
+	ActorRole  string         `gorm:"type:text;not null" json:"actor_role"`
+	Action     string         `gorm:"type:text;not null" json:"action"`
+	TargetType string         `gorm:"type:text;not null" json:"target_type"`
+	TargetID   *string        `gorm:"type:text" json:"target_id,omitempty"`
+	Details    datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"details"`
+	IPAddress  *string        `gorm:"type:text" json:"ip_address,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+func (AuditEntry) TableName() string {
+	return "admin_audit_log"
+}
+
+// Actor is whoever is performing an admin action.
+type Actor struct {
+	UserID    *uuid.UUID
+	Role      string
+	IPAddress string
+}
+
+type UserFilter struct {
+	Query    string
+	Role     string
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
+
+type UserSummary struct {
+	ID               uuid.UUID  `json:"id"`
+	Email            string     `json:"email"`
+	Username         string     `json:"username"`
+	Role             string     `json:"role"`
+	EmailVerified    bool       `json:"email_verified"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	DisabledAt       *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type UserDetail struct {
+	UserSummary
+	ActiveSessions int `json:"active_sessions"`
+}
+
+type UserPage struct {
+	Users []UserSummary `json:"users"`
+	Total int64         `json:"total"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type DisableUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ImportShowRequest struct {
+	TMDBID    int    `json:"tmdb_id"`
+	MediaType string `json:"media_type"`
+}
+
+type ShowFilter struct {
+	Query     string
+	MediaType string
+	Limit     int
+	Offset    int
+}
+
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Limit      int
+	Offset     int
+}
+
+type ShowPage struct {
+	Shows []show.Show `json:"shows"`
+	Total int64       `json:"total"`
+}
+
+// DeadLetter is a notification that exhausted its retries.
+type DeadLetter struct {
+	ID              uuid.UUID  `json:"id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	ProfileID       uuid.UUID  `json:"profile_id"`
+	TimelineEventID *uuid.UUID `json:"timeline_event_id,omitempty"`
+	Title           string     `json:"title"`
+	ErrorClass      string     `json:"error_class"`
+	LastError       string     `json:"last_error"`
+	Attempts        int        `json:"attempts"`
+	FailedAt        *time.Time `json:"failed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type DeadLetterPage struct {
+	Notifications []DeadLetter `json:"notifications"`
+	Total         int64        `json:"total"`
+	// Classes counts the whole queue by error class, ignoring filters.
+	Classes map[string]int64 `json:"classes"`
+}
+
+// DeadLetterRequest selects dead letters for a bulk replay or discard. At
+// least one field must be set.
+type DeadLetterRequest struct {
+	IDs        []uuid.UUID `json:"ids"`
+	ErrorClass string      `json:"error_class"`
+	UserID     *uuid.UUID  `json:"user_id"`
+}
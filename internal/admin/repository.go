@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/tashifkhan/bingebeacon/internal/show"
+	"github.com/tashifkhan/bingebeacon/internal/user"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) ListUsers(ctx context.Context, filter UserFilter) ([]user.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&user.User{})
+	if filter.Query != "" {
+		pattern := "%" + filter.Query + "%"
+		query = query.Where("email ILIKE ? OR username ILIKE ?", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			query = query.Where("disabled_at IS NOT NULL")
+		} else {
+			query = query.Where("disabled_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []user.User
+	err := query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&users).Error
+	return users, total, err
+}
+
+func (r *Repository) ListShows(ctx context.Context, filter ShowFilter) ([]show.Show, int64, error) {
+	query := r.db.WithContext(ctx).Model(&show.Show{})
+	if filter.Query != "" {
+		query = query.Where("title ILIKE ?", "%"+filter.Query+"%")
+	}
+	if filter.MediaType != "" {
+		query = query.Where("media_type = ?", filter.MediaType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var shows []show.Show
+	err := query.Order("updated_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&shows).Error
+	return shows, total, err
+}
+
+func (r *Repository) RecordAudit(ctx context.Context, entry *AuditEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *Repository) ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	query := r.db.WithContext(ctx).Model(&AuditEntry{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+
+	var entries []AuditEntry
+	err := query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,513 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/auth"
+	"github.com/tashifkhan/bingebeacon/internal/metadata"
+	"github.com/tashifkhan/bingebeacon/internal/notification"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
+	"github.com/tashifkhan/bingebeacon/internal/scheduler"
+	"github.com/tashifkhan/bingebeacon/internal/show"
+	"github.com/tashifkhan/bingebeacon/internal/user"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrShowNotFound = errors.New("show not found")
+)
+
+type Service struct {
+	repo      *Repository
+	userRepo  *user.Repository
+	notifRepo *notification.Repository
+	authRepo  *auth.Repository
+	showRepo  *show.Repository
+	showSvc   *show.Service
+	syncer    *metadata.Syncer
+	sched     *scheduler.Scheduler
+	cache     cache.Store
+	logger    *slog.Logger
+}
+
+func NewService(repo *Repository, userRepo *user.Repository, notifRepo *notification.Repository, authRepo *auth.Repository, showRepo *show.Repository, showSvc *show.Service, syncer *metadata.Syncer, sched *scheduler.Scheduler, store cache.Store, logger *slog.Logger) *Service {
+	return &Service{
+		repo:      repo,
+		userRepo:  userRepo,
+		notifRepo: notifRepo,
+		authRepo:  authRepo,
+		showRepo:  showRepo,
+		showSvc:   showSvc,
+		syncer:    syncer,
+		sched:     sched,
+		cache:     store,
+		logger:    logger,
+	}
+}
+
+func (s *Service) ListUsers(ctx context.Context, filter UserFilter) (*UserPage, error) {
+	ctx, span := tracing.Start(ctx, "admin.ListUsers")
+	defer span.End()
+
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Role != "" && !user.ValidRole(filter.Role) {
+		return nil, fmt.Errorf("unknown role %q", filter.Role)
+	}
+	filter.Limit, filter.Offset = pageBounds(filter.Limit, filter.Offset)
+
+	users, total, err := s.repo.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &UserPage{Users: make([]UserSummary, len(users)), Total: total}
+	for i := range users {
+		page.Users[i] = summarize(&users[i])
+	}
+	return page, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, id uuid.UUID) (*UserDetail, error) {
+	ctx, span := tracing.Start(ctx, "admin.GetUser")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	sessions, err := s.authRepo.GetActiveSessions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &UserDetail{UserSummary: summarize(u), ActiveSessions: len(sessions)}, nil
+}
+
+// SetRole changes an account's role. It takes effect at the account's next
+// token refresh; new admins must enroll in 2FA at their next sign-in.
+func (s *Service) SetRole(ctx context.Context, actor Actor, id uuid.UUID, req SetRoleRequest) (*UserSummary, error) {
+	ctx, span := tracing.Start(ctx, "admin.SetRole")
+	defer span.End()
+
+	if !user.ValidRole(req.Role) {
+		return nil, fmt.Errorf("unknown role %q", req.Role)
+	}
+	u, err := s.targetUser(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role == req.Role {
+		summary := summarize(u)
+		return &summary, nil
+	}
+	if err := s.keepAnAdmin(ctx, u); err != nil {
+		return nil, err
+	}
+
+	previous := u.Role
+	u.Role = req.Role
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, actor, "user.role_changed", "user", id.String(), map[string]interface{}{
+		"from": previous, "to": req.Role,
+	})
+	summary := summarize(u)
+	return &summary, nil
+}
+
+// DisableUser suspends an account and signs it out everywhere. Access
+// tokens already issued stay valid until they expire.
+func (s *Service) DisableUser(ctx context.Context, actor Actor, id uuid.UUID, req DisableUserRequest) error {
+	ctx, span := tracing.Start(ctx, "admin.DisableUser")
+	defer span.End()
+
+	u, err := s.targetUser(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if u.DisabledAt != nil {
+		return nil
+	}
+	if err := s.keepAnAdmin(ctx, u); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	u.DisabledAt = &now
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return err
+	}
+	if err := s.authRepo.RevokeAllSessions(ctx, id); err != nil {
+		return err
+	}
+	s.audit(ctx, actor, "user.disabled", "user", id.String(), map[string]interface{}{
+		"reason": strings.TrimSpace(req.Reason),
+	})
+	return nil
+}
+
+func (s *Service) EnableUser(ctx context.Context, actor Actor, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "admin.EnableUser")
+	defer span.End()
+
+	u, err := s.targetUser(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if u.DisabledAt == nil {
+		return nil
+	}
+	u.DisabledAt = nil
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return err
+	}
+	s.audit(ctx, actor, "user.enabled", "user", id.String(), nil)
+	return nil
+}
+
+func (s *Service) DeleteUser(ctx context.Context, actor Actor, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "admin.DeleteUser")
+	defer span.End()
+
+	u, err := s.targetUser(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if err := s.keepAnAdmin(ctx, u); err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit(ctx, actor, "user.deleted", "user", id.String(), map[string]interface{}{
+		"email": u.Email, "username": u.Username,
+	})
+	return nil
+}
+
+func (s *Service) ResetSessions(ctx context.Context, actor Actor, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "admin.ResetSessions")
+	defer span.End()
+
+	if _, err := s.userRepo.FindByID(ctx, id); err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.authRepo.RevokeAllSessions(ctx, id); err != nil {
+		return err
+	}
+	s.audit(ctx, actor, "user.sessions_reset", "user", id.String(), nil)
+	return nil
+}
+
+func (s *Service) ListShows(ctx context.Context, filter ShowFilter) (*ShowPage, error) {
+	ctx, span := tracing.Start(ctx, "admin.ListShows")
+	defer span.End()
+
+	filter.Query = strings.TrimSpace(filter.Query)
+	filter.Limit, filter.Offset = pageBounds(filter.Limit, filter.Offset)
+	shows, total, err := s.repo.ListShows(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &ShowPage{Shows: shows, Total: total}, nil
+}
+
+// ImportShow adds a title from TMDB and syncs it straight away.
+func (s *Service) ImportShow(ctx context.Context, actor Actor, req ImportShowRequest) (*show.Show, error) {
+	ctx, span := tracing.Start(ctx, "admin.ImportShow")
+	defer span.End()
+
+	if req.TMDBID <= 0 {
+		return nil, errors.New("tmdb_id is required")
+	}
+	imported, err := s.showSvc.GetOrCreateByTMDBID(ctx, req.TMDBID, req.MediaType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncer.SyncShow(ctx, imported.ID); err != nil {
+		s.logger.WarnContext(ctx, "Imported show failed its first sync", "show_id", imported.ID, "error", err)
+	}
+	s.audit(ctx, actor, "show.imported", "show", imported.ID.String(), map[string]interface{}{
+		"tmdb_id": req.TMDBID, "media_type": imported.MediaType, "title": imported.Title,
+	})
+	return imported, nil
+}
+
+func (s *Service) SyncShow(ctx context.Context, actor Actor, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "admin.SyncShow")
+	defer span.End()
+
+	if _, err := s.showRepo.FindByID(ctx, id); err != nil {
+		return ErrShowNotFound
+	}
+	syncErr := s.syncer.SyncShow(ctx, id)
+	details := map[string]interface{}{"success": syncErr == nil}
+	if syncErr != nil {
+		details["error"] = syncErr.Error()
+	}
+	s.audit(ctx, actor, "show.synced", "show", id.String(), details)
+	return syncErr
+}
+
+// DeleteShow removes a show from the catalogue along with everything that
+// references it, including users' tracking and history.
+func (s *Service) DeleteShow(ctx context.Context, actor Actor, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "admin.DeleteShow")
+	defer span.End()
+
+	existing, err := s.showRepo.FindByID(ctx, id)
+	if err != nil {
+		return ErrShowNotFound
+	}
+	if _, err := s.showRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.cache.InvalidateTags(ctx, fmt.Sprintf("show:%s", id))
+
+	s.audit(ctx, actor, "show.deleted", "show", id.String(), map[string]interface{}{
+		"title": existing.Title, "tmdb_id": existing.TMDBID,
+	})
+	return nil
+}
+
+func (s *Service) SyncLogs(ctx context.Context, limit int) ([]metadata.SyncLog, error) {
+	ctx, span := tracing.Start(ctx, "admin.SyncLogs")
+	defer span.End()
+
+	return s.syncer.RecentLogs(ctx, limit)
+}
+
+func (s *Service) ListJobs(ctx context.Context) ([]scheduler.JobStatus, error) {
+	ctx, span := tracing.Start(ctx, "admin.ListJobs")
+	defer span.End()
+
+	return s.sched.Status(ctx)
+}
+
+func (s *Service) JobRuns(ctx context.Context, name string, limit int) ([]scheduler.JobRun, error) {
+	ctx, span := tracing.Start(ctx, "admin.JobRuns")
+	defer span.End()
+
+	return s.sched.Runs(ctx, name, limit)
+}
+
+// RunJob starts a job now, even if it is paused. It returns the run's ID
+// without waiting for it to finish.
+func (s *Service) RunJob(ctx context.Context, actor Actor, name string) (uuid.UUID, error) {
+	ctx, span := tracing.Start(ctx, "admin.RunJob")
+	defer span.End()
+
+	runID, err := s.sched.RunNow(name, actor.UserID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.audit(ctx, actor, "job.run", "job", name, map[string]interface{}{"run_id": runID})
+	return runID, nil
+}
+
+func (s *Service) SetJobPaused(ctx context.Context, actor Actor, name string, paused bool) error {
+	ctx, span := tracing.Start(ctx, "admin.SetJobPaused")
+	defer span.End()
+
+	if err := s.sched.SetPaused(ctx, name, paused, actor.UserID); err != nil {
+		return err
+	}
+	action := "job.resumed"
+	if paused {
+		action = "job.paused"
+	}
+	s.audit(ctx, actor, action, "job", name, nil)
+	return nil
+}
+
+// CancelJob stops a job's current run. It only reaches runs on the replica
+// serving the request.
+func (s *Service) CancelJob(ctx context.Context, actor Actor, name string) error {
+	ctx, span := tracing.Start(ctx, "admin.CancelJob")
+	defer span.End()
+
+	if err := s.sched.Cancel(name); err != nil {
+		return err
+	}
+	s.audit(ctx, actor, "job.cancelled", "job", name, nil)
+	return nil
+}
+
+func (s *Service) ListDeadLetters(ctx context.Context, filter notification.DeadLetterFilter) (*DeadLetterPage, error) {
+	ctx, span := tracing.Start(ctx, "admin.ListDeadLetters")
+	defer span.End()
+
+	if filter.ErrorClass != "" && !notification.ValidErrorClass(filter.ErrorClass) {
+		return nil, fmt.Errorf("unknown error class %q", filter.ErrorClass)
+	}
+	filter.Limit, filter.Offset = pageBounds(filter.Limit, filter.Offset)
+
+	notifs, total, err := s.notifRepo.ListDeadLetters(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	classes, err := s.notifRepo.CountDeadLetters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &DeadLetterPage{Notifications: make([]DeadLetter, len(notifs)), Total: total, Classes: classes}
+	for i, n := range notifs {
+		page.Notifications[i] = DeadLetter{
+			ID:              n.ID,
+			UserID:          n.UserID,
+			ProfileID:       n.ProfileID,
+			TimelineEventID: n.TimelineEventID,
+			Title:           n.Title,
+			ErrorClass:      show.SafeString(n.ErrorClass),
+			LastError:       show.SafeString(n.LastError),
+			Attempts:        n.RetryCount,
+			FailedAt:        n.FailedAt,
+			CreatedAt:       n.CreatedAt,
+		}
+	}
+	return page, nil
+}
+
+// ReplayDeadLetters requeues the selected dead letters with a fresh retry
+// budget and returns how many were requeued.
+func (s *Service) ReplayDeadLetters(ctx context.Context, actor Actor, req DeadLetterRequest) (int64, error) {
+	ctx, span := tracing.Start(ctx, "admin.ReplayDeadLetters")
+	defer span.End()
+
+	return s.resolveDeadLetters(ctx, actor, req, "notification.replayed", s.notifRepo.ReplayDeadLetters)
+}
+
+func (s *Service) DiscardDeadLetters(ctx context.Context, actor Actor, req DeadLetterRequest) (int64, error) {
+	ctx, span := tracing.Start(ctx, "admin.DiscardDeadLetters")
+	defer span.End()
+
+	return s.resolveDeadLetters(ctx, actor, req, "notification.discarded", s.notifRepo.DiscardDeadLetters)
+}
+
+func (s *Service) resolveDeadLetters(ctx context.Context, actor Actor, req DeadLetterRequest, action string, apply func(context.Context, notification.DeadLetterFilter) (int64, error)) (int64, error) {
+	filter := notification.DeadLetterFilter{IDs: req.IDs, ErrorClass: req.ErrorClass, UserID: req.UserID}
+	if filter.Empty() {
+		return 0, errors.New("select dead letters by ids, error_class or user_id")
+	}
+	if filter.ErrorClass != "" && !notification.ValidErrorClass(filter.ErrorClass) {
+		return 0, fmt.Errorf("unknown error class %q", filter.ErrorClass)
+	}
+
+	count, err := apply(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	details := map[string]interface{}{"count": count}
+	if len(req.IDs) > 0 {
+		details["ids"] = req.IDs
+	}
+	if req.ErrorClass != "" {
+		details["error_class"] = req.ErrorClass
+	}
+	if req.UserID != nil {
+		details["user_id"] = req.UserID
+	}
+	s.audit(ctx, actor, action, "notification", "", details)
+	return count, nil
+}
+
+func (s *Service) ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "admin.ListAudit")
+	defer span.End()
+
+	filter.Limit, filter.Offset = pageBounds(filter.Limit, filter.Offset)
+	return s.repo.ListAudit(ctx, filter)
+}
+
+// Audit records an action taken outside this service, such as through the
+// internal API.
+func (s *Service) Audit(ctx context.Context, actor Actor, action, targetType, targetID string, details map[string]interface{}) {
+	ctx, span := tracing.Start(ctx, "admin.Audit")
+	defer span.End()
+
+	s.audit(ctx, actor, action, targetType, targetID, details)
+}
+
+// audit is best effort: the action has already happened, so a failed write
+// is logged rather than reported to the caller.
+func (s *Service) audit(ctx context.Context, actor Actor, action, targetType, targetID string, details map[string]interface{}) {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	payload, _ := json.Marshal(details)
+	entry := &AuditEntry{
+		ActorID:    actor.UserID,
+		ActorRole:  actor.Role,
+		Action:     action,
+		TargetType: targetType,
+		Details:    payload,
+	}
+	if targetID != "" {
+		entry.TargetID = &targetID
+	}
+	if actor.IPAddress != "" {
+		entry.IPAddress = &actor.IPAddress
+	}
+	if err := s.repo.RecordAudit(ctx, entry); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to write audit log", "action", action, "target_id", targetID, "error", err)
+	}
+}
+
+// targetUser loads the account an action applies to. Staff cannot act on
+// their own account through the admin API.
+func (s *Service) targetUser(ctx context.Context, actor Actor, id uuid.UUID) (*user.User, error) {
+	if actor.UserID != nil && *actor.UserID == id {
+		return nil, errors.New("you cannot change your own account here")
+	}
+	u, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
+
+// keepAnAdmin refuses to demote, disable or delete the last active admin.
+func (s *Service) keepAnAdmin(ctx context.Context, u *user.User) error {
+	if u.Role != user.RoleAdmin || u.DisabledAt != nil {
+		return nil
+	}
+	count, err := s.userRepo.CountByRole(ctx, user.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return errors.New("cannot remove the last active admin")
+	}
+	return nil
+}
+
+func summarize(u *user.User) UserSummary {
+	return UserSummary{
+		ID:               u.ID,
+		Email:            u.Email,
+		Username:         u.Username,
+		Role:             u.Role,
+		EmailVerified:    u.EmailVerifiedAt != nil,
+		TwoFactorEnabled: u.TwoFactorEnabled(),
+		DisabledAt:       u.DisabledAt,
+		CreatedAt:        u.CreatedAt,
+	}
+}
+
+func pageBounds(limit, offset int) (int, int) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
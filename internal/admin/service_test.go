@@ -0,0 +1,174 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/auth"
+	"github.com/tashifkhan/bingebeacon/internal/user"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// accounts is a minimal database/sql driver standing in for Postgres. It
+// answers the account lookup and the active-admin count the guards run, and
+// accepts every write, recording that it happened.
+type accounts struct {
+	mu     sync.Mutex
+	target map[string]driver.Value
+	admins int64
+	writes []string
+}
+
+func (a *accounts) Open(string) (driver.Conn, error) { return &accountsConn{a}, nil }
+
+type accountsConn struct{ a *accounts }
+
+func (c *accountsConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+func (c *accountsConn) Close() error              { return nil }
+func (c *accountsConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *accountsConn) Commit() error             { return nil }
+func (c *accountsConn) Rollback() error           { return nil }
+
+func (c *accountsConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *accountsConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.a.mu.Lock()
+	defer c.a.mu.Unlock()
+	switch {
+	case strings.Contains(query, "count("):
+		return &rows{columns: []string{"count"}, values: [][]driver.Value{{c.a.admins}}}, nil
+	case strings.HasPrefix(query, `SELECT * FROM "users"`):
+		columns := make([]string, 0, len(c.a.target))
+		values := make([]driver.Value, 0, len(c.a.target))
+		for column, value := range c.a.target {
+			columns = append(columns, column)
+			values = append(values, value)
+		}
+		return &rows{columns: columns, values: [][]driver.Value{values}}, nil
+	default:
+		c.a.writes = append(c.a.writes, query)
+		return &rows{}, nil
+	}
+}
+
+func (c *accountsConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.a.mu.Lock()
+	defer c.a.mu.Unlock()
+	c.a.writes = append(c.a.writes, query)
+	return driver.RowsAffected(1), nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newGuardedService returns a service whose database holds target and
+// admins active admins in total.
+func newGuardedService(t *testing.T, target *user.User, admins int64) (*Service, *accounts) {
+	t.Helper()
+	a := &accounts{
+		target: map[string]driver.Value{
+			"id": target.ID.String(), "email": target.Email, "username": target.Username, "role": target.Role,
+		},
+		admins: admins,
+	}
+	name := "admin-test-" + uuid.NewString()
+	sql.Register(name, a)
+	conn, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.DiscardHandler)
+	svc := NewService(NewRepository(db), user.NewRepository(db), nil, auth.NewRepository(db), nil, nil, nil, nil, nil, logger)
+	return svc, a
+}
+
+func TestLastAdminCannotBeRemoved(t *testing.T) {
+	ctx := context.Background()
+	admin := &user.User{ID: uuid.New(), Email: "root@example.com", Username: "root", Role: user.RoleAdmin}
+	actorID := uuid.New()
+	actor := Actor{UserID: &actorID, Role: user.RoleAdmin}
+
+	actions := map[string]func(*Service) error{
+		"demote": func(s *Service) error {
+			_, err := s.SetRole(ctx, actor, admin.ID, SetRoleRequest{Role: user.RoleUser})
+			return err
+		},
+		"disable": func(s *Service) error { return s.DisableUser(ctx, actor, admin.ID, DisableUserRequest{}) },
+		"delete":  func(s *Service) error { return s.DeleteUser(ctx, actor, admin.ID) },
+	}
+	for name, act := range actions {
+		svc, db := newGuardedService(t, admin, 1)
+		err := act(svc)
+		if err == nil || !strings.Contains(err.Error(), "last active admin") {
+			t.Fatalf("%s the last admin: err = %v", name, err)
+		}
+		if len(db.writes) != 0 {
+			t.Fatalf("%s the last admin wrote %v", name, db.writes)
+		}
+
+		// With another active admin the same action goes through.
+		svc, db = newGuardedService(t, admin, 2)
+		if err := act(svc); err != nil {
+			t.Fatalf("%s one of two admins: %v", name, err)
+		}
+		if len(db.writes) == 0 {
+			t.Fatalf("%s one of two admins wrote nothing", name)
+		}
+	}
+}
+
+func TestActorCannotTargetOwnAccount(t *testing.T) {
+	ctx := context.Background()
+	self := &user.User{ID: uuid.New(), Email: "ops@example.com", Username: "ops", Role: user.RoleAdmin}
+	actor := Actor{UserID: &self.ID, Role: user.RoleAdmin}
+
+	actions := map[string]func(*Service) error{
+		"set role": func(s *Service) error {
+			_, err := s.SetRole(ctx, actor, self.ID, SetRoleRequest{Role: user.RoleUser})
+			return err
+		},
+		"disable": func(s *Service) error { return s.DisableUser(ctx, actor, self.ID, DisableUserRequest{}) },
+		"enable":  func(s *Service) error { return s.EnableUser(ctx, actor, self.ID) },
+		"delete":  func(s *Service) error { return s.DeleteUser(ctx, actor, self.ID) },
+	}
+	for name, act := range actions {
+		// Plenty of admins, so only the self-targeting rule can refuse.
+		svc, db := newGuardedService(t, self, 5)
+		err := act(svc)
+		if err == nil || !strings.Contains(err.Error(), "your own account") {
+			t.Fatalf("%s own account: err = %v", name, err)
+		}
+		if len(db.writes) != 0 {
+			t.Fatalf("%s own account wrote %v", name, db.writes)
+		}
+	}
+}
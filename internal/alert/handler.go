@@ -24,6 +24,7 @@ func (h *Handler) TrackShow(w http.ResponseWriter, r *http.Request) {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
 	var req TrackRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -31,7 +32,7 @@ func (h *Handler) TrackShow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.TrackShow(r.Context(), userID, req); err != nil {
+	if err := h.svc.TrackShow(r.Context(), userID, profileID, req); err != nil {
 		httputil.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -45,6 +46,7 @@ func (h *Handler) UntrackShow(w http.ResponseWriter, r *http.Request) {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
 	vars := mux.Vars(r)
 	showIDStr := vars["show_id"]
@@ -54,7 +56,7 @@ func (h *Handler) UntrackShow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.UntrackShow(r.Context(), userID, showID); err != nil {
+	if err := h.svc.UntrackShow(r.Context(), userID, profileID, showID); err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -63,13 +65,13 @@ func (h *Handler) UntrackShow(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetTrackedShows(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
 
-	shows, err := h.svc.GetTrackedShows(r.Context(), userID)
+	shows, err := h.svc.GetTrackedShows(r.Context(), profileID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -79,13 +81,13 @@ func (h *Handler) GetTrackedShows(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetFavorites(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
 
-	shows, err := h.svc.GetFavorites(r.Context(), userID)
+	shows, err := h.svc.GetFavorites(r.Context(), profileID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -95,7 +97,7 @@ func (h *Handler) GetFavorites(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) ToggleFavorite(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
@@ -109,7 +111,7 @@ func (h *Handler) ToggleFavorite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.ToggleFavorite(r.Context(), userID, showID); err != nil {
+	if err := h.svc.ToggleFavorite(r.Context(), profileID, showID); err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -118,7 +120,7 @@ func (h *Handler) ToggleFavorite(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UpdateTracking(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
@@ -138,7 +140,7 @@ func (h *Handler) UpdateTracking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.UpdateTracking(r.Context(), userID, showID, req); err != nil {
+	if err := h.svc.UpdateTracking(r.Context(), profileID, showID, req); err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -9,8 +9,9 @@ import (
 
 type UserTrackedShow struct {
 	ID                 uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	UserID             uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_show"`
-	ShowID             uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_show"`
+	UserID             uuid.UUID `gorm:"type:uuid;not null;index"`
+	ProfileID          uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_profile_show"`
+	ShowID             uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_profile_show"`
 	IsFavorite         bool      `gorm:"not null;default:false"`
 	NotifyNewEpisode   bool      `gorm:"not null;default:true"`
 	NotifyNewSeason    bool      `gorm:"not null;default:true"`
@@ -1,6 +1,7 @@
 package alert
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,41 +16,41 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Create(track *UserTrackedShow) error {
-	return r.db.Create(track).Error
+func (r *Repository) Create(ctx context.Context, track *UserTrackedShow) error {
+	return r.db.WithContext(ctx).Create(track).Error
 }
 
-func (r *Repository) FindByUserAndShow(userID, showID uuid.UUID) (*UserTrackedShow, error) {
+func (r *Repository) FindByProfileAndShow(ctx context.Context, profileID, showID uuid.UUID) (*UserTrackedShow, error) {
 	var track UserTrackedShow
-	if err := r.db.Where("user_id = ? AND show_id = ?", userID, showID).First(&track).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("profile_id = ? AND show_id = ?", profileID, showID).First(&track).Error; err != nil {
 		return nil, err
 	}
 	return &track, nil
 }
 
-func (r *Repository) GetAllByUser(userID uuid.UUID) ([]UserTrackedShow, error) {
+func (r *Repository) GetAllByProfile(ctx context.Context, profileID uuid.UUID) ([]UserTrackedShow, error) {
 	var tracks []UserTrackedShow
-	if err := r.db.Preload("Show").Where("user_id = ?", userID).Find(&tracks).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Show").Where("profile_id = ?", profileID).Find(&tracks).Error; err != nil {
 		return nil, err
 	}
 	return tracks, nil
 }
 
-func (r *Repository) GetFavorites(userID uuid.UUID) ([]UserTrackedShow, error) {
+func (r *Repository) GetFavorites(ctx context.Context, profileID uuid.UUID) ([]UserTrackedShow, error) {
 	var tracks []UserTrackedShow
-	if err := r.db.Preload("Show").Where("user_id = ? AND is_favorite = ?", userID, true).Find(&tracks).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Show").Where("profile_id = ? AND is_favorite = ?", profileID, true).Find(&tracks).Error; err != nil {
 		return nil, err
 	}
 	return tracks, nil
 }
 
-func (r *Repository) Update(track *UserTrackedShow) error {
-	return r.db.Save(track).Error
+func (r *Repository) Update(ctx context.Context, track *UserTrackedShow) error {
+	return r.db.WithContext(ctx).Save(track).Error
 }
 
-func (r *Repository) UpdateLastWatched(userID, showID uuid.UUID, seasonNumber, episodeNumber int) error {
-	return r.db.Model(&UserTrackedShow{}).
-		Where("user_id = ? AND show_id = ?", userID, showID).
+func (r *Repository) UpdateLastWatched(ctx context.Context, profileID, showID uuid.UUID, seasonNumber, episodeNumber int) error {
+	return r.db.WithContext(ctx).Model(&UserTrackedShow{}).
+		Where("profile_id = ? AND show_id = ?", profileID, showID).
 		Updates(map[string]interface{}{
 			"last_watched_season":  seasonNumber,
 			"last_watched_episode": episodeNumber,
@@ -57,21 +58,21 @@ func (r *Repository) UpdateLastWatched(userID, showID uuid.UUID, seasonNumber, e
 		}).Error
 }
 
-func (r *Repository) Delete(userID, showID uuid.UUID) error {
-	return r.db.Where("user_id = ? AND show_id = ?", userID, showID).Delete(&UserTrackedShow{}).Error
+func (r *Repository) Delete(ctx context.Context, profileID, showID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("profile_id = ? AND show_id = ?", profileID, showID).Delete(&UserTrackedShow{}).Error
 }
 
-func (r *Repository) GetUsersTrackingShow(showID uuid.UUID) ([]UserTrackedShow, error) {
+func (r *Repository) GetUsersTrackingShow(ctx context.Context, showID uuid.UUID) ([]UserTrackedShow, error) {
 	var tracks []UserTrackedShow
-	if err := r.db.Where("show_id = ?", showID).Find(&tracks).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("show_id = ?", showID).Find(&tracks).Error; err != nil {
 		return nil, err
 	}
 	return tracks, nil
 }
 
-func (r *Repository) GetDistinctTrackedShowIDs() ([]uuid.UUID, error) {
+func (r *Repository) GetDistinctTrackedShowIDs(ctx context.Context) ([]uuid.UUID, error) {
 	var ids []uuid.UUID
-	if err := r.db.Model(&UserTrackedShow{}).Distinct("show_id").Pluck("show_id", &ids).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&UserTrackedShow{}).Distinct("show_id").Pluck("show_id", &ids).Error; err != nil {
 		return nil, err
 	}
 	return ids, nil
@@ -3,35 +3,37 @@ package alert
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
-	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
 	"github.com/tashifkhan/bingebeacon/internal/show"
+	"github.com/tashifkhan/bingebeacon/internal/social"
+	"github.com/tashifkhan/bingebeacon/internal/user"
 )
 
 type ShowSyncer interface {
 	SyncShow(ctx context.Context, showID uuid.UUID) error
-	QueueShowNotifications(ctx context.Context, userID, showID uuid.UUID) error
+	QueueShowNotifications(ctx context.Context, profileID, showID uuid.UUID) error
 }
 
 type Service struct {
-	repo     *Repository
-	showSvc  *show.Service
-	showRepo *show.Repository
-	syncer   ShowSyncer
-	redis    *redis.Client
+	repo      *Repository
+	showSvc   *show.Service
+	showRepo  *show.Repository
+	syncer    ShowSyncer
+	socialSvc *social.Service
+	userSvc   *user.Service
 }
 
-func NewService(repo *Repository, showSvc *show.Service, showRepo *show.Repository, syncer ShowSyncer, rdb *redis.Client) *Service {
+func NewService(repo *Repository, showSvc *show.Service, showRepo *show.Repository, syncer ShowSyncer, socialSvc *social.Service, userSvc *user.Service) *Service {
 	return &Service{
-		repo:     repo,
-		showSvc:  showSvc,
-		showRepo: showRepo,
-		syncer:   syncer,
-		redis:    rdb,
+		repo:      repo,
+		showSvc:   showSvc,
+		showRepo:  showRepo,
+		syncer:    syncer,
+		socialSvc: socialSvc,
+		userSvc:   userSvc,
 	}
 }
 
@@ -69,36 +71,46 @@ type TrackedShowResponse struct {
 	NextEpisodeDate    string    `json:"next_episode_date,omitempty"` // For UI
 }
 
-func (s *Service) TrackShow(ctx context.Context, userID uuid.UUID, req TrackRequest) error {
+func (s *Service) TrackShow(ctx context.Context, userID, profileID uuid.UUID, req TrackRequest) error {
+	ctx, span := tracing.Start(ctx, "alert.TrackShow")
+	defer span.End()
+
 	if req.NotifyHoursBefore != nil && (*req.NotifyHoursBefore < 0 || *req.NotifyHoursBefore > 168) {
 		return errors.New("notify_hours_before must be between 0 and 168")
 	}
-	var showID uuid.UUID
+	var target *show.Show
 
 	if req.ShowID != nil {
 		// Check if exists
-		if _, err := s.showRepo.FindByID(*req.ShowID); err != nil {
+		found, err := s.showRepo.FindByID(ctx, *req.ShowID)
+		if err != nil {
 			return errors.New("show not found")
 		}
-		showID = *req.ShowID
+		target = found
 	} else if req.TMDBID != nil {
 		// Get or Create
-		show, err := s.showSvc.GetOrCreateByTMDBID(ctx, *req.TMDBID, req.MediaType)
+		created, err := s.showSvc.GetOrCreateByTMDBID(ctx, *req.TMDBID, req.MediaType)
 		if err != nil {
 			return err
 		}
-		showID = show.ID
+		target = created
 	} else {
 		return errors.New("show_id or tmdb_id required")
 	}
+	showID := target.ID
+
+	if err := s.userSvc.CheckContentRating(ctx, profileID, target.Rated()); err != nil {
+		return err
+	}
 
 	// Check if already tracked
-	if _, err := s.repo.FindByUserAndShow(userID, showID); err == nil {
+	if _, err := s.repo.FindByProfileAndShow(ctx, profileID, showID); err == nil {
 		return errors.New("show already tracked")
 	}
 
 	track := &UserTrackedShow{
 		UserID:             userID,
+		ProfileID:          profileID,
 		ShowID:             showID,
 		IsFavorite:         DefaultBool(req.IsFavorite, false),
 		NotifyNewEpisode:   DefaultBool(req.NotifyNewEpisode, true),
@@ -107,39 +119,48 @@ func (s *Service) TrackShow(ctx context.Context, userID uuid.UUID, req TrackRequ
 		NotifyHoursBefore:  DefaultInt(req.NotifyHoursBefore, 0),
 	}
 
-	if err := s.repo.Create(track); err != nil {
+	if err := s.repo.Create(ctx, track); err != nil {
 		return err
 	}
-	_ = s.showRepo.BumpSyncPriority(showID)
+	_ = s.showRepo.BumpSyncPriority(ctx, showID)
+	_ = s.socialSvc.Record(ctx, &social.Activity{
+		UserID: userID, Type: social.ActivityTracked, ShowID: showID, SourceID: &track.ID,
+	})
 
 	// Trigger Sync asynchronously to not block response
 	go func() {
-		// Create a new context for background work
-		bgCtx := context.Background()
+		// Outlive the request but stay in its trace
+		bgCtx := context.WithoutCancel(ctx)
 		if err := s.syncer.SyncShow(bgCtx, showID); err == nil {
-			_ = s.syncer.QueueShowNotifications(bgCtx, userID, showID)
+			_ = s.syncer.QueueShowNotifications(bgCtx, profileID, showID)
 		}
 	}()
 
-	// Invalidate timeline cache for this user
-	cache.Invalidate(ctx, s.redis, fmt.Sprintf("timeline:%s:*", userID))
-
 	return nil
 }
 
-func (s *Service) UntrackShow(ctx context.Context, userID, showID uuid.UUID) error {
-	if err := s.repo.Delete(userID, showID); err != nil {
+func (s *Service) UntrackShow(ctx context.Context, userID, profileID, showID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "alert.UntrackShow")
+	defer span.End()
+
+	track, _ := s.repo.FindByProfileAndShow(ctx, profileID, showID)
+	if err := s.repo.Delete(ctx, profileID, showID); err != nil {
 		return err
 	}
-	// Invalidate timeline cache
-	return cache.Invalidate(ctx, s.redis, fmt.Sprintf("timeline:%s:*", userID))
+	if track != nil {
+		_ = s.socialSvc.RemoveBySource(ctx, userID, track.ID)
+	}
+	return nil
 }
 
-func (s *Service) UpdateTracking(ctx context.Context, userID, showID uuid.UUID, req UpdateTrackRequest) error {
+func (s *Service) UpdateTracking(ctx context.Context, profileID, showID uuid.UUID, req UpdateTrackRequest) error {
+	ctx, span := tracing.Start(ctx, "alert.UpdateTracking")
+	defer span.End()
+
 	if req.NotifyHoursBefore != nil && (*req.NotifyHoursBefore < 0 || *req.NotifyHoursBefore > 168) {
 		return errors.New("notify_hours_before must be between 0 and 168")
 	}
-	track, err := s.repo.FindByUserAndShow(userID, showID)
+	track, err := s.repo.FindByProfileAndShow(ctx, profileID, showID)
 	if err != nil {
 		return errors.New("tracked show not found")
 	}
@@ -161,33 +182,42 @@ func (s *Service) UpdateTracking(ctx context.Context, userID, showID uuid.UUID,
 	}
 	track.UpdatedAt = time.Now()
 
-	return s.repo.Update(track)
+	return s.repo.Update(ctx, track)
 }
 
-func (s *Service) GetTrackedShows(ctx context.Context, userID uuid.UUID) ([]TrackedShowResponse, error) {
-	tracks, err := s.repo.GetAllByUser(userID)
+func (s *Service) GetTrackedShows(ctx context.Context, profileID uuid.UUID) ([]TrackedShowResponse, error) {
+	ctx, span := tracing.Start(ctx, "alert.GetTrackedShows")
+	defer span.End()
+
+	tracks, err := s.repo.GetAllByProfile(ctx, profileID)
 	if err != nil {
 		return nil, err
 	}
 	return s.mapTracks(tracks), nil
 }
 
-func (s *Service) GetFavorites(ctx context.Context, userID uuid.UUID) ([]TrackedShowResponse, error) {
-	tracks, err := s.repo.GetFavorites(userID)
+func (s *Service) GetFavorites(ctx context.Context, profileID uuid.UUID) ([]TrackedShowResponse, error) {
+	ctx, span := tracing.Start(ctx, "alert.GetFavorites")
+	defer span.End()
+
+	tracks, err := s.repo.GetFavorites(ctx, profileID)
 	if err != nil {
 		return nil, err
 	}
 	return s.mapTracks(tracks), nil
 }
 
-func (s *Service) ToggleFavorite(ctx context.Context, userID, showID uuid.UUID) error {
-	track, err := s.repo.FindByUserAndShow(userID, showID)
+func (s *Service) ToggleFavorite(ctx context.Context, profileID, showID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "alert.ToggleFavorite")
+	defer span.End()
+
+	track, err := s.repo.FindByProfileAndShow(ctx, profileID, showID)
 	if err != nil {
 		return errors.New("tracked show not found")
 	}
 	track.IsFavorite = !track.IsFavorite
 	track.UpdatedAt = time.Now()
-	return s.repo.Update(track)
+	return s.repo.Update(ctx, track)
 }
 
 func (s *Service) mapTracks(tracks []UserTrackedShow) []TrackedShowResponse {
@@ -1,24 +1,34 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/tashifkhan/bingebeacon/internal/config"
+	appctx "github.com/tashifkhan/bingebeacon/internal/pkg/context"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/httputil"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/ratelimit"
 )
 
 type Handler struct {
 	svc         *Service
 	cfg         config.JWTConfig
 	environment string
+	successURL  string
 }
 
-func NewHandler(svc *Service, cfg config.JWTConfig, environment string) *Handler {
-	return &Handler{svc: svc, cfg: cfg, environment: environment}
+func NewHandler(svc *Service, cfg config.JWTConfig, environment, successURL string) *Handler {
+	return &Handler{svc: svc, cfg: cfg, environment: environment, successURL: successURL}
 }
 
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
@@ -28,7 +38,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenPair, err := h.svc.Register(req)
+	tokenPair, err := h.svc.Register(r.Context(), req, sessionMeta(r))
 	if err != nil {
 		httputil.Error(w, http.StatusBadRequest, err.Error())
 		return
@@ -44,15 +54,164 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenPair, err := h.svc.Login(req)
+	result, err := h.svc.Login(r.Context(), req, sessionMeta(r))
 	if err != nil {
-		httputil.Error(w, http.StatusUnauthorized, err.Error())
+		writeAuthError(w, err)
+		return
+	}
+
+	h.writeLoginResult(w, r, result)
+}
+
+// VerifyMFA is the second step of login for accounts with 2FA.
+func (h *Handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req MFAVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tokenPair, err := h.svc.VerifyMFA(r.Context(), req, sessionMeta(r))
+	if err != nil {
+		writeAuthError(w, err)
 		return
 	}
 
 	h.writeTokenPair(w, r, http.StatusOK, tokenPair)
 }
 
+// EnrollMFA starts TOTP setup for an admin whose login was held back until
+// they enroll.
+func (h *Handler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	var req MFAEnrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	enrollment, err := h.svc.BeginChallengeEnrollment(r.Context(), req)
+	if err != nil {
+		httputil.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	httputil.JSON(w, http.StatusOK, enrollment)
+}
+
+// ConfirmMFAEnrollment enables TOTP from a login challenge and returns the
+// token pair together with the recovery codes.
+func (h *Handler) ConfirmMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	var req MFAEnrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := h.svc.ConfirmChallengeEnrollment(r.Context(), req, sessionMeta(r))
+	if err != nil {
+		httputil.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	codes.TokenPair = h.deliverTokens(w, r, codes.TokenPair)
+	w.Header().Set("Cache-Control", "no-store")
+	httputil.JSON(w, http.StatusOK, codes)
+}
+
+func (h *Handler) MFAStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	status, err := h.svc.MFAStatus(r.Context(), userID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) BeginTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	enrollment, err := h.svc.BeginTOTPEnrollment(r.Context(), userID)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	httputil.JSON(w, http.StatusOK, enrollment)
+}
+
+func (h *Handler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := h.svc.ConfirmTOTPEnrollment(r.Context(), userID, req.Code)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	httputil.JSON(w, http.StatusOK, codes)
+}
+
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.svc.DisableTOTP(r.Context(), userID, req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := h.svc.RegenerateRecoveryCodes(r.Context(), userID, req)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	httputil.JSON(w, http.StatusOK, codes)
+}
+
 func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		RefreshToken string `json:"refresh_token"`
@@ -71,7 +230,7 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenPair, err := h.svc.RefreshToken(req.RefreshToken)
+	tokenPair, err := h.svc.RefreshToken(r.Context(), req.RefreshToken, sessionMeta(r))
 	if err != nil {
 		httputil.Error(w, http.StatusUnauthorized, err.Error())
 		return
@@ -80,6 +239,395 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	h.writeTokenPair(w, r, http.StatusOK, tokenPair)
 }
 
+func (h *Handler) SelectProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	sessionID, _ := appctx.SessionID(r.Context())
+
+	var req SelectProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tokenPair, err := h.svc.SelectProfile(r.Context(), userID, sessionID, req, sessionMeta(r))
+	if err != nil {
+		httputil.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeTokenPair(w, r, http.StatusOK, tokenPair)
+}
+
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+	sessionID, _ := appctx.SessionID(r.Context())
+
+	sessions, err := h.svc.ListSessions(r.Context(), userID, sessionID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, sessions)
+}
+
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	sessionID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if err := h.svc.RevokeSession(r.Context(), userID, sessionID); err != nil {
+		httputil.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if current, _ := appctx.SessionID(r.Context()); current == sessionID {
+		h.clearRefreshCookie(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAllSessions is "sign out everywhere", including this device.
+func (h *Handler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	if err := h.svc.RevokeAllSessions(r.Context(), userID); err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.clearRefreshCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListPersonalTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	tokens, err := h.svc.ListPersonalTokens(r.Context(), userID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, tokens)
+}
+
+func (h *Handler) CreatePersonalToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+	profileID, _ := appctx.ProfileID(r.Context())
+
+	var req CreatePersonalTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	token, err := h.svc.CreatePersonalToken(r.Context(), userID, profileID, req)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	httputil.JSON(w, http.StatusCreated, token)
+}
+
+func (h *Handler) RevokePersonalToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	tokenID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	if err := h.svc.RevokePersonalToken(r.Context(), userID, tokenID); err != nil {
+		httputil.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Always 202, whether or not the address has an account.
+	h.svc.RequestPasswordReset(r.Context(), req)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.svc.ResetPassword(r.Context(), req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.clearRefreshCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+	profileID, _ := appctx.ProfileID(r.Context())
+	sessionID, _ := appctx.SessionID(r.Context())
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tokenPair, err := h.svc.ChangePassword(r.Context(), userID, profileID, sessionID, req, sessionMeta(r))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeTokenPair(w, r, http.StatusOK, tokenPair)
+}
+
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	if err := h.svc.ResendVerification(r.Context(), userID); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.svc.VerifyEmail(r.Context(), req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	var req ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.svc.RequestEmailChange(r.Context(), userID, req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) OIDCProviders(w http.ResponseWriter, r *http.Request) {
+	httputil.JSON(w, http.StatusOK, map[string][]string{"providers": h.svc.OIDCProviders()})
+}
+
+// OIDCLogin redirects the browser to the identity provider.
+func (h *Handler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, state, err := h.svc.BeginOIDC(r.Context(), mux.Vars(r)["provider"], nil)
+	if err != nil {
+		httputil.Error(w, oidcErrorStatus(err), err.Error())
+		return
+	}
+	h.setOIDCStateCookie(w, state)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCLink returns the provider URL for a signed-in user adding an identity;
+// the client navigates there itself since the redirect cannot carry the
+// bearer token.
+func (h *Handler) OIDCLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	authURL, state, err := h.svc.BeginOIDC(r.Context(), mux.Vars(r)["provider"], &userID)
+	if err != nil {
+		httputil.Error(w, oidcErrorStatus(err), err.Error())
+		return
+	}
+	h.setOIDCStateCookie(w, state)
+	httputil.JSON(w, http.StatusOK, map[string]string{"authorization_url": authURL})
+}
+
+// OIDCCallback finishes the provider redirect and sends the browser back to
+// the app with a one-time ticket (or an error) in the query string. The
+// state must match the cookie set when this browser started the flow, so a
+// callback URL from someone else's sign-in is refused.
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cookie, err := r.Cookie(oidcStateCookie)
+	h.clearOIDCStateCookie(w)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(hashOIDCState(q.Get("state")))) != 1 {
+		h.redirectOIDC(w, r, url.Values{"error": {"sign-in was not started in this browser; please try again"}})
+		return
+	}
+	if providerErr := q.Get("error"); providerErr != "" {
+		h.redirectOIDC(w, r, url.Values{"error": {providerErr}})
+		return
+	}
+
+	result, err := h.svc.CompleteOIDC(r.Context(), mux.Vars(r)["provider"], q.Get("code"), q.Get("state"))
+	if err != nil {
+		h.redirectOIDC(w, r, url.Values{"error": {err.Error()}})
+		return
+	}
+	if result.Linked {
+		h.redirectOIDC(w, r, url.Values{"linked": {mux.Vars(r)["provider"]}})
+		return
+	}
+	h.redirectOIDC(w, r, url.Values{"ticket": {result.Ticket}})
+}
+
+// OIDCExchange trades a login ticket for a token pair, using the same
+// cookie-or-body delivery as password login.
+func (h *Handler) OIDCExchange(w http.ResponseWriter, r *http.Request) {
+	var req OIDCExchangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Ticket == "" {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	result, err := h.svc.ExchangeOIDCTicket(r.Context(), req.Ticket, sessionMeta(r))
+	if err != nil {
+		httputil.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	h.writeLoginResult(w, r, result)
+}
+
+func (h *Handler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	identities, err := h.svc.ListIdentities(r.Context(), userID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.JSON(w, http.StatusOK, identities)
+}
+
+func (h *Handler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	identityID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid identity ID")
+		return
+	}
+
+	if err := h.svc.UnlinkIdentity(r.Context(), userID, identityID); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) redirectOIDC(w http.ResponseWriter, r *http.Request, params url.Values) {
+	if h.successURL == "" {
+		if msg := params.Get("error"); msg != "" {
+			httputil.Error(w, http.StatusUnauthorized, msg)
+			return
+		}
+		httputil.JSON(w, http.StatusOK, params)
+		return
+	}
+	target := h.successURL
+	if strings.Contains(target, "?") {
+		target += "&" + params.Encode()
+	} else {
+		target += "?" + params.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// writeAuthError answers a failed sign-in, telling locked-out clients when
+// to retry.
+func writeAuthError(w http.ResponseWriter, err error) {
+	var locked *LockedError
+	if errors.As(err, &locked) {
+		ratelimit.RetryAfter(w, locked.RetryAfter)
+		httputil.Error(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	httputil.Error(w, http.StatusUnauthorized, err.Error())
+}
+
+func oidcErrorStatus(err error) int {
+	if errors.Is(err, ErrUnknownProvider) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadGateway
+}
+
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		RefreshToken string `json:"refresh_token"`
@@ -95,7 +643,7 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.RefreshToken != "" {
-		if err := h.svc.Logout(req.RefreshToken); err != nil {
+		if err := h.svc.Logout(r.Context(), req.RefreshToken); err != nil {
 			httputil.Error(w, http.StatusInternalServerError, err.Error())
 			return
 		}
@@ -104,15 +652,111 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *Handler) writeTokenPair(w http.ResponseWriter, r *http.Request, status int, tokenPair *TokenPair) {
-	if r.Header.Get("X-Client-Platform") == "web" {
-		h.setRefreshCookie(w, tokenPair.RefreshToken)
-		response := *tokenPair
-		response.RefreshToken = ""
-		httputil.JSON(w, status, &response)
+// sessionMeta describes the calling client. Apps may name themselves with
+// X-Device-Name; otherwise a name is derived from the user agent.
+func sessionMeta(r *http.Request) SessionMeta {
+	userAgent := truncate(r.UserAgent(), 512)
+	deviceName := truncate(strings.TrimSpace(r.Header.Get("X-Device-Name")), 100)
+	if deviceName == "" {
+		deviceName = describeUserAgent(userAgent)
+	}
+	return SessionMeta{DeviceName: deviceName, UserAgent: userAgent, IPAddress: httputil.ClientIP(r)}
+}
+
+var (
+	browserNames = []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	}
+	platformNames = []struct{ token, name string }{
+		{"iPhone", "iPhone"}, {"iPad", "iPad"}, {"Android", "Android"},
+		{"Windows", "Windows"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"},
+	}
+)
+
+// describeUserAgent turns a user agent into a short label such as
+// "Firefox on Windows". Order matters: Chrome's UA also mentions Safari.
+func describeUserAgent(ua string) string {
+	var browser, platform string
+	for _, b := range browserNames {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, p := range platformNames {
+		if strings.Contains(ua, p.token) {
+			platform = p.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	default:
+		return platform
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+// writeLoginResult sends either the token pair or the MFA challenge that
+// stands in for it.
+func (h *Handler) writeLoginResult(w http.ResponseWriter, r *http.Request, result *LoginResult) {
+	if result.Challenge != nil {
+		w.Header().Set("Cache-Control", "no-store")
+		httputil.JSON(w, http.StatusOK, result.Challenge)
 		return
 	}
-	httputil.JSON(w, status, tokenPair)
+	h.writeTokenPair(w, r, http.StatusOK, result.Tokens)
+}
+
+func (h *Handler) writeTokenPair(w http.ResponseWriter, r *http.Request, status int, tokenPair *TokenPair) {
+	httputil.JSON(w, status, h.deliverTokens(w, r, tokenPair))
+}
+
+// deliverTokens moves the refresh token into a cookie for the web client
+// and returns the pair to put in the response body.
+func (h *Handler) deliverTokens(w http.ResponseWriter, r *http.Request, tokenPair *TokenPair) *TokenPair {
+	if r.Header.Get("X-Client-Platform") != "web" {
+		return tokenPair
+	}
+	h.setRefreshCookie(w, tokenPair.RefreshToken)
+	response := *tokenPair
+	response.RefreshToken = ""
+	return &response
+}
+
+const oidcStateCookie = "bb_oidc_state"
+
+// setOIDCStateCookie binds an OIDC flow to the browser that started it. Lax
+// lets the cookie ride along on the provider's top-level redirect back.
+func (h *Handler) setOIDCStateCookie(w http.ResponseWriter, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name: oidcStateCookie, Value: hashOIDCState(state), Path: "/api/v1/auth/oidc",
+		HttpOnly: true, Secure: h.environment == "production", SameSite: http.SameSiteLaxMode,
+		MaxAge: int(oidcStateTTL.Seconds()), Expires: time.Now().Add(oidcStateTTL),
+	})
+}
+
+func (h *Handler) clearOIDCStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name: oidcStateCookie, Value: "", Path: "/api/v1/auth/oidc",
+		HttpOnly: true, Secure: h.environment == "production",
+		SameSite: http.SameSiteLaxMode, MaxAge: -1, Expires: time.Unix(0, 0),
+	})
+}
+
+func hashOIDCState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
 }
 
 func (h *Handler) setRefreshCookie(w http.ResponseWriter, token string) {
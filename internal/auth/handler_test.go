@@ -3,6 +3,7 @@ package auth
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -11,7 +12,7 @@ import (
 )
 
 func TestWebTokenPairUsesHTTPOnlyRefreshCookie(t *testing.T) {
-	handler := NewHandler(nil, config.JWTConfig{RefreshTokenTTL: 7 * 24 * time.Hour}, "production")
+	handler := NewHandler(nil, config.JWTConfig{RefreshTokenTTL: 7 * 24 * time.Hour}, "production", "")
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
 	req.Header.Set("X-Client-Platform", "web")
 	res := httptest.NewRecorder()
@@ -35,3 +36,67 @@ func TestWebTokenPairUsesHTTPOnlyRefreshCookie(t *testing.T) {
 		t.Fatalf("refresh cookie is missing production security attributes: %#v", cookie)
 	}
 }
+
+func TestDescribeUserAgent(t *testing.T) {
+	cases := map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36":                             "Chrome on Windows",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15":                      "Safari on macOS",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1": "Safari on iPhone",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0":                                                                  "Firefox on Linux",
+		"okhttp/4.12.0": "",
+	}
+	for ua, want := range cases {
+		if got := describeUserAgent(ua); got != want {
+			t.Errorf("describeUserAgent(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	// The service is never reached: a callback that this browser did not
+	// start is refused before the code is redeemed.
+	handler := NewHandler(nil, config.JWTConfig{}, "production", "https://app.example.com/auth/callback")
+	callback := "/api/v1/auth/oidc/google/callback?code=attacker-code&state=attacker-state"
+
+	for name, cookie := range map[string]*http.Cookie{
+		"missing":    nil,
+		"mismatched": {Name: oidcStateCookie, Value: hashOIDCState("victim-state")},
+	} {
+		req := httptest.NewRequest(http.MethodGet, callback, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		res := httptest.NewRecorder()
+		handler.OIDCCallback(res, req)
+
+		if res.Code != http.StatusFound {
+			t.Fatalf("%s cookie: expected redirect, got %d", name, res.Code)
+		}
+		location, _ := url.Parse(res.Header().Get("Location"))
+		if location.Query().Get("error") == "" || location.Query().Get("ticket") != "" {
+			t.Fatalf("%s cookie: callback was not rejected: %s", name, location)
+		}
+		cleared := res.Result().Cookies()
+		if len(cleared) != 1 || cleared[0].Name != oidcStateCookie || cleared[0].MaxAge >= 0 {
+			t.Fatalf("%s cookie: state cookie not cleared: %#v", name, cleared)
+		}
+	}
+}
+
+func TestOIDCStateCookieAttributes(t *testing.T) {
+	handler := NewHandler(nil, config.JWTConfig{}, "production", "")
+	res := httptest.NewRecorder()
+	handler.setOIDCStateCookie(res, "state-value")
+
+	cookies := res.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected one cookie, got %d", len(cookies))
+	}
+	cookie := cookies[0]
+	if cookie.Value != hashOIDCState("state-value") || strings.Contains(cookie.Value, "state-value") {
+		t.Fatalf("cookie must hold the state's hash: %#v", cookie)
+	}
+	if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("state cookie is missing security attributes: %#v", cookie)
+	}
+}
@@ -0,0 +1,332 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
+	"github.com/tashifkhan/bingebeacon/internal/user"
+)
+
+// VerifyMFA completes a two-step login with an authenticator code or a
+// recovery code.
+func (s *Service) VerifyMFA(ctx context.Context, req MFAVerifyRequest, meta SessionMeta) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "auth.VerifyMFA")
+	defer span.End()
+
+	userID, jti, err := s.parseMFAChallenge(req.MFAToken, mfaPurposeVerify)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recordMFAAttempt(ctx, jti); err != nil {
+		return nil, err
+	}
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.TwoFactorEnabled() {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	if err := s.checkLockout(ctx, u.Email); err != nil {
+		return nil, err
+	}
+	// Failed codes count towards the account lockout, so fresh challenges
+	// from repeated logins do not reset the guessing budget.
+	if err := s.checkSecondFactor(ctx, u, req.Code, req.RecoveryCode); err != nil {
+		s.recordLoginFailure(ctx, u.Email)
+		return nil, err
+	}
+	s.spendMFAChallenge(ctx, jti)
+	s.clearLoginFailures(ctx, u.Email)
+	return s.startSession(ctx, u.ID, u.ID, meta, true)
+}
+
+// BeginChallengeEnrollment starts TOTP setup for an admin who was stopped
+// at login because they have not enrolled yet.
+func (s *Service) BeginChallengeEnrollment(ctx context.Context, req MFAEnrollRequest) (*TOTPEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "auth.BeginChallengeEnrollment")
+	defer span.End()
+
+	userID, _, err := s.parseMFAChallenge(req.MFAToken, mfaPurposeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	return s.BeginTOTPEnrollment(ctx, userID)
+}
+
+// ConfirmChallengeEnrollment enables TOTP from a login challenge and
+// completes the sign-in.
+func (s *Service) ConfirmChallengeEnrollment(ctx context.Context, req MFAEnrollRequest, meta SessionMeta) (*RecoveryCodes, error) {
+	ctx, span := tracing.Start(ctx, "auth.ConfirmChallengeEnrollment")
+	defer span.End()
+
+	userID, jti, err := s.parseMFAChallenge(req.MFAToken, mfaPurposeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recordMFAAttempt(ctx, jti); err != nil {
+		return nil, err
+	}
+	codes, err := s.ConfirmTOTPEnrollment(ctx, userID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	s.spendMFAChallenge(ctx, jti)
+
+	tokens, err := s.startSession(ctx, userID, userID, meta, true)
+	if err != nil {
+		return nil, err
+	}
+	codes.TokenPair = tokens
+	return codes, nil
+}
+
+func (s *Service) MFAStatus(ctx context.Context, userID uuid.UUID) (*MFAStatus, error) {
+	ctx, span := tracing.Start(ctx, "auth.MFAStatus")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &MFAStatus{
+		Enabled:  u.TwoFactorEnabled(),
+		Required: u.Role == user.RoleAdmin,
+	}
+	if status.Enabled {
+		status.EnabledAt = u.TOTPEnabledAt
+		if status.RecoveryCodesRemaining, err = s.repo.CountRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// BeginTOTPEnrollment stores a new pending secret. It only takes effect
+// once ConfirmTOTPEnrollment sees a code generated from it.
+func (s *Service) BeginTOTPEnrollment(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "auth.BeginTOTPEnrollment")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.TwoFactorEnabled() {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret := generateTOTPSecret()
+	u.TOTPSecret = &secret
+	u.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{Secret: secret, URI: totpURI(u.Email, secret)}, nil
+}
+
+// ConfirmTOTPEnrollment enables 2FA and returns the first set of recovery
+// codes.
+func (s *Service) ConfirmTOTPEnrollment(ctx context.Context, userID uuid.UUID, code string) (*RecoveryCodes, error) {
+	ctx, span := tracing.Start(ctx, "auth.ConfirmTOTPEnrollment")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.TwoFactorEnabled() {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if u.TOTPSecret == nil {
+		return nil, errors.New("start two-factor enrollment first")
+	}
+	if err := s.verifyUserTOTP(ctx, u, code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	u.TOTPEnabledAt = &now
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, u.ID)
+}
+
+// DisableTOTP turns 2FA off after checking the second factor. Admin
+// accounts must keep it.
+func (s *Service) DisableTOTP(ctx context.Context, userID uuid.UUID, req MFACodeRequest) error {
+	ctx, span := tracing.Start(ctx, "auth.DisableTOTP")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !u.TwoFactorEnabled() {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if u.Role == user.RoleAdmin {
+		return errors.New("admin accounts must keep two-factor authentication enabled")
+	}
+	if err := s.checkSecondFactor(ctx, u, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+
+	u.TOTPSecret = nil
+	u.TOTPEnabledAt = nil
+	u.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return err
+	}
+	return s.repo.DeleteRecoveryCodes(ctx, u.ID)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req MFACodeRequest) (*RecoveryCodes, error) {
+	ctx, span := tracing.Start(ctx, "auth.RegenerateRecoveryCodes")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.TwoFactorEnabled() {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	if err := s.verifyUserTOTP(ctx, u, req.Code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, u.ID)
+}
+
+func (s *Service) checkSecondFactor(ctx context.Context, u *user.User, code, recoveryCode string) error {
+	if recoveryCode == "" {
+		return s.verifyUserTOTP(ctx, u, code)
+	}
+	ok, err := s.repo.ConsumeRecoveryCode(ctx, u.ID, hashToken(normalizeRecoveryCode(recoveryCode)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid recovery code")
+	}
+	return nil
+}
+
+func (s *Service) verifyUserTOTP(ctx context.Context, u *user.User, code string) error {
+	if u.TOTPSecret == nil {
+		return ErrInvalidMFACode
+	}
+	step, ok := verifyTOTP(*u.TOTPSecret, code, time.Now(), u.TOTPLastStep)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	advanced, err := s.userRepo.AdvanceTOTPStep(ctx, u.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidMFACode
+	}
+	u.TOTPLastStep = step
+	return nil
+}
+
+func (s *Service) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) (*RecoveryCodes, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i] = generateRecoveryCode()
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// generateRecoveryCode returns a code such as "k7q2m-x9fdp": 50 random
+// bits, easy to read back from paper.
+func generateRecoveryCode() string {
+	b := make([]byte, 7)
+	rand.Read(b)
+	raw := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+	return raw[:5] + "-" + raw[5:]
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func (s *Service) issueMFAChallenge(userID uuid.UUID, purpose string) (*MFAChallenge, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id": userID.String(),
+		"sub":     userID.String(),
+		"type":    "mfa",
+		"purpose": purpose,
+		"jti":     uuid.NewString(),
+		"iat":     now.Unix(),
+		"exp":     now.Add(mfaChallengeTTL).Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.cfg.Secret))
+	if err != nil {
+		return nil, err
+	}
+	return &MFAChallenge{
+		MFARequired:        true,
+		EnrollmentRequired: purpose == mfaPurposeEnroll,
+		MFAToken:           token,
+		ExpiresIn:          int64(mfaChallengeTTL.Seconds()),
+	}, nil
+}
+
+func (s *Service) parseMFAChallenge(tokenStr, purpose string) (uuid.UUID, string, error) {
+	invalid := errors.New("invalid or expired MFA token; sign in again")
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.cfg.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return uuid.Nil, "", invalid
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["type"] != "mfa" || claims["purpose"] != purpose {
+		return uuid.Nil, "", invalid
+	}
+	userIDStr, _ := claims["user_id"].(string)
+	jti, _ := claims["jti"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil || jti == "" {
+		return uuid.Nil, "", invalid
+	}
+	return userID, jti, nil
+}
+
+// recordMFAAttempt caps the codes tried against one challenge, so its
+// five-minute lifetime cannot be used to guess six digits.
+func (s *Service) recordMFAAttempt(ctx context.Context, jti string) error {
+	key := "mfa:attempts:" + jti
+	attempts, _, err := s.cache.Incr(ctx, key, mfaChallengeTTL)
+	if err != nil {
+		return err
+	}
+	if attempts > maxMFAAttempts {
+		return errors.New("too many attempts; sign in again")
+	}
+	return nil
+}
+
+// spendMFAChallenge makes a redeemed challenge unusable for the rest of its
+// lifetime.
+func (s *Service) spendMFAChallenge(ctx context.Context, jti string) {
+	s.cache.Set(ctx, "mfa:attempts:"+jti, []byte(strconv.Itoa(maxMFAAttempts)), mfaChallengeTTL)
+}
@@ -1,8 +1,10 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,16 +12,30 @@ import (
 	"github.com/tashifkhan/bingebeacon/internal/config"
 	pkgctx "github.com/tashifkhan/bingebeacon/internal/pkg/context"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/httputil"
+	"github.com/tashifkhan/bingebeacon/internal/user"
 )
 
+// PersonalTokenVerifier resolves personal access tokens for the middleware.
+type PersonalTokenVerifier interface {
+	VerifyPersonalToken(ctx context.Context, raw string) (*PersonalAccessToken, error)
+}
+
 type Middleware struct {
-	cfg config.JWTConfig
+	cfg    config.JWTConfig
+	tokens PersonalTokenVerifier
 }
 
-func NewMiddleware(cfg config.JWTConfig) *Middleware {
-	return &Middleware{cfg: cfg}
+func NewMiddleware(cfg config.JWTConfig, tokens PersonalTokenVerifier) *Middleware {
+	return &Middleware{cfg: cfg, tokens: tokens}
 }
 
+type scopeKey struct{}
+
+type mfaKey struct{}
+
+// Authenticate accepts access tokens and personal access tokens. Personal
+// tokens are only accepted on routes wrapped with Scoped, and only when
+// they grant that route's scope.
 func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -28,49 +44,182 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			httputil.Error(w, http.StatusUnauthorized, "Invalid authorization header format")
+		if raw, ok := personalToken(authHeader); ok {
+			m.authenticatePersonalToken(w, r, raw, next)
+			return
+		}
+
+		claims, err := m.parseAccessToken(authHeader)
+		if err != nil {
+			httputil.Error(w, http.StatusUnauthorized, err.Error())
 			return
 		}
 
-		tokenString := parts[1]
+		next.ServeHTTP(w, r.WithContext(claims.attach(r.Context())))
+	})
+}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-				return nil, errors.New("unexpected signing method")
+// Scoped authenticates a route group that personal access tokens may use.
+// Reads need read:<resource>; any other method needs write:<resource>.
+func (m *Middleware) Scoped(resource string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authenticated := m.Authenticate(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope := "write:" + resource
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				scope = "read:" + resource
 			}
-			return []byte(m.cfg.Secret), nil
+			authenticated.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), scopeKey{}, scope)))
 		})
+	}
+}
 
-		if err != nil || !token.Valid {
-			httputil.Error(w, http.StatusUnauthorized, "Invalid or expired token")
-			return
-		}
+func (m *Middleware) authenticatePersonalToken(w http.ResponseWriter, r *http.Request, raw string, next http.Handler) {
+	if m.tokens == nil {
+		httputil.Error(w, http.StatusUnauthorized, "Personal access tokens are not enabled")
+		return
+	}
+	token, err := m.tokens.VerifyPersonalToken(r.Context(), raw)
+	if err != nil {
+		httputil.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			httputil.Error(w, http.StatusUnauthorized, "Invalid token claims")
-			return
-		}
-		if tokenType, ok := claims["type"].(string); !ok || tokenType != "access" {
-			httputil.Error(w, http.StatusUnauthorized, "Access token required")
-			return
-		}
+	scope, _ := r.Context().Value(scopeKey{}).(string)
+	if scope == "" {
+		httputil.Error(w, http.StatusForbidden, "Personal access tokens cannot be used on this route")
+		return
+	}
+	if !token.Allows(scope) {
+		httputil.Error(w, http.StatusForbidden, "Token is missing the "+scope+" scope")
+		return
+	}
 
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			httputil.Error(w, http.StatusUnauthorized, "Invalid user ID in token")
-			return
-		}
+	claims := accessClaims{UserID: token.UserID, ProfileID: token.ProfileID}
+	next.ServeHTTP(w, r.WithContext(claims.attach(r.Context())))
+}
 
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			httputil.Error(w, http.StatusUnauthorized, "Invalid user ID format")
-			return
+// OptionalAuthenticate attaches the user ID when a valid access token is
+// present and otherwise serves the request anonymously, so public routes can
+// personalise their response for signed-in callers. Personal access tokens
+// are treated as anonymous here.
+func (m *Middleware) OptionalAuthenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+			if _, isPersonal := personalToken(authHeader); !isPersonal {
+				if claims, err := m.parseAccessToken(authHeader); err == nil {
+					r = r.WithContext(claims.attach(r.Context()))
+				}
+			}
 		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func personalToken(authHeader string) (string, bool) {
+	raw, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok || !strings.HasPrefix(raw, personalTokenPrefix) {
+		return "", false
+	}
+	return raw, true
+}
+
+// RequireRole admits callers whose access token carries one of roles. It
+// must run after Authenticate. Admins must also have signed in with their
+// second factor.
+func (m *Middleware) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := pkgctx.Role(r.Context())
+			if !slices.Contains(roles, role) {
+				httputil.Error(w, http.StatusForbidden, "Insufficient role for this route")
+				return
+			}
+			if mfa, _ := r.Context().Value(mfaKey{}).(bool); role == user.RoleAdmin && !mfa {
+				httputil.Error(w, http.StatusForbidden, "Two-factor authentication required; sign in again")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-		ctx := pkgctx.WithUserID(r.Context(), userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+// accessClaims is the identity carried by a verified access token.
+type accessClaims struct {
+	UserID    uuid.UUID
+	ProfileID uuid.UUID
+	SessionID uuid.UUID
+	Role      string
+	MFA       bool
+}
+
+func (c *accessClaims) attach(ctx context.Context) context.Context {
+	ctx = pkgctx.WithUserID(ctx, c.UserID)
+	if c.SessionID != uuid.Nil {
+		ctx = pkgctx.WithSessionID(ctx, c.SessionID)
+	}
+	if c.Role != "" {
+		ctx = pkgctx.WithRole(ctx, c.Role)
+	}
+	ctx = context.WithValue(ctx, mfaKey{}, c.MFA)
+	return pkgctx.WithProfileID(ctx, c.ProfileID)
+}
+
+func (m *Middleware) parseAccessToken(authHeader string) (*accessClaims, error) {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, errors.New("Invalid authorization header format")
+	}
+
+	tokenString := parts[1]
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(m.cfg.Secret), nil
 	})
+
+	if err != nil || !token.Valid {
+		return nil, errors.New("Invalid or expired token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("Invalid token claims")
+	}
+	if tokenType, ok := claims["type"].(string); !ok || tokenType != "access" {
+		return nil, errors.New("Access token required")
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		return nil, errors.New("Invalid user ID in token")
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, errors.New("Invalid user ID format")
+	}
+
+	// Tokens issued before household profiles act as the primary profile.
+	profileID := userID
+	if profileIDStr, ok := claims["profile_id"].(string); ok {
+		if profileID, err = uuid.Parse(profileIDStr); err != nil {
+			return nil, errors.New("Invalid profile ID format")
+		}
+	}
+	var sessionID uuid.UUID
+	if sessionIDStr, ok := claims["sid"].(string); ok {
+		if sessionID, err = uuid.Parse(sessionIDStr); err != nil {
+			return nil, errors.New("Invalid session ID format")
+		}
+	}
+	// Tokens issued before roles existed act as regular users.
+	role, _ := claims["role"].(string)
+	if role == "" {
+		role = user.RoleUser
+	}
+	mfa, _ := claims["mfa"].(bool)
+	return &accessClaims{UserID: userID, ProfileID: profileID, SessionID: sessionID, Role: role, MFA: mfa}, nil
 }
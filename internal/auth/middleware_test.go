@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -15,7 +16,7 @@ import (
 func TestMiddlewareAcceptsAccessToken(t *testing.T) {
 	cfg := config.JWTConfig{Secret: "test-secret-that-is-at-least-32-characters"}
 	token := signedTestToken(t, cfg.Secret, "access")
-	middleware := NewMiddleware(cfg)
+	middleware := NewMiddleware(cfg, nil)
 
 	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if _, ok := appctx.UserID(r.Context()); !ok {
@@ -34,10 +35,34 @@ func TestMiddlewareAcceptsAccessToken(t *testing.T) {
 	}
 }
 
+func TestMiddlewareDefaultsToPrimaryProfile(t *testing.T) {
+	cfg := config.JWTConfig{Secret: "test-secret-that-is-at-least-32-characters"}
+	token := signedTestToken(t, cfg.Secret, "access")
+	middleware := NewMiddleware(cfg, nil)
+
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := appctx.UserID(r.Context())
+		profileID, ok := appctx.ProfileID(r.Context())
+		if !ok || profileID != userID {
+			t.Fatalf("expected primary profile %s, got %s", userID, profileID)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tracking", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d", http.StatusNoContent, res.Code)
+	}
+}
+
 func TestMiddlewareRejectsRefreshToken(t *testing.T) {
 	cfg := config.JWTConfig{Secret: "test-secret-that-is-at-least-32-characters"}
 	token := signedTestToken(t, cfg.Secret, "refresh")
-	middleware := NewMiddleware(cfg)
+	middleware := NewMiddleware(cfg, nil)
 
 	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Fatal("protected handler must not receive refresh tokens")
@@ -52,13 +77,94 @@ func TestMiddlewareRejectsRefreshToken(t *testing.T) {
 	}
 }
 
+type stubTokenVerifier struct {
+	token *PersonalAccessToken
+}
+
+func (s stubTokenVerifier) VerifyPersonalToken(context.Context, string) (*PersonalAccessToken, error) {
+	return s.token, nil
+}
+
+func TestMiddlewareEnforcesPersonalTokenScopes(t *testing.T) {
+	cfg := config.JWTConfig{Secret: "test-secret-that-is-at-least-32-characters"}
+	middleware := NewMiddleware(cfg, stubTokenVerifier{token: &PersonalAccessToken{
+		UserID: uuid.New(), ProfileID: uuid.New(), Scopes: []string{"write:history"},
+	}})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	cases := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		want    int
+	}{
+		{"write scope allows writes", middleware.Scoped("history")(ok), http.MethodPost, http.StatusNoContent},
+		{"write scope implies read", middleware.Scoped("history")(ok), http.MethodGet, http.StatusNoContent},
+		{"other resource is forbidden", middleware.Scoped("tracking")(ok), http.MethodGet, http.StatusForbidden},
+		{"unscoped route is forbidden", middleware.Authenticate(ok), http.MethodGet, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, "/api/v1/history", nil)
+		req.Header.Set("Authorization", "Bearer bbpat_example")
+		res := httptest.NewRecorder()
+		tc.handler.ServeHTTP(res, req)
+		if res.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, res.Code)
+		}
+	}
+}
+
+func TestRequireRoleEnforcesRoleAndAdminMFA(t *testing.T) {
+	cfg := config.JWTConfig{Secret: "test-secret-that-is-at-least-32-characters"}
+	middleware := NewMiddleware(cfg, nil)
+	handler := middleware.Authenticate(middleware.RequireRole("moderator", "admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	cases := []struct {
+		name string
+		role string
+		mfa  bool
+		want int
+	}{
+		{"regular user", "user", true, http.StatusForbidden},
+		{"moderator", "moderator", false, http.StatusNoContent},
+		{"admin with 2FA", "admin", true, http.StatusNoContent},
+		{"admin without 2FA", "admin", false, http.StatusForbidden},
+		{"token without role claim", "", false, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		claims := jwt.MapClaims{"mfa": tc.mfa}
+		if tc.role != "" {
+			claims["role"] = tc.role
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
+		req.Header.Set("Authorization", "Bearer "+signedTestTokenWithClaims(t, cfg.Secret, claims))
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, res.Code)
+		}
+	}
+}
+
 func signedTestToken(t *testing.T, secret, tokenType string) string {
+	t.Helper()
+	return signedTestTokenWithClaims(t, secret, jwt.MapClaims{"type": tokenType})
+}
+
+func signedTestTokenWithClaims(t *testing.T, secret string, extra jwt.MapClaims) string {
 	t.Helper()
 	claims := jwt.MapClaims{
 		"user_id": uuid.NewString(),
-		"type":    tokenType,
+		"type":    "access",
 		"exp":     time.Now().Add(time.Hour).Unix(),
 	}
+	for key, value := range extra {
+		claims[key] = value
+	}
 	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
 	if err != nil {
 		t.Fatalf("sign test token: %v", err)
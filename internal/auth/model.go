@@ -1,10 +1,11 @@
 package auth
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/tashifkhan/bingebeacon/internal/user"
+	"github.com/lib/pq"
 )
 
 type RegisterRequest struct {
@@ -24,11 +25,244 @@ type TokenPair struct {
 	ExpiresIn    int64  `json:"expires_in"`
 }
 
+// LoginResult is either a token pair or, for accounts that need a second
+// factor, a challenge to complete first.
+type LoginResult struct {
+	Tokens    *TokenPair
+	Challenge *MFAChallenge
+}
+
+// MFAChallenge is returned by login instead of a token pair. The MFA token
+// is redeemed at /auth/mfa/verify, or at /auth/mfa/enroll when an admin
+// must set up two-factor authentication before signing in.
+type MFAChallenge struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+	MFAToken           string `json:"mfa_token"`
+	ExpiresIn          int64  `json:"expires_in"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type MFAEnrollRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// MFACodeRequest proves possession of the second factor for account
+// changes, with either an authenticator code or a recovery code.
+type MFACodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// TOTPEnrollment is the secret to load into an authenticator app, either
+// typed in or scanned from the otpauth URI rendered as a QR code.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes are shown once, when 2FA is enabled or the codes are
+// regenerated. The token pair is included when enrollment also completed
+// a sign-in.
+type RecoveryCodes struct {
+	*TokenPair
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	Required               bool       `json:"required"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// RecoveryCode is a hashed single-use fallback for a lost authenticator.
+type RecoveryCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	CodeHash  string    `gorm:"type:text;not null;uniqueIndex"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (RecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+type SelectProfileRequest struct {
+	ProfileID uuid.UUID `json:"profile_id"`
+}
+
+// RefreshToken is one link in a session's rotation chain. Only a hash of
+// the token is stored; UsedAt is set when it is rotated, so presenting it
+// again is detectable as reuse.
 type RefreshToken struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
 	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
-	Token     string    `gorm:"type:text;not null;uniqueIndex"`
+	SessionID uuid.UUID `gorm:"type:uuid;not null;index"`
+	TokenHash string    `gorm:"type:text;not null;uniqueIndex"`
 	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
 	CreatedAt time.Time
-	User      user.User `gorm:"foreignKey:UserID"`
+}
+
+// Session is a signed-in device: one refresh-token family and the profile
+// it is using.
+type Session struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"-"`
+	ProfileID   *uuid.UUID `gorm:"type:uuid" json:"profile_id,omitempty"`
+	DeviceName  *string    `gorm:"type:text" json:"device_name,omitempty"`
+	UserAgent   *string    `gorm:"type:text" json:"user_agent,omitempty"`
+	IPAddress   *string    `gorm:"type:text" json:"ip_address,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  time.Time  `json:"last_used_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RevokedAt   *time.Time `json:"-"`
+	MFAVerified bool       `gorm:"not null;default:false" json:"mfa_verified"`
+	Current     bool       `gorm:"-" json:"current"`
+}
+
+// SessionMeta describes the client starting or refreshing a session.
+type SessionMeta struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+// UserIdentity links an account to a subject at an external identity
+// provider. An account may have several, and needs no password when it has
+// at least one.
+type UserIdentity struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"-"`
+	Provider    string     `gorm:"type:text;not null" json:"provider"`
+	Subject     string     `gorm:"type:text;not null" json:"-"`
+	Email       *string    `gorm:"type:text" json:"email,omitempty"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type OIDCExchangeRequest struct {
+	Ticket string `json:"ticket"`
+}
+
+// oidcState is kept in Redis between the redirect to the provider and the
+// callback. LinkUserID is set when a signed-in user is adding an identity.
+type oidcState struct {
+	Provider   string     `json:"provider"`
+	Nonce      string     `json:"nonce"`
+	Verifier   string     `json:"verifier"`
+	LinkUserID *uuid.UUID `json:"link_user_id,omitempty"`
+}
+
+// OIDCResult tells the callback handler where to send the browser: a
+// one-time login ticket, or confirmation that an identity was linked.
+type OIDCResult struct {
+	Ticket string
+	Linked bool
+}
+
+const (
+	purposePasswordReset = "password_reset"
+	purposeEmailVerify   = "email_verify"
+	purposeEmailChange   = "email_change"
+)
+
+// AccountToken is a single-use emailed token. Only its SHA-256 hash is
+// stored; NewEmail is set for email changes.
+type AccountToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Purpose   string    `gorm:"type:text;not null"`
+	TokenHash string    `gorm:"type:text;not null;uniqueIndex"`
+	NewEmail  *string   `gorm:"type:text"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email"`
+	Password string `json:"password"`
+}
+
+// applyMeta records the latest client details, keeping earlier values the
+// client did not send.
+func (s *Session) applyMeta(meta SessionMeta) {
+	if meta.DeviceName != "" {
+		s.DeviceName = &meta.DeviceName
+	}
+	if meta.UserAgent != "" {
+		s.UserAgent = &meta.UserAgent
+	}
+	if meta.IPAddress != "" {
+		s.IPAddress = &meta.IPAddress
+	}
+}
+
+// PersonalAccessToken lets scripts call the API without the JWT dance. It
+// acts as one profile and only on routes its scopes allow.
+type PersonalAccessToken struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID      `gorm:"type:uuid;not null;index" json:"-"`
+	ProfileID   uuid.UUID      `gorm:"type:uuid;not null" json:"profile_id"`
+	Name        string         `gorm:"type:text;not null" json:"name"`
+	TokenHash   string         `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	TokenPrefix string         `gorm:"type:text;not null" json:"token_prefix"`
+	Scopes      pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"scopes"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time     `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time     `json:"-"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// Allows reports whether the token grants scope. A write scope also grants
+// reading the same resource.
+func (t *PersonalAccessToken) Allows(scope string) bool {
+	for _, granted := range t.Scopes {
+		if granted == scope {
+			return true
+		}
+		if strings.HasPrefix(scope, "read:") && granted == "write:"+strings.TrimPrefix(scope, "read:") {
+			return true
+		}
+	}
+	return false
+}
+
+type CreatePersonalTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expires_in_days"` // omitted: 90 days; 0: never
+}
+
+// CreatedPersonalToken is returned once, at creation; only the hash is kept.
+type CreatedPersonalToken struct {
+	PersonalAccessToken
+	Token string `json:"token"`
 }
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tashifkhan/bingebeacon/internal/config"
+)
+
+// mockIssuer is a minimal OIDC provider: discovery, JWKS, and a token
+// endpoint that returns an ID token carrying the given nonce.
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	nonce  string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "test-key", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            m.server.URL,
+			"aud":            "client-id",
+			"sub":            "subject-123",
+			"email":          "viewer@example.com",
+			"email_verified": true,
+			"nonce":          m.nonce,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = "test-key"
+		signed, _ := token.SignedString(key)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access", "token_type": "Bearer", "id_token": signed,
+		})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func TestOIDCProviderExchangeAgainstMockIssuer(t *testing.T) {
+	issuer := newMockIssuer(t)
+	providers, err := NewIdentityProviders(config.OIDCConfig{
+		CallbackBaseURL: "http://localhost:8080/api/v1/auth/oidc",
+		Providers: []config.OIDCProviderConfig{{
+			Name: "mock", Issuer: issuer.server.URL, ClientID: "client-id", ClientSecret: "secret",
+		}},
+	}, issuer.server.Client())
+	if err != nil {
+		t.Fatalf("NewIdentityProviders: %v", err)
+	}
+	provider := providers["mock"]
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-0123456789-0123456789-0123456789")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	q := parsed.Query()
+	if q.Get("nonce") != "nonce-1" || q.Get("code_challenge_method") != "S256" ||
+		q.Get("redirect_uri") != "http://localhost:8080/api/v1/auth/oidc/mock/callback" {
+		t.Fatalf("unexpected authorization URL: %s", authURL)
+	}
+
+	issuer.nonce = "nonce-1"
+	identity, err := provider.Exchange(ctx, "good-code", "nonce-1", "verifier-0123456789-0123456789-0123456789")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Subject != "subject-123" || identity.Email != "viewer@example.com" || !identity.EmailVerified {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	issuer.nonce = "replayed"
+	if _, err := provider.Exchange(ctx, "good-code", "nonce-1", "verifier-0123456789-0123456789-0123456789"); err == nil {
+		t.Fatal("expected nonce mismatch to be rejected")
+	}
+}
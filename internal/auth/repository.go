@@ -1,8 +1,12 @@
 package auth
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
@@ -13,22 +17,223 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) CreateRefreshToken(token *RefreshToken) error {
-	return r.db.Create(token).Error
+func (r *Repository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
 }
 
-func (r *Repository) FindRefreshToken(tokenStr string) (*RefreshToken, error) {
+func (r *Repository) FindRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
 	var token RefreshToken
-	if err := r.db.Preload("User").Where("token = ?", tokenStr).First(&token).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
 		return nil, err
 	}
 	return &token, nil
 }
 
-func (r *Repository) DeleteRefreshToken(tokenStr string) error {
-	return r.db.Where("token = ?", tokenStr).Delete(&RefreshToken{}).Error
+// MarkRefreshTokenUsed rotates a token out. It reports false when another
+// request rotated it first.
+func (r *Repository) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&RefreshToken{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// RetireSessionTokens marks every unused token in a session used, so only a
+// token issued afterwards can refresh it.
+func (r *Repository) RetireSessionTokens(ctx context.Context, sessionID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&RefreshToken{}).Where("session_id = ? AND used_at IS NULL", sessionID).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *Repository) CreateSession(ctx context.Context, session *Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *Repository) FindSession(ctx context.Context, id uuid.UUID) (*Session, error) {
+	var session Session
+	if err := r.db.WithContext(ctx).First(&session, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *Repository) UpdateSession(ctx context.Context, session *Session) error {
+	return r.db.WithContext(ctx).Save(session).Error
+}
+
+func (r *Repository) GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	var sessions []Session
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession ends one session and drops its refresh tokens. It reports
+// whether an active session belonging to userID was found.
+func (r *Repository) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+	var revoked bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Session{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		revoked = result.RowsAffected > 0
+		return tx.Where("session_id = ?", sessionID).Delete(&RefreshToken{}).Error
+	})
+	return revoked, err
+}
+
+func (r *Repository) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&RefreshToken{}).Error
+	})
+}
+
+// DeleteExpiredSessions removes sessions that expired or were revoked
+// before cutoff, along with their tokens.
+func (r *Repository) DeleteExpiredSessions(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ? OR revoked_at < ?", cutoff, cutoff).Delete(&Session{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *Repository) FindIdentity(ctx context.Context, provider, subject string) (*UserIdentity, error) {
+	var identity UserIdentity
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *Repository) CreateIdentity(ctx context.Context, identity *UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *Repository) TouchIdentity(ctx context.Context, id uuid.UUID, email *string) error {
+	return r.db.WithContext(ctx).Model(&UserIdentity{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_login_at": time.Now(), "email": email}).Error
+}
+
+func (r *Repository) GetIdentities(ctx context.Context, userID uuid.UUID) ([]UserIdentity, error) {
+	var identities []UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+func (r *Repository) CountIdentities(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *Repository) DeleteIdentity(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&UserIdentity{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateAccountToken stores a new token, retiring any unused token the user
+// already had for the same purpose.
+func (r *Repository) CreateAccountToken(ctx context.Context, token *AccountToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Delete(&AccountToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// ConsumeAccountToken marks an unexpired token used and returns it. The
+// conditional update makes redemption single-use under concurrency.
+func (r *Repository) ConsumeAccountToken(ctx context.Context, tokenHash string, purposes ...string) (*AccountToken, error) {
+	var token AccountToken
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&token).Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose IN ? AND used_at IS NULL AND expires_at > ?", tokenHash, purposes, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &token, nil
+}
+
+func (r *Repository) CreatePersonalToken(ctx context.Context, token *PersonalAccessToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *Repository) FindPersonalToken(ctx context.Context, tokenHash string) (*PersonalAccessToken, error) {
+	var token PersonalAccessToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *Repository) GetPersonalTokens(ctx context.Context, userID uuid.UUID) ([]PersonalAccessToken, error) {
+	var tokens []PersonalAccessToken
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *Repository) CountPersonalTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&PersonalAccessToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *Repository) RevokePersonalToken(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// TouchPersonalToken records use at most once a minute, so busy scripts do
+// not turn every request into a write.
+func (r *Repository) TouchPersonalToken(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-time.Minute)).
+		Update("last_used_at", now).Error
+}
+
+// ReplaceRecoveryCodes swaps the user's recovery codes for a new set, so
+// earlier codes stop working.
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// ConsumeRecoveryCode marks an unused code used, reporting whether one
+// matched.
+func (r *Repository) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *Repository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
 }
 
-func (r *Repository) DeleteAllUserRefreshTokens(userID uuid.UUID) error {
-	return r.db.Where("user_id = ?", userID).Delete(&RefreshToken{}).Error
+func (r *Repository) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error
 }
@@ -1,34 +1,102 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math/big"
 	"net/mail"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/tashifkhan/bingebeacon/internal/config"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/email"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
 	"github.com/tashifkhan/bingebeacon/internal/user"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcStateTTL   = 10 * time.Minute
+	oidcTicketTTL  = 2 * time.Minute
+	resetTokenTTL  = time.Hour
+	verifyTokenTTL = 48 * time.Hour
+	changeTokenTTL = 24 * time.Hour
+
+	refreshReuseGrace = 10 * time.Second
+
+	mfaChallengeTTL   = 5 * time.Minute
+	maxMFAAttempts    = 5
+	recoveryCodeCount = 10
+
+	mfaPurposeVerify = "verify"
+	mfaPurposeEnroll = "enroll"
+
+	loginLockoutThreshold = 5
+	maxLoginLockout       = time.Hour
+	loginFailureWindow    = 24 * time.Hour
+
+	personalTokenPrefix     = "bbpat_"
+	maxPersonalTokens       = 25
+	defaultPersonalTokenTTL = 90
+	maxPersonalTokenTTL     = 365
+)
+
+// personalTokenResources are the route groups personal access tokens can be
+// scoped to, each as read:<resource> or write:<resource>.
+var personalTokenResources = map[string]bool{
+	"timeline": true, "tracking": true, "history": true, "watchlist": true,
+	"lists": true, "notifications": true, "recommendations": true,
+	"social": true, "streaming": true, "showtimes": true,
+}
+
+var (
+	ErrUnknownProvider   = errors.New("unknown identity provider")
+	ErrIdentityTaken     = errors.New("this identity is linked to another account")
+	ErrRefreshTokenReuse = errors.New("refresh token reuse detected; session signed out")
+	ErrInvalidMFACode    = errors.New("invalid authentication code")
+	ErrAccountDisabled   = errors.New("this account has been disabled")
+	ErrUnverifiedAccount = errors.New("an account with this email exists but is not verified; sign in with your password and link this provider from settings")
 )
 
 type Service struct {
-	repo     *Repository
-	userRepo *user.Repository
-	cfg      config.JWTConfig
+	repo      *Repository
+	userRepo  *user.Repository
+	cfg       config.JWTConfig
+	providers map[string]IdentityProvider
+	cache     cache.Store
+	mailer    email.Sender
+	appURL    string
 }
 
-func NewService(repo *Repository, userRepo *user.Repository, cfg config.JWTConfig) *Service {
+func NewService(repo *Repository, userRepo *user.Repository, cfg config.JWTConfig, providers map[string]IdentityProvider, store cache.Store, mailer email.Sender, appURL string) *Service {
 	return &Service{
-		repo:     repo,
-		userRepo: userRepo,
-		cfg:      cfg,
+		repo:      repo,
+		userRepo:  userRepo,
+		cfg:       cfg,
+		providers: providers,
+		cache:     store,
+		mailer:    mailer,
+		appURL:    strings.TrimRight(appURL, "/"),
 	}
 }
 
-func (s *Service) Register(req RegisterRequest) (*TokenPair, error) {
+func (s *Service) Register(ctx context.Context, req RegisterRequest, meta SessionMeta) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "auth.Register")
+	defer span.End()
+
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 	req.Username = strings.TrimSpace(req.Username)
 	if _, err := mail.ParseAddress(req.Email); err != nil {
@@ -37,11 +105,11 @@ func (s *Service) Register(req RegisterRequest) (*TokenPair, error) {
 	if len(req.Username) < 3 || len(req.Username) > 30 {
 		return nil, errors.New("username must be 3-30 characters")
 	}
-	if len(req.Password) < 8 || len(req.Password) > 72 {
-		return nil, errors.New("password must be 8-72 characters")
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
 	}
 	// Check if user exists
-	if _, err := s.userRepo.FindByEmail(req.Email); err == nil {
+	if _, err := s.userRepo.FindByEmail(ctx, req.Email); err == nil {
 		return nil, errors.New("email already registered")
 	}
 
@@ -56,60 +124,872 @@ func (s *Service) Register(req RegisterRequest) (*TokenPair, error) {
 		PasswordHash: string(hashedPassword),
 	}
 
-	if err := s.userRepo.Create(newUser); err != nil {
+	if err := s.userRepo.Create(ctx, newUser); err != nil {
 		return nil, err
 	}
+	if err := s.sendVerification(ctx, newUser.ID, newUser.Email); err != nil {
+		slog.Warn("Failed to send verification email", "user_id", newUser.ID, "error", err)
+	}
 
-	return s.generateTokenPair(newUser.ID)
+	// The primary profile shares the account's ID.
+	return s.startSession(ctx, newUser.ID, newUser.ID, meta, false)
 }
 
-func (s *Service) Login(req LoginRequest) (*TokenPair, error) {
-	u, err := s.userRepo.FindByEmail(strings.ToLower(strings.TrimSpace(req.Email)))
+func (s *Service) Login(ctx context.Context, req LoginRequest, meta SessionMeta) (*LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "auth.Login")
+	defer span.End()
+
+	address := strings.ToLower(strings.TrimSpace(req.Email))
+	if err := s.checkLockout(ctx, address); err != nil {
+		return nil, err
+	}
+
+	u, err := s.userRepo.FindByEmail(ctx, address)
 	if err != nil {
+		s.recordLoginFailure(ctx, address)
 		return nil, errors.New("invalid credentials")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
+		s.recordLoginFailure(ctx, address)
 		return nil, errors.New("invalid credentials")
 	}
 
-	return s.generateTokenPair(u.ID)
+	return s.completeLogin(ctx, u, meta)
 }
 
-func (s *Service) RefreshToken(tokenStr string) (*TokenPair, error) {
-	// Verify refresh token in DB
-	storedToken, err := s.repo.FindRefreshToken(tokenStr)
+// completeLogin finishes a first-factor sign-in. Accounts with 2FA get a
+// challenge instead of tokens, as do admins who have not enrolled yet.
+func (s *Service) completeLogin(ctx context.Context, u *user.User, meta SessionMeta) (*LoginResult, error) {
+	if u.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	switch {
+	case u.TwoFactorEnabled():
+		challenge, err := s.issueMFAChallenge(u.ID, mfaPurposeVerify)
+		return &LoginResult{Challenge: challenge}, err
+	case u.Role == user.RoleAdmin:
+		challenge, err := s.issueMFAChallenge(u.ID, mfaPurposeEnroll)
+		return &LoginResult{Challenge: challenge}, err
+	}
+
+	s.clearLoginFailures(ctx, u.Email)
+	tokens, err := s.startSession(ctx, u.ID, u.ID, meta, false)
 	if err != nil {
-		return nil, errors.New("invalid refresh token")
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// LockedError is returned while an account is locked out after repeated
+// failed sign-ins.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return "too many failed sign-in attempts; try again later"
+}
+
+// lockoutDuration doubles from one minute once failures reach the
+// threshold, capped at an hour.
+func lockoutDuration(failures int64) time.Duration {
+	over := failures - loginLockoutThreshold
+	if over < 0 {
+		return 0
+	}
+	if over >= 6 {
+		return maxLoginLockout
+	}
+	return min(time.Minute<<over, maxLoginLockout)
+}
+
+// Lockout state is keyed by a hash of the address rather than the account,
+// so unknown addresses lock the same way and reveal nothing.
+func loginKey(kind, address string) string {
+	return "login:" + kind + ":" + hashToken(address)
+}
+
+func (s *Service) checkLockout(ctx context.Context, address string) error {
+	ttl, err := s.cache.TTL(ctx, loginKey("lock", address))
+	if err != nil {
+		slog.Warn("Failed to read login lockout", "error", err)
+		return nil
+	}
+	if ttl > 0 {
+		return &LockedError{RetryAfter: ttl}
+	}
+	return nil
+}
+
+func (s *Service) recordLoginFailure(ctx context.Context, address string) {
+	key := loginKey("failures", address)
+	failures, _, err := s.cache.Incr(ctx, key, loginFailureWindow)
+	if err != nil {
+		slog.Warn("Failed to record login failure", "error", err)
+		return
+	}
+	s.cache.Expire(ctx, key, loginFailureWindow)
+	if lockout := lockoutDuration(failures); lockout > 0 {
+		s.cache.Set(ctx, loginKey("lock", address), []byte(strconv.FormatInt(failures, 10)), lockout)
 	}
+}
+
+func (s *Service) clearLoginFailures(ctx context.Context, address string) {
+	s.cache.Delete(ctx, loginKey("failures", address), loginKey("lock", address))
+}
 
-	if storedToken.ExpiresAt.Before(time.Now()) {
-		s.repo.DeleteRefreshToken(tokenStr)
+// RefreshToken rotates a refresh token within its session. Presenting a
+// token that was rotated more than a few seconds ago means it leaked, so
+// the whole session is revoked. The short grace period lets two browser
+// tabs sharing one cookie refresh at the same moment.
+func (s *Service) RefreshToken(ctx context.Context, tokenStr string, meta SessionMeta) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "auth.RefreshToken")
+	defer span.End()
+
+	stored, err := s.repo.FindRefreshToken(ctx, hashToken(tokenStr))
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	if stored.UsedAt != nil && time.Since(*stored.UsedAt) > refreshReuseGrace {
+		s.repo.RevokeSession(ctx, stored.UserID, stored.SessionID)
+		return nil, ErrRefreshTokenReuse
+	}
+	if stored.ExpiresAt.Before(time.Now()) {
 		return nil, errors.New("refresh token expired")
 	}
 
-	// Delete used token (rotation)
-	if err := s.repo.DeleteRefreshToken(tokenStr); err != nil {
+	session, err := s.repo.FindSession(ctx, stored.SessionID)
+	if err != nil || session.RevokedAt != nil {
+		return nil, errors.New("session has been signed out")
+	}
+
+	if stored.UsedAt == nil {
+		if _, err := s.repo.MarkRefreshTokenUsed(ctx, stored.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	session.applyMeta(meta)
+	return s.issueTokens(ctx, session)
+}
+
+// SelectProfile issues a token pair scoped to another household profile on
+// the same account, keeping the caller's session when there is one.
+func (s *Service) SelectProfile(ctx context.Context, userID, sessionID uuid.UUID, req SelectProfileRequest, meta SessionMeta) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "auth.SelectProfile")
+	defer span.End()
+
+	if _, err := s.userRepo.FindProfile(ctx, userID, req.ProfileID); err != nil {
+		return nil, errors.New("profile not found")
+	}
+
+	session, err := s.repo.FindSession(ctx, sessionID)
+	if err != nil || session.UserID != userID || session.RevokedAt != nil {
+		return s.startSession(ctx, userID, req.ProfileID, meta, false)
+	}
+	if err := s.repo.RetireSessionTokens(ctx, session.ID); err != nil {
+		return nil, err
+	}
+	session.ProfileID = &req.ProfileID
+	session.applyMeta(meta)
+	return s.issueTokens(ctx, session)
+}
+
+// ListSessions returns the account's signed-in devices, flagging the one
+// making the request.
+func (s *Service) ListSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]Session, error) {
+	ctx, span := tracing.Start(ctx, "auth.ListSessions")
+	defer span.End()
+
+	sessions, err := s.repo.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "auth.RevokeSession")
+	defer span.End()
+
+	revoked, err := s.repo.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("session not found")
+	}
+	return nil
+}
+
+// RevokeAllSessions signs the account out everywhere. Access tokens already
+// issued stay valid until they expire.
+func (s *Service) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "auth.RevokeAllSessions")
+	defer span.End()
+
+	return s.repo.RevokeAllSessions(ctx, userID)
+}
+
+// RequestPasswordReset emails a reset link if the address belongs to an
+// account. The token and email are handled in the background and failures
+// only logged, so the answer and its timing are the same for unknown
+// addresses.
+func (s *Service) RequestPasswordReset(ctx context.Context, req ForgotPasswordRequest) {
+	ctx, span := tracing.Start(ctx, "auth.RequestPasswordReset")
+	defer span.End()
+
+	u, err := s.userRepo.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return
+	}
+	go s.sendPasswordReset(context.WithoutCancel(ctx), u)
+}
+
+func (s *Service) sendPasswordReset(ctx context.Context, u *user.User) {
+	token, err := s.issueAccountToken(ctx, u.ID, purposePasswordReset, nil, resetTokenTTL)
+	if err != nil {
+		slog.Warn("Failed to issue password reset token", "user_id", u.ID, "error", err)
+		return
+	}
+	err = s.mailer.Send(ctx, email.Message{
+		To:      u.Email,
+		Subject: "Reset your BingeBeacon password",
+		Body: fmt.Sprintf("Someone asked to reset the password for %s.\n\nReset it here within the next hour:\n%s/reset-password?token=%s\n\nIf this wasn't you, ignore this email.",
+			u.Username, s.appURL, token),
+	})
+	if err != nil {
+		slog.Warn("Failed to send password reset email", "user_id", u.ID, "error", err)
+	}
+}
+
+// ResetPassword sets a new password from an emailed token and signs out
+// every session. Redeeming the token also proves the address.
+func (s *Service) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	ctx, span := tracing.Start(ctx, "auth.ResetPassword")
+	defer span.End()
+
+	if err := validatePassword(req.Password); err != nil {
+		return err
+	}
+	token, err := s.repo.ConsumeAccountToken(ctx, hashToken(req.Token), purposePasswordReset)
+	if err != nil {
+		return errors.New("invalid or expired reset token")
+	}
+	u, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerifiedAt == nil {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	}
+	return s.setPassword(ctx, u, req.Password)
+}
+
+// ChangePassword replaces the password, signs out every session, and
+// starts a fresh one for the caller. Accounts created through
+// an identity provider may set a first password without a current one.
+// The new session keeps the caller's second-factor status.
+func (s *Service) ChangePassword(ctx context.Context, userID, profileID, sessionID uuid.UUID, req ChangePasswordRequest, meta SessionMeta) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "auth.ChangePassword")
+	defer span.End()
+
+	if err := validatePassword(req.NewPassword); err != nil {
+		return nil, err
+	}
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+			return nil, errors.New("current password is incorrect")
+		}
+	}
+	mfaVerified := false
+	if session, err := s.repo.FindSession(ctx, sessionID); err == nil && session.UserID == userID {
+		mfaVerified = session.MFAVerified
+	}
+	if err := s.setPassword(ctx, u, req.NewPassword); err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, userID, profileID, meta, mfaVerified)
+}
+
+func (s *Service) ResendVerification(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "auth.ResendVerification")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerifiedAt != nil {
+		return errors.New("email is already verified")
+	}
+	return s.sendVerification(ctx, u.ID, u.Email)
+}
+
+// VerifyEmail redeems a verification or email-change token. An email change
+// only takes effect here, once the new address has been proven.
+func (s *Service) VerifyEmail(ctx context.Context, req VerifyEmailRequest) error {
+	ctx, span := tracing.Start(ctx, "auth.VerifyEmail")
+	defer span.End()
+
+	token, err := s.repo.ConsumeAccountToken(ctx, hashToken(req.Token), purposeEmailVerify, purposeEmailChange)
+	if err != nil {
+		return errors.New("invalid or expired verification token")
+	}
+	u, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+
+	previous := u.Email
+	if token.Purpose == purposeEmailChange && token.NewEmail != nil {
+		if other, err := s.userRepo.FindByEmail(ctx, *token.NewEmail); err == nil && other.ID != u.ID {
+			return errors.New("email already registered")
+		}
+		u.Email = *token.NewEmail
+	}
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return err
+	}
+
+	if previous != u.Email {
+		if err := s.mailer.Send(ctx, email.Message{
+			To:      previous,
+			Subject: "Your BingeBeacon email address was changed",
+			Body:    fmt.Sprintf("The email address for %s is now %s. If you didn't make this change, reset your password straight away.", u.Username, u.Email),
+		}); err != nil {
+			slog.Warn("Failed to send email change notice", "user_id", u.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// RequestEmailChange sends a confirmation link to the new address; the
+// account keeps its current email until the link is used.
+func (s *Service) RequestEmailChange(ctx context.Context, userID uuid.UUID, req ChangeEmailRequest) error {
+	ctx, span := tracing.Start(ctx, "auth.RequestEmailChange")
+	defer span.End()
+
+	newEmail := strings.ToLower(strings.TrimSpace(req.NewEmail))
+	if _, err := mail.ParseAddress(newEmail); err != nil {
+		return errors.New("a valid email is required")
+	}
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
+			return errors.New("password is incorrect")
+		}
+	}
+	if newEmail == u.Email {
+		return errors.New("that is already your email address")
+	}
+	if _, err := s.userRepo.FindByEmail(ctx, newEmail); err == nil {
+		return errors.New("email already registered")
+	}
+
+	token, err := s.issueAccountToken(ctx, u.ID, purposeEmailChange, &newEmail, changeTokenTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, email.Message{
+		To:      newEmail,
+		Subject: "Confirm your new BingeBeacon email address",
+		Body: fmt.Sprintf("Confirm %s as the email address for %s:\n%s/verify-email?token=%s\n\nThe link expires in 24 hours.",
+			newEmail, u.Username, s.appURL, token),
+	})
+}
+
+func (s *Service) sendVerification(ctx context.Context, userID uuid.UUID, address string) error {
+	token, err := s.issueAccountToken(ctx, userID, purposeEmailVerify, nil, verifyTokenTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, email.Message{
+		To:      address,
+		Subject: "Verify your BingeBeacon email address",
+		Body: fmt.Sprintf("Confirm this address to receive email alerts and recover your account:\n%s/verify-email?token=%s\n\nThe link expires in 48 hours.",
+			s.appURL, token),
+	})
+}
+
+// issueAccountToken stores the hash of a new random token and returns the
+// raw value for the email link.
+func (s *Service) issueAccountToken(ctx context.Context, userID uuid.UUID, purpose string, newEmail *string, ttl time.Duration) (string, error) {
+	raw := randomToken(32)
+	err := s.repo.CreateAccountToken(ctx, &AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(raw),
+		NewEmail:  newEmail,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	return raw, err
+}
+
+// setPassword stores a new hash and signs out every session.
+func (s *Service) setPassword(ctx context.Context, u *user.User, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = string(hashed)
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return err
+	}
+	return s.repo.RevokeAllSessions(ctx, u.ID)
+}
+
+func validatePassword(password string) error {
+	if len(password) < 8 || len(password) > 72 {
+		return errors.New("password must be 8-72 characters")
+	}
+	return nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// OIDCProviders returns the names of the configured identity providers.
+func (s *Service) OIDCProviders() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginOIDC returns the provider URL to send the browser to and the state
+// it carries, which the handler binds to the browser. A non-nil linkUserID
+// attaches the resulting identity to that account instead of signing in.
+func (s *Service) BeginOIDC(ctx context.Context, providerName string, linkUserID *uuid.UUID) (string, string, error) {
+	ctx, span := tracing.Start(ctx, "auth.BeginOIDC")
+	defer span.End()
+
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	state := randomToken(32)
+	pending := oidcState{
+		Provider:   providerName,
+		Nonce:      randomToken(16),
+		Verifier:   oauth2.GenerateVerifier(),
+		LinkUserID: linkUserID,
+	}
+	authURL, err := provider.AuthCodeURL(ctx, state, pending.Nonce, pending.Verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	payload, _ := json.Marshal(pending)
+	if err := s.cache.Set(ctx, "oidc:state:"+state, payload, oidcStateTTL); err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// CompleteOIDC handles the provider callback. Identities are matched by
+// provider subject first, then linked to an existing account by verified
+// email; otherwise a new account is created. Sign-ins yield a short-lived
+// one-time ticket that the client exchanges for a token pair.
+func (s *Service) CompleteOIDC(ctx context.Context, providerName, code, state string) (*OIDCResult, error) {
+	ctx, span := tracing.Start(ctx, "auth.CompleteOIDC")
+	defer span.End()
+
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	raw, err := s.cache.Take(ctx, "oidc:state:"+state)
+	if err != nil {
+		return nil, errors.New("sign-in session expired; please try again")
+	}
+	var pending oidcState
+	if err := json.Unmarshal(raw, &pending); err != nil || pending.Provider != providerName {
+		return nil, errors.New("invalid sign-in state")
+	}
+
+	external, err := provider.Exchange(ctx, code, pending.Nonce, pending.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if pending.LinkUserID != nil {
+		if err := s.linkIdentity(ctx, *pending.LinkUserID, external); err != nil {
+			return nil, err
+		}
+		return &OIDCResult{Linked: true}, nil
+	}
+
+	userID, err := s.resolveIdentity(ctx, external)
+	if err != nil {
+		return nil, err
+	}
+	ticket := randomToken(32)
+	if err := s.cache.Set(ctx, "oidc:ticket:"+ticket, []byte(userID.String()), oidcTicketTTL); err != nil {
+		return nil, err
+	}
+	return &OIDCResult{Ticket: ticket}, nil
+}
+
+// ExchangeOIDCTicket redeems a login ticket from CompleteOIDC. The provider
+// counts as the first factor only, so 2FA still applies.
+func (s *Service) ExchangeOIDCTicket(ctx context.Context, ticket string, meta SessionMeta) (*LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "auth.ExchangeOIDCTicket")
+	defer span.End()
+
+	raw, err := s.cache.Take(ctx, "oidc:ticket:"+ticket)
+	if err != nil {
+		return nil, errors.New("invalid or expired ticket")
+	}
+	userID, err := uuid.ParseBytes(raw)
+	if err != nil {
+		return nil, errors.New("invalid or expired ticket")
+	}
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.completeLogin(ctx, u, meta)
+}
+
+func (s *Service) ListIdentities(ctx context.Context, userID uuid.UUID) ([]UserIdentity, error) {
+	ctx, span := tracing.Start(ctx, "auth.ListIdentities")
+	defer span.End()
+
+	return s.repo.GetIdentities(ctx, userID)
+}
+
+// UnlinkIdentity removes a linked identity, refusing to remove the last way
+// into an account that has no password.
+func (s *Service) UnlinkIdentity(ctx context.Context, userID, identityID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "auth.UnlinkIdentity")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.PasswordHash == "" {
+		count, err := s.repo.CountIdentities(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if count <= 1 {
+			return errors.New("cannot remove the only sign-in method; set a password first")
+		}
+	}
+	deleted, err := s.repo.DeleteIdentity(ctx, userID, identityID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("identity not found")
+	}
+	return nil
+}
+
+func (s *Service) resolveIdentity(ctx context.Context, external *ExternalIdentity) (uuid.UUID, error) {
+	address := strings.ToLower(strings.TrimSpace(external.Email))
+
+	if existing, err := s.repo.FindIdentity(ctx, external.Provider, external.Subject); err == nil {
+		if err := s.repo.TouchIdentity(ctx, existing.ID, optionalString(address)); err != nil {
+			return uuid.Nil, err
+		}
+		return existing.UserID, nil
+	}
+
+	// Unverified addresses could belong to someone else, so they neither
+	// link to nor create an account.
+	if address == "" || !external.EmailVerified {
+		return uuid.Nil, errors.New("the provider did not return a verified email address")
+	}
+
+	account, err := s.userRepo.FindByEmail(ctx, address)
+	if err != nil {
+		username, err := s.availableUsername(ctx, external, address)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		now := time.Now()
+		account = &user.User{Email: address, Username: username, EmailVerifiedAt: &now}
+		if err := s.userRepo.Create(ctx, account); err != nil {
+			return uuid.Nil, err
+		}
+	} else if account.EmailVerifiedAt == nil {
+		// Whoever registered the address never proved they own it; linking
+		// would hand their password a now-verified account.
+		return uuid.Nil, ErrUnverifiedAccount
+	}
+
+	now := time.Now()
+	if err := s.repo.CreateIdentity(ctx, &UserIdentity{
+		UserID:      account.ID,
+		Provider:    external.Provider,
+		Subject:     external.Subject,
+		Email:       optionalString(address),
+		LastLoginAt: &now,
+	}); err != nil {
+		return uuid.Nil, err
+	}
+	return account.ID, nil
+}
+
+func (s *Service) linkIdentity(ctx context.Context, userID uuid.UUID, external *ExternalIdentity) error {
+	if existing, err := s.repo.FindIdentity(ctx, external.Provider, external.Subject); err == nil {
+		if existing.UserID != userID {
+			return ErrIdentityTaken
+		}
+		return nil
+	}
+	return s.repo.CreateIdentity(ctx, &UserIdentity{
+		UserID:   userID,
+		Provider: external.Provider,
+		Subject:  external.Subject,
+		Email:    optionalString(strings.ToLower(strings.TrimSpace(external.Email))),
+	})
+}
+
+// availableUsername derives a username from the provider's handle or the
+// email's local part, adding a numeric suffix when it is taken.
+func (s *Service) availableUsername(ctx context.Context, external *ExternalIdentity, address string) (string, error) {
+	base := sanitizeUsername(external.Username)
+	if len(base) < 3 {
+		base = sanitizeUsername(strings.SplitN(address, "@", 2)[0])
+	}
+	if len(base) < 3 {
+		base = "viewer"
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		taken, err := s.userRepo.UsernameExists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		n, _ := rand.Int(rand.Reader, big.NewInt(10000))
+		candidate = fmt.Sprintf("%s_%04d", base, n.Int64())
+	}
+	return "", errors.New("could not allocate a username")
+}
+
+func sanitizeUsername(raw string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(raw) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' || r == '.' {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if len(name) > 24 {
+		name = name[:24]
+	}
+	return name
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func randomToken(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// CreatePersonalToken issues a token acting as the caller's current profile.
+// The raw token is only ever returned here.
+func (s *Service) CreatePersonalToken(ctx context.Context, userID, profileID uuid.UUID, req CreatePersonalTokenRequest) (*CreatedPersonalToken, error) {
+	ctx, span := tracing.Start(ctx, "auth.CreatePersonalToken")
+	defer span.End()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, errors.New("name must be 1-100 characters")
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.generateTokenPair(storedToken.UserID)
+	days := defaultPersonalTokenTTL
+	if req.ExpiresInDays != nil {
+		days = *req.ExpiresInDays
+	}
+	if days < 0 || days > maxPersonalTokenTTL {
+		return nil, fmt.Errorf("expires_in_days must be between 0 and %d", maxPersonalTokenTTL)
+	}
+
+	count, err := s.repo.CountPersonalTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxPersonalTokens {
+		return nil, fmt.Errorf("an account can have at most %d personal access tokens", maxPersonalTokens)
+	}
+
+	raw := personalTokenPrefix + randomToken(32)
+	token := PersonalAccessToken{
+		UserID:      userID,
+		ProfileID:   profileID,
+		Name:        name,
+		TokenHash:   hashToken(raw),
+		TokenPrefix: raw[:len(personalTokenPrefix)+6],
+		Scopes:      scopes,
+	}
+	if days > 0 {
+		expires := time.Now().AddDate(0, 0, days)
+		token.ExpiresAt = &expires
+	}
+	if err := s.repo.CreatePersonalToken(ctx, &token); err != nil {
+		return nil, err
+	}
+	return &CreatedPersonalToken{PersonalAccessToken: token, Token: raw}, nil
+}
+
+func (s *Service) ListPersonalTokens(ctx context.Context, userID uuid.UUID) ([]PersonalAccessToken, error) {
+	ctx, span := tracing.Start(ctx, "auth.ListPersonalTokens")
+	defer span.End()
+
+	return s.repo.GetPersonalTokens(ctx, userID)
+}
+
+func (s *Service) RevokePersonalToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "auth.RevokePersonalToken")
+	defer span.End()
+
+	revoked, err := s.repo.RevokePersonalToken(ctx, userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("token not found")
+	}
+	return nil
+}
+
+// VerifyPersonalToken resolves a raw personal access token, rejecting
+// revoked and expired ones, and records its use.
+func (s *Service) VerifyPersonalToken(ctx context.Context, raw string) (*PersonalAccessToken, error) {
+	ctx, span := tracing.Start(ctx, "auth.VerifyPersonalToken")
+	defer span.End()
+
+	token, err := s.repo.FindPersonalToken(ctx, hashToken(raw))
+	if err != nil || token.RevokedAt != nil {
+		return nil, errors.New("invalid personal access token")
+	}
+	if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("personal access token expired")
+	}
+	if owner, err := s.userRepo.FindByID(ctx, token.UserID); err != nil || owner.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	if err := s.repo.TouchPersonalToken(ctx, token.ID); err != nil {
+		slog.Warn("Failed to record personal access token use", "token_id", token.ID, "error", err)
+	}
+	return token, nil
 }
 
-func (s *Service) Logout(tokenStr string) error {
-	return s.repo.DeleteRefreshToken(tokenStr)
+func normalizeScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	seen := make(map[string]bool, len(requested))
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		access, resource, ok := strings.Cut(scope, ":")
+		if !ok || (access != "read" && access != "write") || !personalTokenResources[resource] {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes, nil
 }
 
-func (s *Service) generateTokenPair(userID uuid.UUID) (*TokenPair, error) {
+// Logout ends the session the refresh token belongs to, whether or not the
+// token has already been rotated.
+func (s *Service) Logout(ctx context.Context, tokenStr string) error {
+	ctx, span := tracing.Start(ctx, "auth.Logout")
+	defer span.End()
+
+	stored, err := s.repo.FindRefreshToken(ctx, hashToken(tokenStr))
+	if err != nil {
+		return nil
+	}
+	_, err = s.repo.RevokeSession(ctx, stored.UserID, stored.SessionID)
+	return err
+}
+
+func (s *Service) startSession(ctx context.Context, userID, profileID uuid.UUID, meta SessionMeta, mfaVerified bool) (*TokenPair, error) {
+	session := &Session{UserID: userID, ProfileID: &profileID, MFAVerified: mfaVerified}
+	session.applyMeta(meta)
+	session.ExpiresAt = time.Now().Add(s.cfg.RefreshTokenTTL)
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, session)
+}
+
+// issueTokens mints an access token and the next refresh token in the
+// session's family, extending the session to the new token's expiry. The
+// account is re-read so role changes and suspensions apply on refresh.
+func (s *Service) issueTokens(ctx context.Context, session *Session) (*TokenPair, error) {
+	account, err := s.userRepo.FindByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if account.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
 	now := time.Now()
+	profileID := session.UserID
+	if session.ProfileID != nil {
+		profileID = *session.ProfileID
+	}
+
 	// Access Token
 	accessTokenClaims := jwt.MapClaims{
-		"user_id": userID.String(),
-		"sub":     userID.String(),
-		"type":    "access",
-		"jti":     uuid.NewString(),
-		"iat":     now.Unix(),
-		"exp":     now.Add(s.cfg.AccessTokenTTL).Unix(),
+		"user_id":    session.UserID.String(),
+		"profile_id": profileID.String(),
+		"sid":        session.ID.String(),
+		"sub":        session.UserID.String(),
+		"mfa":        session.MFAVerified,
+		"role":       account.Role,
+		"type":       "access",
+		"jti":        uuid.NewString(),
+		"iat":        now.Unix(),
+		"exp":        now.Add(s.cfg.AccessTokenTTL).Unix(),
 	}
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims)
 	accessTokenString, err := accessToken.SignedString([]byte(s.cfg.Secret))
@@ -119,8 +999,9 @@ func (s *Service) generateTokenPair(userID uuid.UUID) (*TokenPair, error) {
 
 	// Refresh Token
 	refreshTokenClaims := jwt.MapClaims{
-		"user_id": userID.String(),
-		"sub":     userID.String(),
+		"user_id": session.UserID.String(),
+		"sid":     session.ID.String(),
+		"sub":     session.UserID.String(),
 		"type":    "refresh",
 		"jti":     uuid.NewString(),
 		"iat":     now.Unix(),
@@ -132,16 +1013,23 @@ func (s *Service) generateTokenPair(userID uuid.UUID) (*TokenPair, error) {
 		return nil, err
 	}
 
-	// Store Refresh Token in DB
-	err = s.repo.CreateRefreshToken(&RefreshToken{
-		UserID:    userID,
-		Token:     refreshTokenString,
+	// Store only the hash of the refresh token
+	err = s.repo.CreateRefreshToken(ctx, &RefreshToken{
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		TokenHash: hashToken(refreshTokenString),
 		ExpiresAt: now.Add(s.cfg.RefreshTokenTTL),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(s.cfg.RefreshTokenTTL)
+	if err := s.repo.UpdateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
 	return &TokenPair{
 		AccessToken:  accessTokenString,
 		RefreshToken: refreshTokenString,
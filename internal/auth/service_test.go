@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutDurationBacksOff(t *testing.T) {
+	cases := map[int64]time.Duration{
+		1:  0,
+		4:  0,
+		5:  time.Minute,
+		6:  2 * time.Minute,
+		8:  8 * time.Minute,
+		11: time.Hour,
+		70: time.Hour,
+	}
+	for failures, want := range cases {
+		if got := lockoutDuration(failures); got != want {
+			t.Errorf("%d failures: expected %s, got %s", failures, want, got)
+		}
+	}
+}
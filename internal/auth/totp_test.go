@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTOTPMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA1 seed, truncated to six digits.
+	secret := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		if got := totpCode(secret, unix/totpPeriod); got != want {
+			t.Errorf("T=%d: expected %s, got %s", unix, want, got)
+		}
+	}
+}
+
+func TestVerifyTOTPRejectsReplay(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+
+	step, ok := verifyTOTP(secret, "081804", now, 0)
+	if !ok {
+		t.Fatal("expected current code to verify")
+	}
+	if _, ok := verifyTOTP(secret, "081804", now, step); ok {
+		t.Fatal("expected a used step to be refused")
+	}
+	if _, ok := verifyTOTP(secret, "000000", now, 0); ok {
+		t.Fatal("expected a wrong code to be refused")
+	}
+}
@@ -10,15 +10,24 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	TMDB     TMDBConfig
-	OMDB     OMDBConfig
-	TheTVDB  TheTVDBConfig
-	FCM      FCMConfig
-	MovieGlu MovieGluConfig
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	Cache        CacheConfig
+	RateLimit    RateLimitConfig `mapstructure:"ratelimit"`
+	Metrics      MetricsConfig
+	Tracing      TracingConfig
+	Scheduler    SchedulerConfig
+	JWT          JWTConfig
+	TMDB         TMDBConfig
+	OMDB         OMDBConfig
+	TheTVDB      TheTVDBConfig
+	FCM          FCMConfig
+	WebPush      WebPushConfig
+	Notification NotificationConfig
+	MovieGlu     MovieGluConfig
+	OIDC         OIDCConfig
+	Email        EmailConfig
 }
 
 type ServerConfig struct {
@@ -26,6 +35,7 @@ type ServerConfig struct {
 	Environment    string `mapstructure:"environment"`
 	CORSOrigins    string `mapstructure:"cors_origins"`
 	InternalAPIKey string `mapstructure:"internal_api_key"`
+	AdminEmails    string `mapstructure:"admin_emails"`
 }
 
 type DatabaseConfig struct {
@@ -35,14 +45,86 @@ type DatabaseConfig struct {
 	Password string `mapstructure:"password"`
 	DBName   string `mapstructure:"dbname"`
 	SSLMode  string `mapstructure:"sslmode"`
+
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+	// QueryTimeout bounds each query from the client side; StatementTimeout
+	// is Postgres's statement_timeout for the API's connections. Zero
+	// disables either.
+	QueryTimeout     time.Duration `mapstructure:"query_timeout"`
+	StatementTimeout time.Duration `mapstructure:"statement_timeout"`
 }
 
+// RedisConfig points at the shared cache. With Enabled false the API runs on
+// its in-process cache alone, which suits a single instance.
 type RedisConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
 	Addr     string `mapstructure:"addr"`
 	Password string `mapstructure:"password"`
 	DB       int    `mapstructure:"db"`
 }
 
+// CacheConfig sizes the in-process tier in front of Redis. L1TTL caps how
+// long a replica keeps an entry, bounding staleness if it misses an
+// invalidation; without Redis entries keep their full TTL.
+type CacheConfig struct {
+	L1MaxBytes int64         `mapstructure:"l1_max_bytes"`
+	L1TTL      time.Duration `mapstructure:"l1_ttl"`
+}
+
+// RateLimitConfig sets the request budget for each route group. A rule with
+// zero requests is not limited.
+type RateLimitConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Auth    RateLimitRule `mapstructure:"auth"`
+	Search  RateLimitRule `mapstructure:"search"`
+	Import  RateLimitRule `mapstructure:"import"`
+	API     RateLimitRule `mapstructure:"api"`
+}
+
+type RateLimitRule struct {
+	Requests int           `mapstructure:"requests"`
+	Window   time.Duration `mapstructure:"window"`
+}
+
+// MetricsConfig controls the Prometheus endpoint. When Token is set scrapes
+// must send it as a bearer token; without one the endpoint is closed in
+// production.
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Token   string `mapstructure:"token"`
+}
+
+// TracingConfig exports OpenTelemetry spans over OTLP/HTTP. Endpoint is the
+// full traces URL, e.g. http://otel-collector:4318/v1/traces.
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// SchedulerConfig overrides job schedules with cron expressions or
+// descriptors such as "@every 30m". Empty keeps a job's built-in interval.
+type SchedulerConfig struct {
+	EpisodeSync          string `mapstructure:"episode_sync"`
+	TMDBChangesSync      string `mapstructure:"tmdb_changes_sync"`
+	NotificationDispatch string `mapstructure:"notification_dispatch"`
+	StaleCleanup         string `mapstructure:"stale_cleanup"`
+}
+
+// Schedules returns the overrides keyed by job name.
+func (c SchedulerConfig) Schedules() map[string]string {
+	return map[string]string{
+		"episode_sync":          c.EpisodeSync,
+		"tmdb_changes_sync":     c.TMDBChangesSync,
+		"notification_dispatch": c.NotificationDispatch,
+		"stale_cleanup":         c.StaleCleanup,
+	}
+}
+
 type JWTConfig struct {
 	Secret          string        `mapstructure:"secret"`
 	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`
@@ -69,6 +151,23 @@ type FCMConfig struct {
 	CredentialsFile string `mapstructure:"credentials_file"`
 }
 
+// WebPushConfig enables Web Push delivery to browser subscriptions. Without
+// a VAPID private key, webpush devices are skipped.
+type WebPushConfig struct {
+	VAPIDPrivateKey string `mapstructure:"vapid_private_key"`
+	Subject         string `mapstructure:"subject"`
+}
+
+// NotificationConfig tunes dispatch. Releases of one show due within
+// CoalesceWindow of each other go out as a single grouped notification;
+// zero only groups those already due together. Push actions stay usable for
+// ActionTTL, and "remind me later" resends after Snooze.
+type NotificationConfig struct {
+	CoalesceWindow time.Duration `mapstructure:"coalesce_window"`
+	ActionTTL      time.Duration `mapstructure:"action_ttl"`
+	Snooze         time.Duration `mapstructure:"snooze"`
+}
+
 type MovieGluConfig struct {
 	APIKey        string `mapstructure:"api_key"`
 	Authorization string `mapstructure:"authorization"`
@@ -77,6 +176,35 @@ type MovieGluConfig struct {
 	BaseURL       string `mapstructure:"base_url"`
 }
 
+// EmailConfig configures outbound mail. An empty SMTPHost logs messages
+// instead of sending them. AppURL is the PWA origin used in emailed links.
+type EmailConfig struct {
+	SMTPHost        string `mapstructure:"smtp_host"`
+	SMTPPort        int    `mapstructure:"smtp_port"`
+	SMTPUsername    string `mapstructure:"smtp_username"`
+	SMTPPassword    string `mapstructure:"smtp_password"`
+	From            string `mapstructure:"from"`
+	AppURL          string `mapstructure:"app_url"`
+	RequireVerified bool   `mapstructure:"require_verified"`
+}
+
+// OIDCConfig lists the external identity providers users may sign in with.
+// Providers are named in OIDC_PROVIDERS (e.g. "google,github,keycloak") and
+// each reads OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and _SCOPES.
+type OIDCConfig struct {
+	CallbackBaseURL string               `mapstructure:"callback_base_url"`
+	SuccessURL      string               `mapstructure:"success_url"`
+	Providers       []OIDCProviderConfig `mapstructure:"-"`
+}
+
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
 func Load() *Config {
 	_ = godotenv.Load()
 
@@ -93,15 +221,44 @@ func Load() *Config {
 	viper.SetDefault("server.environment", "development")
 	viper.SetDefault("server.cors_origins", "http://localhost:3000")
 	viper.SetDefault("server.internal_api_key", "")
+	viper.SetDefault("server.admin_emails", "")
 	viper.SetDefault("database.host", "localhost")
 	viper.SetDefault("database.port", 5432)
 	viper.SetDefault("database.user", "postgres")
 	viper.SetDefault("database.password", "")
 	viper.SetDefault("database.dbname", "bingebeacon")
 	viper.SetDefault("database.sslmode", "disable")
+	viper.SetDefault("database.max_open_conns", 100)
+	viper.SetDefault("database.max_idle_conns", 10)
+	viper.SetDefault("database.conn_max_lifetime", time.Hour)
+	viper.SetDefault("database.conn_max_idle_time", 10*time.Minute)
+	viper.SetDefault("database.query_timeout", 15*time.Second)
+	viper.SetDefault("database.statement_timeout", 30*time.Second)
+	viper.SetDefault("redis.enabled", true)
 	viper.SetDefault("redis.addr", "localhost:6379")
 	viper.SetDefault("redis.password", "")
 	viper.SetDefault("redis.db", 0)
+	viper.SetDefault("cache.l1_max_bytes", 64<<20)
+	viper.SetDefault("cache.l1_ttl", time.Minute)
+	viper.SetDefault("ratelimit.enabled", true)
+	viper.SetDefault("ratelimit.auth.requests", 10)
+	viper.SetDefault("ratelimit.auth.window", time.Minute)
+	viper.SetDefault("ratelimit.search.requests", 120)
+	viper.SetDefault("ratelimit.search.window", time.Minute)
+	viper.SetDefault("ratelimit.import.requests", 10)
+	viper.SetDefault("ratelimit.import.window", time.Minute)
+	viper.SetDefault("ratelimit.api.requests", 600)
+	viper.SetDefault("ratelimit.api.window", time.Minute)
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.token", "")
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.endpoint", "http://localhost:4318/v1/traces")
+	viper.SetDefault("tracing.service_name", "bingebeacon-api")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("scheduler.episode_sync", "")
+	viper.SetDefault("scheduler.tmdb_changes_sync", "")
+	viper.SetDefault("scheduler.notification_dispatch", "")
+	viper.SetDefault("scheduler.stale_cleanup", "")
 	viper.SetDefault("jwt.secret", "development-only-secret-change-me")
 	viper.SetDefault("jwt.access_token_ttl", 15*time.Minute)
 	viper.SetDefault("jwt.refresh_token_ttl", 168*time.Hour)
@@ -113,11 +270,26 @@ func Load() *Config {
 	viper.SetDefault("thetvdb.pin", "")
 	viper.SetDefault("thetvdb.base_url", "https://api4.thetvdb.com/v4")
 	viper.SetDefault("fcm.credentials_file", "firebase-credentials.json")
+	viper.SetDefault("webpush.vapid_private_key", "")
+	viper.SetDefault("webpush.subject", "")
+	viper.SetDefault("notification.coalesce_window", 15*time.Minute)
+	viper.SetDefault("notification.action_ttl", 24*time.Hour)
+	viper.SetDefault("notification.snooze", time.Hour)
 	viper.SetDefault("movieglu.api_key", "")
 	viper.SetDefault("movieglu.authorization", "")
 	viper.SetDefault("movieglu.client_id", "")
 	viper.SetDefault("movieglu.territory", "US")
 	viper.SetDefault("movieglu.base_url", "https://api.movieglu.com")
+	viper.SetDefault("email.smtp_host", "")
+	viper.SetDefault("email.smtp_port", 587)
+	viper.SetDefault("email.smtp_username", "")
+	viper.SetDefault("email.smtp_password", "")
+	viper.SetDefault("email.from", "BingeBeacon <no-reply@localhost>")
+	viper.SetDefault("email.app_url", "http://localhost:3000")
+	viper.SetDefault("email.require_verified", true)
+	viper.SetDefault("oidc.providers", "")
+	viper.SetDefault("oidc.callback_base_url", "http://localhost:8080/api/v1/auth/oidc")
+	viper.SetDefault("oidc.success_url", "http://localhost:3000/auth/callback")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -133,6 +305,26 @@ func Load() *Config {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		log.Fatalf("Unable to decode into struct: %v", err)
 	}
+	cfg.OIDC.Providers = loadOIDCProviders()
 
 	return &cfg
 }
+
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(viper.GetString("oidc.providers"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "oidc." + name + "."
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			Issuer:       viper.GetString(prefix + "issuer"),
+			ClientID:     viper.GetString(prefix + "client_id"),
+			ClientSecret: viper.GetString(prefix + "client_secret"),
+			Scopes:       strings.Fields(strings.ReplaceAll(viper.GetString(prefix+"scopes"), ",", " ")),
+		})
+	}
+	return providers
+}
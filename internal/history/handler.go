@@ -20,7 +20,7 @@ func NewHandler(svc *Service) *Handler {
 }
 
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
@@ -43,7 +43,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		showID = &id
 	}
 
-	entries, err := h.svc.List(userID, showID, limit)
+	entries, err := h.svc.List(r.Context(), profileID, showID, limit)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -58,6 +58,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
 	var req CreateEntryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -65,7 +66,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.Create(r.Context(), userID, req); err != nil {
+	if err := h.svc.Create(r.Context(), userID, profileID, req); err != nil {
 		httputil.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -79,6 +80,7 @@ func (h *Handler) CreateBatch(w http.ResponseWriter, r *http.Request) {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
 	var req BatchCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -86,7 +88,7 @@ func (h *Handler) CreateBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.CreateBatch(r.Context(), userID, req); err != nil {
+	if err := h.svc.CreateBatch(r.Context(), userID, profileID, req); err != nil {
 		httputil.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -100,6 +102,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -115,7 +118,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.Update(r.Context(), userID, id, req.Rating, req.Notes); err != nil {
+	if err := h.svc.Update(r.Context(), userID, profileID, id, req.Rating, req.Notes); err != nil {
 		httputil.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -129,6 +132,7 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -138,7 +142,7 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.Delete(r.Context(), userID, id); err != nil {
+	if err := h.svc.Delete(r.Context(), userID, profileID, id); err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -147,13 +151,13 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
 
-	stats, err := h.svc.Stats(r.Context(), userID)
+	stats, err := h.svc.Stats(r.Context(), profileID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -163,7 +167,7 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) Progress(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
@@ -177,7 +181,7 @@ func (h *Handler) Progress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	progress, err := h.svc.Progress(r.Context(), userID, showID)
+	progress, err := h.svc.Progress(r.Context(), profileID, showID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -10,6 +10,7 @@ import (
 type Entry struct {
 	ID            uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
 	UserID        uuid.UUID     `gorm:"type:uuid;not null" json:"user_id"`
+	ProfileID     uuid.UUID     `gorm:"type:uuid;not null" json:"profile_id"`
 	ShowID        uuid.UUID     `gorm:"type:uuid;not null" json:"show_id"`
 	SeasonNumber  int           `gorm:"not null" json:"season_number"`
 	EpisodeNumber int           `gorm:"not null" json:"episode_number"`
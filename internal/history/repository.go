@@ -1,6 +1,8 @@
 package history
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -13,9 +15,9 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) List(userID uuid.UUID, showID *uuid.UUID, limit int) ([]Entry, error) {
+func (r *Repository) List(ctx context.Context, profileID uuid.UUID, showID *uuid.UUID, limit int) ([]Entry, error) {
 	var entries []Entry
-	query := r.db.Preload("Show").Preload("Episode").Where("user_id = ?", userID)
+	query := r.db.WithContext(ctx).Preload("Show").Preload("Episode").Where("profile_id = ?", profileID)
 	if showID != nil {
 		query = query.Where("show_id = ?", *showID)
 	}
@@ -23,48 +25,48 @@ func (r *Repository) List(userID uuid.UUID, showID *uuid.UUID, limit int) ([]Ent
 	return entries, err
 }
 
-func (r *Repository) ListByShow(userID, showID uuid.UUID) ([]Entry, error) {
+func (r *Repository) ListByShow(ctx context.Context, profileID, showID uuid.UUID) ([]Entry, error) {
 	var entries []Entry
-	err := r.db.Where("user_id = ? AND show_id = ?", userID, showID).
+	err := r.db.WithContext(ctx).Where("profile_id = ? AND show_id = ?", profileID, showID).
 		Order("season_number ASC, episode_number ASC").Find(&entries).Error
 	return entries, err
 }
 
-func (r *Repository) Create(entry *Entry) error {
-	return r.db.Create(entry).Error
+func (r *Repository) Create(ctx context.Context, entry *Entry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
 }
 
-func (r *Repository) Update(entry *Entry) error {
-	return r.db.Save(entry).Error
+func (r *Repository) Update(ctx context.Context, entry *Entry) error {
+	return r.db.WithContext(ctx).Save(entry).Error
 }
 
-func (r *Repository) CreateBatch(entries []Entry) error {
-	return r.db.CreateInBatches(entries, 100).Error
+func (r *Repository) CreateBatch(ctx context.Context, entries []Entry) error {
+	return r.db.WithContext(ctx).CreateInBatches(entries, 100).Error
 }
 
-func (r *Repository) GetByID(userID, id uuid.UUID) (*Entry, error) {
+func (r *Repository) GetByID(ctx context.Context, profileID, id uuid.UUID) (*Entry, error) {
 	var entry Entry
-	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&entry).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ? AND profile_id = ?", id, profileID).First(&entry).Error; err != nil {
 		return nil, err
 	}
 	return &entry, nil
 }
 
-func (r *Repository) Delete(id uuid.UUID, userID uuid.UUID) error {
-	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Entry{}).Error
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID, profileID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ? AND profile_id = ?", id, profileID).Delete(&Entry{}).Error
 }
 
-func (r *Repository) GetStats(userID uuid.UUID) (*Stats, error) {
+func (r *Repository) GetStats(ctx context.Context, profileID uuid.UUID) (*Stats, error) {
 	var stats Stats
 	var count int64
 
-	err := r.db.Model(&Entry{}).Where("user_id = ?", userID).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&Entry{}).Where("profile_id = ?", profileID).Count(&count).Error
 	if err != nil {
 		return nil, err
 	}
 	stats.TotalEpisodes = int(count)
 
-	err = r.db.Model(&Entry{}).Where("user_id = ?", userID).Distinct("show_id").Count(&count).Error
+	err = r.db.WithContext(ctx).Model(&Entry{}).Where("profile_id = ?", profileID).Distinct("show_id").Count(&count).Error
 	if err != nil {
 		return nil, err
 	}
@@ -73,16 +75,16 @@ func (r *Repository) GetStats(userID uuid.UUID) (*Stats, error) {
 	return &stats, nil
 }
 
-func (r *Repository) GetShowProgress(userID, showID uuid.UUID) (int, error) {
+func (r *Repository) GetShowProgress(ctx context.Context, profileID, showID uuid.UUID) (int, error) {
 	var count int64
-	err := r.db.Model(&Entry{}).Where("user_id = ? AND show_id = ?", userID, showID).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&Entry{}).Where("profile_id = ? AND show_id = ?", profileID, showID).Count(&count).Error
 	return int(count), err
 }
 
-func (r *Repository) FindEntry(userID, showID uuid.UUID, season, episode int) (*Entry, error) {
+func (r *Repository) FindEntry(ctx context.Context, profileID, showID uuid.UUID, season, episode int) (*Entry, error) {
 	var entry Entry
-	err := r.db.Where("user_id = ? AND show_id = ? AND season_number = ? AND episode_number = ?",
-		userID, showID, season, episode).First(&entry).Error
+	err := r.db.WithContext(ctx).Where("profile_id = ? AND show_id = ? AND season_number = ? AND episode_number = ?",
+		profileID, showID, season, episode).First(&entry).Error
 	if err != nil {
 		return nil, err
 	}
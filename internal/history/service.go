@@ -2,38 +2,50 @@ package history
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"math"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/tashifkhan/bingebeacon/internal/alert"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
 	"github.com/tashifkhan/bingebeacon/internal/show"
+	"github.com/tashifkhan/bingebeacon/internal/social"
+	"gorm.io/datatypes"
 )
 
 type Service struct {
 	repo      *Repository
 	showRepo  *show.Repository
 	alertRepo *alert.Repository
+	socialSvc *social.Service
 }
 
-func NewService(repo *Repository, showRepo *show.Repository, alertRepo *alert.Repository) *Service {
+func NewService(repo *Repository, showRepo *show.Repository, alertRepo *alert.Repository, socialSvc *social.Service) *Service {
 	return &Service{
 		repo:      repo,
 		showRepo:  showRepo,
 		alertRepo: alertRepo,
+		socialSvc: socialSvc,
 	}
 }
 
-func (s *Service) List(userID uuid.UUID, showID *uuid.UUID, limit int) ([]Entry, error) {
-	return s.repo.List(userID, showID, limit)
+func (s *Service) List(ctx context.Context, profileID uuid.UUID, showID *uuid.UUID, limit int) ([]Entry, error) {
+	ctx, span := tracing.Start(ctx, "history.List")
+	defer span.End()
+
+	return s.repo.List(ctx, profileID, showID, limit)
 }
 
-func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateEntryRequest) error {
+func (s *Service) Create(ctx context.Context, userID, profileID uuid.UUID, req CreateEntryRequest) error {
+	ctx, span := tracing.Start(ctx, "history.Create")
+	defer span.End()
+
 	if req.ShowID == uuid.Nil {
 		return errors.New("show_id required")
 	}
-	if _, err := s.showRepo.FindByID(req.ShowID); err != nil {
+	if _, err := s.showRepo.FindByID(ctx, req.ShowID); err != nil {
 		return errors.New("show not found")
 	}
 	if req.SeasonNumber <= 0 || req.EpisodeNumber <= 0 {
@@ -44,7 +56,7 @@ func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateEntryR
 	}
 
 	var episodeID *uuid.UUID
-	if ep, err := s.showRepo.GetEpisodeByNumber(req.ShowID, req.SeasonNumber, req.EpisodeNumber); err == nil {
+	if ep, err := s.showRepo.GetEpisodeByNumber(ctx, req.ShowID, req.SeasonNumber, req.EpisodeNumber); err == nil {
 		episodeID = &ep.ID
 	}
 
@@ -55,6 +67,7 @@ func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateEntryR
 
 	entry := &Entry{
 		UserID:        userID,
+		ProfileID:     profileID,
 		ShowID:        req.ShowID,
 		SeasonNumber:  req.SeasonNumber,
 		EpisodeNumber: req.EpisodeNumber,
@@ -64,24 +77,38 @@ func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateEntryR
 		WatchedAt:     watchedAt,
 	}
 
-	if err := s.repo.Create(entry); err != nil {
+	if err := s.repo.Create(ctx, entry); err != nil {
 		return err
 	}
 
 	// Update last watched on tracked show (if tracked)
-	_ = s.alertRepo.UpdateLastWatched(userID, req.ShowID, req.SeasonNumber, req.EpisodeNumber)
+	_ = s.alertRepo.UpdateLastWatched(ctx, profileID, req.ShowID, req.SeasonNumber, req.EpisodeNumber)
+
+	_ = s.socialSvc.Record(ctx, &social.Activity{
+		UserID:        userID,
+		Type:          social.ActivityWatched,
+		ShowID:        entry.ShowID,
+		SourceID:      &entry.ID,
+		SeasonNumber:  &entry.SeasonNumber,
+		EpisodeNumber: &entry.EpisodeNumber,
+		Rating:        entry.Rating,
+		Notes:         entry.Notes,
+	})
 
 	return nil
 }
 
-func (s *Service) CreateBatch(ctx context.Context, userID uuid.UUID, req BatchCreateRequest) error {
+func (s *Service) CreateBatch(ctx context.Context, userID, profileID uuid.UUID, req BatchCreateRequest) error {
+	ctx, span := tracing.Start(ctx, "history.CreateBatch")
+	defer span.End()
+
 	if req.ShowID == uuid.Nil {
 		return errors.New("show_id required")
 	}
 	if req.SeasonNumber <= 0 {
 		return errors.New("season_number required")
 	}
-	if _, err := s.showRepo.FindByID(req.ShowID); err != nil {
+	if _, err := s.showRepo.FindByID(ctx, req.ShowID); err != nil {
 		return errors.New("show not found")
 	}
 
@@ -95,11 +122,12 @@ func (s *Service) CreateBatch(ctx context.Context, userID uuid.UUID, req BatchCr
 			continue
 		}
 		var episodeID *uuid.UUID
-		if ep, err := s.showRepo.GetEpisodeByNumber(req.ShowID, req.SeasonNumber, epNum); err == nil {
+		if ep, err := s.showRepo.GetEpisodeByNumber(ctx, req.ShowID, req.SeasonNumber, epNum); err == nil {
 			episodeID = &ep.ID
 		}
 		entries = append(entries, Entry{
 			UserID:        userID,
+			ProfileID:     profileID,
 			ShowID:        req.ShowID,
 			SeasonNumber:  req.SeasonNumber,
 			EpisodeNumber: epNum,
@@ -112,28 +140,46 @@ func (s *Service) CreateBatch(ctx context.Context, userID uuid.UUID, req BatchCr
 		return errors.New("no valid episodes")
 	}
 
-	if err := s.repo.CreateBatch(entries); err != nil {
+	if err := s.repo.CreateBatch(ctx, entries); err != nil {
 		return err
 	}
 
 	// Update last watched on tracked show
 	maxSeason := 0
 	maxEpisode := 0
+	var furthestID uuid.UUID
 	for _, entry := range entries {
 		if entry.SeasonNumber > maxSeason || (entry.SeasonNumber == maxSeason && entry.EpisodeNumber > maxEpisode) {
 			maxSeason = entry.SeasonNumber
 			maxEpisode = entry.EpisodeNumber
+			furthestID = entry.ID
 		}
 	}
 	if maxSeason > 0 {
-		_ = s.alertRepo.UpdateLastWatched(userID, req.ShowID, maxSeason, maxEpisode)
+		_ = s.alertRepo.UpdateLastWatched(ctx, profileID, req.ShowID, maxSeason, maxEpisode)
 	}
 
+	// One feed entry per batch; the furthest episode stands in for the rest,
+	// and deleting that entry removes it.
+	metadata, _ := json.Marshal(map[string]int{"episode_count": len(entries)})
+	_ = s.socialSvc.Record(ctx, &social.Activity{
+		UserID:        userID,
+		Type:          social.ActivityWatched,
+		ShowID:        req.ShowID,
+		SourceID:      &furthestID,
+		SeasonNumber:  &maxSeason,
+		EpisodeNumber: &maxEpisode,
+		Metadata:      datatypes.JSON(metadata),
+	})
+
 	return nil
 }
 
-func (s *Service) Update(ctx context.Context, userID uuid.UUID, id uuid.UUID, rating *int, notes *string) error {
-	entry, err := s.repo.GetByID(userID, id)
+func (s *Service) Update(ctx context.Context, userID, profileID, id uuid.UUID, rating *int, notes *string) error {
+	ctx, span := tracing.Start(ctx, "history.Update")
+	defer span.End()
+
+	entry, err := s.repo.GetByID(ctx, profileID, id)
 	if err != nil {
 		return errors.New("history entry not found")
 	}
@@ -146,25 +192,53 @@ func (s *Service) Update(ctx context.Context, userID uuid.UUID, id uuid.UUID, ra
 	if notes != nil {
 		entry.Notes = notes
 	}
-	return s.repo.Update(entry)
+	if err := s.repo.Update(ctx, entry); err != nil {
+		return err
+	}
+	if rating != nil {
+		_ = s.socialSvc.Record(ctx, &social.Activity{
+			UserID:        userID,
+			Type:          social.ActivityRated,
+			ShowID:        entry.ShowID,
+			SourceID:      &entry.ID,
+			SeasonNumber:  &entry.SeasonNumber,
+			EpisodeNumber: &entry.EpisodeNumber,
+			Rating:        entry.Rating,
+			Notes:         entry.Notes,
+		})
+	}
+	return nil
 }
 
-func (s *Service) Delete(ctx context.Context, userID, id uuid.UUID) error {
-	return s.repo.Delete(id, userID)
+func (s *Service) Delete(ctx context.Context, userID, profileID, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "history.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id, profileID); err != nil {
+		return err
+	}
+	_ = s.socialSvc.RemoveBySource(ctx, userID, id)
+	return nil
 }
 
-func (s *Service) Stats(ctx context.Context, userID uuid.UUID) (*Stats, error) {
-	return s.repo.GetStats(userID)
+func (s *Service) Stats(ctx context.Context, profileID uuid.UUID) (*Stats, error) {
+	ctx, span := tracing.Start(ctx, "history.Stats")
+	defer span.End()
+
+	return s.repo.GetStats(ctx, profileID)
 }
 
-func (s *Service) Progress(ctx context.Context, userID, showID uuid.UUID) (*Progress, error) {
+func (s *Service) Progress(ctx context.Context, profileID, showID uuid.UUID) (*Progress, error) {
+	ctx, span := tracing.Start(ctx, "history.Progress")
+	defer span.End()
+
 	// total episodes
-	total, err := s.showRepo.CountEpisodes(showID)
+	total, err := s.showRepo.CountEpisodes(ctx, showID)
 	if err != nil {
 		return nil, err
 	}
 	// watched episodes
-	entries, err := s.repo.ListByShow(userID, showID)
+	entries, err := s.repo.ListByShow(ctx, profileID, showID)
 	if err != nil {
 		return nil, err
 	}
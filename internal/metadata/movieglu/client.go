@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/tashifkhan/bingebeacon/internal/config"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type Client struct {
@@ -22,7 +24,7 @@ type Client struct {
 
 func NewClient(cfg config.MovieGluConfig) *Client {
 	return &Client{
-		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		httpClient:    &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(metrics.InstrumentTransport("movieglu", nil))},
 		apiKey:        cfg.APIKey,
 		authorization: cfg.Authorization,
 		clientID:      cfg.ClientID,
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/tashifkhan/bingebeacon/internal/config"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type Client struct {
@@ -20,7 +22,8 @@ type Client struct {
 func NewClient(cfg config.OMDBConfig) *Client {
 	return &Client{
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(metrics.InstrumentTransport("omdb", nil)),
 		},
 		apiKey:  cfg.APIKey,
 		baseURL: cfg.BaseURL,
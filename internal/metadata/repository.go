@@ -1,6 +1,7 @@
 package metadata
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,15 +25,15 @@ type Repository struct{ db *gorm.DB }
 
 func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
 
-func (r *Repository) Start(source string, showID *uuid.UUID) (*SyncLog, error) {
+func (r *Repository) Start(ctx context.Context, source string, showID *uuid.UUID) (*SyncLog, error) {
 	entry := &SyncLog{Source: source, ShowID: showID, Status: "running", StartedAt: time.Now()}
-	if err := r.db.Create(entry).Error; err != nil {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
 		return nil, err
 	}
 	return entry, nil
 }
 
-func (r *Repository) Finish(id uuid.UUID, status string, recordsUpdated int, syncErr error) error {
+func (r *Repository) Finish(ctx context.Context, id uuid.UUID, status string, recordsUpdated int, syncErr error) error {
 	now := time.Now()
 	updates := map[string]interface{}{
 		"status": status, "records_updated": recordsUpdated, "finished_at": now,
@@ -41,19 +42,19 @@ func (r *Repository) Finish(id uuid.UUID, status string, recordsUpdated int, syn
 		message := syncErr.Error()
 		updates["error_message"] = message
 	}
-	return r.db.Model(&SyncLog{}).Where("id = ?", id).Updates(updates).Error
+	return r.db.WithContext(ctx).Model(&SyncLog{}).Where("id = ?", id).Updates(updates).Error
 }
 
-func (r *Repository) Recent(limit int) ([]SyncLog, error) {
+func (r *Repository) Recent(ctx context.Context, limit int) ([]SyncLog, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 20
 	}
 	var entries []SyncLog
-	err := r.db.Order("started_at DESC").Limit(limit).Find(&entries).Error
+	err := r.db.WithContext(ctx).Order("started_at DESC").Limit(limit).Find(&entries).Error
 	return entries, err
 }
 
-func (r *Repository) DeleteOlderThan(cutoff time.Time) (int64, error) {
-	result := r.db.Where("started_at < ?", cutoff).Delete(&SyncLog{})
+func (r *Repository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("started_at < ?", cutoff).Delete(&SyncLog{})
 	return result.RowsAffected, result.Error
 }
@@ -5,16 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
+	"github.com/lib/pq"
 	"github.com/tashifkhan/bingebeacon/internal/alert"
 	"github.com/tashifkhan/bingebeacon/internal/metadata/omdb"
 	"github.com/tashifkhan/bingebeacon/internal/metadata/thetvdb"
 	"github.com/tashifkhan/bingebeacon/internal/metadata/tmdb"
 	"github.com/tashifkhan/bingebeacon/internal/notification"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
 	"github.com/tashifkhan/bingebeacon/internal/show"
 	"github.com/tashifkhan/bingebeacon/internal/timeline"
 	"gorm.io/datatypes"
@@ -29,7 +31,7 @@ type Syncer struct {
 	timelineRepo *timeline.Repository
 	notifRepo    *notification.Repository
 	syncRepo     *Repository
-	redis        *redis.Client
+	cache        cache.Store
 	logger       *slog.Logger
 }
 
@@ -42,7 +44,7 @@ func NewSyncer(
 	timelineRepo *timeline.Repository,
 	notifRepo *notification.Repository,
 	syncRepo *Repository,
-	rdb *redis.Client,
+	store cache.Store,
 	logger *slog.Logger,
 ) *Syncer {
 	return &Syncer{
@@ -54,30 +56,33 @@ func NewSyncer(
 		timelineRepo: timelineRepo,
 		notifRepo:    notifRepo,
 		syncRepo:     syncRepo,
-		redis:        rdb,
+		cache:        store,
 		logger:       logger,
 	}
 }
 
 func (s *Syncer) SyncShow(ctx context.Context, showID uuid.UUID) error {
-	entry, logErr := s.syncRepo.Start("multi", &showID)
+	ctx, span := tracing.Start(ctx, "metadata.SyncShow")
+	defer span.End()
+
+	entry, logErr := s.syncRepo.Start(ctx, "multi", &showID)
 	err := s.syncShow(ctx, showID)
 	if logErr == nil {
 		status := "success"
 		if err != nil {
 			status = "failed"
 		}
-		if finishErr := s.syncRepo.Finish(entry.ID, status, 0, err); finishErr != nil {
-			s.logger.Warn("Failed to finish sync log", "show_id", showID, "error", finishErr)
+		if finishErr := s.syncRepo.Finish(ctx, entry.ID, status, 0, err); finishErr != nil {
+			s.logger.WarnContext(ctx, "Failed to finish sync log", "show_id", showID, "error", finishErr)
 		}
 	}
 	return err
 }
 
 func (s *Syncer) syncShow(ctx context.Context, showID uuid.UUID) error {
-	s.logger.Info("Syncing show", "show_id", showID)
+	s.logger.InfoContext(ctx, "Syncing show", "show_id", showID)
 
-	localShow, err := s.showRepo.FindByID(showID)
+	localShow, err := s.showRepo.FindByID(ctx, showID)
 	if err != nil {
 		return fmt.Errorf("failed to find show: %w", err)
 	}
@@ -99,6 +104,7 @@ func (s *Syncer) syncShow(ctx context.Context, showID uuid.UUID) error {
 	previousStatus := show.SafeString(localShow.Status)
 	// Update Show
 	localShow.Title = tmdbShow.Name
+	localShow.AlternativeTitles = alternativeTitles(tmdbShow.Name, tmdbShow.OriginalName)
 	localShow.Overview = &tmdbShow.Overview
 	localShow.PosterURL = &tmdbShow.PosterPath
 	localShow.BackdropURL = &tmdbShow.BackdropPath
@@ -123,7 +129,7 @@ func (s *Syncer) syncShow(ctx context.Context, showID uuid.UUID) error {
 		localShow.IMDBID = extIDs.IMDBID
 		localShow.TheTVDBID = extIDs.TVDBID
 	} else {
-		s.logger.Warn("Failed to fetch external IDs from TMDB", "show_id", showID, "error", err)
+		s.logger.WarnContext(ctx, "Failed to fetch external IDs from TMDB", "show_id", showID, "error", err)
 	}
 
 	// Enrich with OMDB Ratings (if IMDB ID exists)
@@ -156,23 +162,18 @@ func (s *Syncer) syncShow(ctx context.Context, showID uuid.UUID) error {
 			jsonBytes, _ := json.Marshal(ratingsMap)
 			localShow.Ratings = datatypes.JSON(jsonBytes)
 		} else {
-			s.logger.Warn("Failed to enrich with OMDB", "imdb_id", *localShow.IMDBID, "error", err)
+			s.logger.WarnContext(ctx, "Failed to enrich with OMDB", "imdb_id", *localShow.IMDBID, "error", err)
 		}
 	}
 
-	if err := s.showRepo.UpsertFromTMDB(localShow); err != nil {
-		return fmt.Errorf("failed to update show: %w", err)
-	}
-	if previousStatus != "" && previousStatus != tmdbShow.Status {
-		s.createStatusEvent(localShow, previousStatus, tmdbShow.Status)
-	}
-
+	// Fetch every season before writing so the show is saved in one
+	// transaction without holding it open across provider calls.
+	var seasons []show.SeasonSync
 	var episodesToBackfill []*show.Episode
-
 	for _, tmdbSeason := range tmdbShow.Seasons {
 		fullSeason, err := s.tmdb.GetTVSeason(ctx, *localShow.TMDBID, tmdbSeason.SeasonNumber)
 		if err != nil {
-			s.logger.Error("Failed to fetch season details", "season", tmdbSeason.SeasonNumber, "error", err)
+			s.logger.ErrorContext(ctx, "Failed to fetch season details", "season", tmdbSeason.SeasonNumber, "error", err)
 			continue
 		}
 
@@ -183,7 +184,7 @@ func (s *Syncer) syncShow(ctx context.Context, showID uuid.UUID) error {
 		epCount := len(fullSeason.Episodes)
 		seasonTMDBID := fullSeason.ID
 
-		season := &show.Season{
+		synced := show.SeasonSync{Season: &show.Season{
 			ShowID:       localShow.ID,
 			SeasonNumber: fullSeason.SeasonNumber,
 			Name:         &seasonName,
@@ -192,29 +193,22 @@ func (s *Syncer) syncShow(ctx context.Context, showID uuid.UUID) error {
 			AirDate:      seasonAirDate,
 			EpisodeCount: &epCount,
 			TMDBID:       &seasonTMDBID,
-		}
-
-		if err := s.showRepo.UpsertSeason(season); err != nil {
-			s.logger.Error("Failed to upsert season", "season", season.SeasonNumber, "error", err)
-			continue
-		}
+		}}
 
 		for _, tmdbEp := range fullSeason.Episodes {
 			epName := tmdbEp.Name
 			epOverview := tmdbEp.Overview
-			epAirDate := parseDate(tmdbEp.AirDate)
 			epStill := tmdbEp.StillPath
 			epRuntime := tmdbEp.Runtime
 			epTMDBID := tmdbEp.ID
 
 			ep := &show.Episode{
 				ShowID:         localShow.ID,
-				SeasonID:       season.ID,
-				SeasonNumber:   season.SeasonNumber,
+				SeasonNumber:   fullSeason.SeasonNumber,
 				EpisodeNumber:  tmdbEp.EpisodeNumber,
 				Title:          &epName,
 				Overview:       &epOverview,
-				AirDate:        epAirDate,
+				AirDate:        parseDate(tmdbEp.AirDate),
 				RuntimeMinutes: &epRuntime,
 				StillURL:       &epStill,
 				TMDBID:         &epTMDBID,
@@ -224,43 +218,70 @@ func (s *Syncer) syncShow(ctx context.Context, showID uuid.UUID) error {
 			if ep.AirDate == nil {
 				episodesToBackfill = append(episodesToBackfill, ep)
 			}
-
-			created, err := s.showRepo.UpsertEpisode(ep)
-			if err != nil {
-				s.logger.Error("Failed to upsert episode", "ep", ep.EpisodeNumber, "error", err)
-				continue
-			}
-
-			if created && ep.AirDate != nil && ep.AirDate.After(time.Now()) {
-				// New upcoming episode -> Generate Timeline Event
-				s.createTimelineEvent(localShow, season, ep)
-			}
+			synced.Episodes = append(synced.Episodes, ep)
 		}
+		seasons = append(seasons, synced)
 	}
 
 	// Backfill Air Dates from TheTVDB if needed
 	if len(episodesToBackfill) > 0 && localShow.TheTVDBID != nil {
 		if err := s.backfillAirDates(ctx, *localShow.TheTVDBID, episodesToBackfill); err != nil {
-			s.logger.Warn("Failed to backfill air dates", "error", err)
+			s.logger.WarnContext(ctx, "Failed to backfill air dates", "error", err)
 		}
 	}
 
-	// Invalidate Cache
-	// - Show details: show:{id}
-	// - Seasons: season:{id}:*
-	// - Episodes: episodes:{id}:*
-	s.redis.Del(ctx, fmt.Sprintf("show:%s", showID))
-	cache.Invalidate(ctx, s.redis, fmt.Sprintf("season:%s:*", showID))
-	cache.Invalidate(ctx, s.redis, fmt.Sprintf("episodes:%s:*", showID))
+	changes, err := s.showRepo.ApplySync(ctx, localShow, seasons)
+	if err != nil {
+		return fmt.Errorf("failed to save show: %w", err)
+	}
+	if previousStatus != "" && previousStatus != tmdbShow.Status {
+		s.createStatusEvent(ctx, localShow, previousStatus, tmdbShow.Status)
+	}
+
+	// New upcoming episodes get a timeline event, as do known ones that
+	// have just been given a future air date. Known episodes whose air date
+	// moved have their event rescheduled.
+	for _, ep := range changes.Inserted {
+		if ep.AirDate != nil && ep.AirDate.After(time.Now()) {
+			s.createTimelineEvent(ctx, localShow, ep)
+		}
+	}
+	for _, ep := range changes.Updated {
+		if ep.AirDate == nil {
+			continue
+		}
+		event, err := s.timelineRepo.FindByShowAndEpisode(ctx, localShow.ID, ep.ID)
+		if err != nil {
+			if ep.AirDate.After(time.Now()) {
+				s.createTimelineEvent(ctx, localShow, ep)
+			}
+			continue
+		}
+		if !event.EventDate.Equal(*ep.AirDate) {
+			s.rescheduleTimelineEvent(ctx, event, *ep.AirDate)
+		}
+	}
+
+	// Invalidate Cache: show details, seasons, episodes and streaming carry
+	// the show's tag.
+	if err := s.cache.InvalidateTags(ctx, fmt.Sprintf("show:%s", showID)); err != nil {
+		s.logger.WarnContext(ctx, "Failed to invalidate show cache", "show_id", showID, "error", err)
+	}
 
 	return nil
 }
 
-func (s *Syncer) RecentLogs(limit int) ([]SyncLog, error) {
-	return s.syncRepo.Recent(limit)
+func (s *Syncer) RecentLogs(ctx context.Context, limit int) ([]SyncLog, error) {
+	ctx, span := tracing.Start(ctx, "metadata.RecentLogs")
+	defer span.End()
+
+	return s.syncRepo.Recent(ctx, limit)
 }
 
 func (s *Syncer) SyncChanged(ctx context.Context, from, to time.Time) error {
+	ctx, span := tracing.Start(ctx, "metadata.SyncChanged")
+	defer span.End()
+
 	for _, mediaType := range []string{"tv", "movie"} {
 		changes, err := s.tmdb.GetChanges(ctx, mediaType, from, to)
 		if err != nil {
@@ -270,23 +291,23 @@ func (s *Syncer) SyncChanged(ctx context.Context, from, to time.Time) error {
 		for _, item := range changes.Results {
 			ids = append(ids, item.ID)
 		}
-		localShows, err := s.showRepo.FindByTMDBIDs(mediaType, ids)
+		localShows, err := s.showRepo.FindByTMDBIDs(ctx, mediaType, ids)
 		if err != nil {
 			return err
 		}
 		for i := range localShows {
 			if err := s.SyncShow(ctx, localShows[i].ID); err != nil {
-				s.logger.Warn("Changed title sync failed", "show_id", localShows[i].ID, "error", err)
+				s.logger.WarnContext(ctx, "Changed title sync failed", "show_id", localShows[i].ID, "error", err)
 			}
 		}
 	}
 	return nil
 }
 
-func (s *Syncer) createTimelineEvent(show *show.Show, season *show.Season, ep *show.Episode) {
+func (s *Syncer) createTimelineEvent(ctx context.Context, show *show.Show, ep *show.Episode) {
 	eventType := "new_episode"
 	if ep.EpisodeNumber == 1 {
-		if season.SeasonNumber == 1 {
+		if ep.SeasonNumber == 1 {
 			eventType = "series_premiere"
 		} else {
 			eventType = "season_premiere"
@@ -295,19 +316,31 @@ func (s *Syncer) createTimelineEvent(show *show.Show, season *show.Season, ep *s
 	event := &timeline.TimelineEvent{
 		ShowID:        show.ID,
 		EventType:     eventType,
-		Title:         fmt.Sprintf("%s - S%02dE%02d", show.Title, season.SeasonNumber, ep.EpisodeNumber),
+		Title:         fmt.Sprintf("%s - S%02dE%02d", show.Title, ep.SeasonNumber, ep.EpisodeNumber),
 		Description:   ep.Overview,
 		EventDate:     *ep.AirDate,
-		SeasonNumber:  &season.SeasonNumber,
+		SeasonNumber:  &ep.SeasonNumber,
 		EpisodeNumber: &ep.EpisodeNumber,
 		EpisodeID:     &ep.ID,
 		Metadata:      datatypes.JSON([]byte("{}")),
 	}
-	if err := s.timelineRepo.Create(event); err != nil {
-		s.logger.Error("Failed to create timeline event", "error", err)
+	if err := s.timelineRepo.Create(ctx, event); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create timeline event", "error", err)
+		return
+	}
+	s.queueNotifications(ctx, event, notificationDetails(event, show, ep))
+}
+
+// rescheduleTimelineEvent moves an event and its pending notifications by
+// the same amount. Profile timelines follow the event through triggers.
+func (s *Syncer) rescheduleTimelineEvent(ctx context.Context, event *timeline.TimelineEvent, date time.Time) {
+	if err := s.timelineRepo.Reschedule(ctx, event.ID, date); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to reschedule timeline event", "event_id", event.ID, "error", err)
 		return
 	}
-	s.queueNotifications(event)
+	if err := s.notifRepo.ShiftPending(ctx, event.ID, date.Sub(event.EventDate)); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to reschedule notifications", "event_id", event.ID, "error", err)
+	}
 }
 
 func (s *Syncer) syncMovie(ctx context.Context, localShow *show.Show) error {
@@ -318,6 +351,7 @@ func (s *Syncer) syncMovie(ctx context.Context, localShow *show.Show) error {
 
 	previousStatus := show.SafeString(localShow.Status)
 	localShow.Title = movie.Title
+	localShow.AlternativeTitles = alternativeTitles(movie.Title, movie.OriginalTitle)
 	localShow.Overview = &movie.Overview
 	localShow.PosterURL = &movie.PosterPath
 	localShow.BackdropURL = &movie.BackdropPath
@@ -332,18 +366,18 @@ func (s *Syncer) syncMovie(ctx context.Context, localShow *show.Show) error {
 	if extIDs, extErr := s.tmdb.GetExternalIDs(ctx, "movie", *localShow.TMDBID); extErr == nil {
 		localShow.IMDBID = extIDs.IMDBID
 	} else {
-		s.logger.Warn("Failed to fetch movie external IDs", "show_id", localShow.ID, "error", extErr)
+		s.logger.WarnContext(ctx, "Failed to fetch movie external IDs", "show_id", localShow.ID, "error", extErr)
 	}
 	s.enrichFromOMDB(ctx, localShow)
 
-	if err := s.showRepo.UpsertFromTMDB(localShow); err != nil {
+	if err := s.showRepo.UpsertFromTMDB(ctx, localShow); err != nil {
 		return fmt.Errorf("failed to update movie: %w", err)
 	}
 	if previousStatus != "" && previousStatus != movie.Status {
-		s.createStatusEvent(localShow, previousStatus, movie.Status)
+		s.createStatusEvent(ctx, localShow, previousStatus, movie.Status)
 	}
 	if localShow.PremiereDate != nil && localShow.PremiereDate.After(time.Now()) {
-		if _, findErr := s.timelineRepo.FindByShowTypeAndDate(localShow.ID, "movie_release", *localShow.PremiereDate); findErr != nil {
+		if _, findErr := s.timelineRepo.FindByShowTypeAndDate(ctx, localShow.ID, "movie_release", *localShow.PremiereDate); findErr != nil {
 			event := &timeline.TimelineEvent{
 				ShowID:      localShow.ID,
 				EventType:   "movie_release",
@@ -352,30 +386,33 @@ func (s *Syncer) syncMovie(ctx context.Context, localShow *show.Show) error {
 				EventDate:   *localShow.PremiereDate,
 				Metadata:    datatypes.JSON([]byte("{}")),
 			}
-			if createErr := s.timelineRepo.Create(event); createErr != nil {
-				s.logger.Error("Failed to create movie release event", "error", createErr)
+			if createErr := s.timelineRepo.Create(ctx, event); createErr != nil {
+				s.logger.ErrorContext(ctx, "Failed to create movie release event", "error", createErr)
 			} else {
-				s.queueNotifications(event)
+				s.queueNotifications(ctx, event, notificationDetails(event, localShow, nil))
 			}
 		}
 	}
 
-	s.redis.Del(ctx, fmt.Sprintf("show:%s", localShow.ID))
+	if err := s.cache.InvalidateTags(ctx, fmt.Sprintf("show:%s", localShow.ID)); err != nil {
+		s.logger.WarnContext(ctx, "Failed to invalidate show cache", "show_id", localShow.ID, "error", err)
+	}
 	return nil
 }
 
-func (s *Syncer) createStatusEvent(localShow *show.Show, previousStatus, currentStatus string) {
+func (s *Syncer) createStatusEvent(ctx context.Context, localShow *show.Show, previousStatus, currentStatus string) {
 	description := fmt.Sprintf("Status changed from %s to %s", previousStatus, currentStatus)
+	metadata, _ := json.Marshal(map[string]string{"status_from": previousStatus, "status_to": currentStatus})
 	event := &timeline.TimelineEvent{
 		ShowID: localShow.ID, EventType: "status_change",
 		Title: fmt.Sprintf("%s status updated", localShow.Title), Description: &description,
-		EventDate: time.Now(), Metadata: datatypes.JSON([]byte("{}")),
+		EventDate: time.Now(), Metadata: datatypes.JSON(metadata),
 	}
-	if err := s.timelineRepo.Create(event); err != nil {
-		s.logger.Error("Failed to create status event", "show_id", localShow.ID, "error", err)
+	if err := s.timelineRepo.Create(ctx, event); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create status event", "show_id", localShow.ID, "error", err)
 		return
 	}
-	s.queueNotifications(event)
+	s.queueNotifications(ctx, event, notificationDetails(event, localShow, nil))
 }
 
 func (s *Syncer) enrichFromOMDB(ctx context.Context, localShow *show.Show) {
@@ -384,7 +421,7 @@ func (s *Syncer) enrichFromOMDB(ctx context.Context, localShow *show.Show) {
 	}
 	detail, err := s.omdb.GetByIMDBID(ctx, *localShow.IMDBID)
 	if err != nil {
-		s.logger.Warn("Failed to enrich with OMDB", "imdb_id", *localShow.IMDBID, "error", err)
+		s.logger.WarnContext(ctx, "Failed to enrich with OMDB", "imdb_id", *localShow.IMDBID, "error", err)
 		return
 	}
 	ratings := map[string]interface{}{
@@ -405,40 +442,60 @@ func (s *Syncer) enrichFromOMDB(ctx context.Context, localShow *show.Show) {
 	}
 }
 
-func (s *Syncer) queueNotifications(event *timeline.TimelineEvent) {
-	tracks, err := s.alertRepo.GetUsersTrackingShow(event.ShowID)
+func (s *Syncer) queueNotifications(ctx context.Context, event *timeline.TimelineEvent, details notification.Details) {
+	tracks, err := s.alertRepo.GetUsersTrackingShow(ctx, event.ShowID)
 	if err != nil {
-		s.logger.Error("Failed to find notification recipients", "event_id", event.ID, "error", err)
+		s.logger.ErrorContext(ctx, "Failed to find notification recipients", "event_id", event.ID, "error", err)
 		return
 	}
 	for _, track := range tracks {
-		if err := s.queueNotification(event, &track); err != nil {
-			s.logger.Error("Failed to queue notification", "event_id", event.ID, "user_id", track.UserID, "error", err)
+		if err := s.queueNotification(ctx, event, &track, details); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to queue notification", "event_id", event.ID, "profile_id", track.ProfileID, "error", err)
 		}
 	}
 }
 
-func (s *Syncer) QueueShowNotifications(ctx context.Context, userID, showID uuid.UUID) error {
+func (s *Syncer) QueueShowNotifications(ctx context.Context, profileID, showID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "metadata.QueueShowNotifications")
+	defer span.End()
+
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	track, err := s.alertRepo.FindByUserAndShow(userID, showID)
+	track, err := s.alertRepo.FindByProfileAndShow(ctx, profileID, showID)
+	if err != nil {
+		return err
+	}
+	localShow, err := s.showRepo.FindByID(ctx, showID)
+	if err != nil {
+		return err
+	}
+	events, err := s.timelineRepo.GetShowUpcomingEvents(ctx, showID, time.Now())
 	if err != nil {
 		return err
 	}
-	events, err := s.timelineRepo.GetShowUpcomingEvents(showID, time.Now())
+	episodes, err := s.showRepo.GetEpisodes(ctx, showID, true)
 	if err != nil {
 		return err
 	}
+	episodesByID := make(map[uuid.UUID]*show.Episode, len(episodes))
+	for i := range episodes {
+		episodesByID[episodes[i].ID] = &episodes[i]
+	}
 	for i := range events {
-		if err := s.queueNotification(&events[i], track); err != nil {
+		var ep *show.Episode
+		if events[i].EpisodeID != nil {
+			ep = episodesByID[*events[i].EpisodeID]
+		}
+		details := notificationDetails(&events[i], localShow, ep)
+		if err := s.queueNotification(ctx, &events[i], track, details); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (s *Syncer) queueNotification(event *timeline.TimelineEvent, track *alert.UserTrackedShow) error {
+func (s *Syncer) queueNotification(ctx context.Context, event *timeline.TimelineEvent, track *alert.UserTrackedShow, details notification.Details) error {
 	shouldNotify := track.NotifyNewEpisode
 	if event.EventType == "season_premiere" || event.EventType == "series_premiere" {
 		shouldNotify = track.NotifyNewSeason
@@ -453,18 +510,45 @@ func (s *Syncer) queueNotification(event *timeline.TimelineEvent, track *alert.U
 	if scheduledFor.Before(time.Now()) {
 		scheduledFor = time.Now()
 	}
-	payload, _ := json.Marshal(map[string]string{
-		"event_id": event.ID.String(), "show_id": event.ShowID.String(), "type": event.EventType,
-	})
-	return s.notifRepo.Create(&notification.Notification{
-		UserID: track.UserID, TimelineEventID: &event.ID, Title: event.Title,
-		Body: "A release you track is coming up.", Payload: datatypes.JSON(payload),
+	payload, _ := json.Marshal(details)
+	title, body := notification.DefaultContent(details)
+	return s.notifRepo.Create(ctx, &notification.Notification{
+		UserID: track.UserID, ProfileID: track.ProfileID, TimelineEventID: &event.ID, Title: title,
+		Body: body, Payload: datatypes.JSON(payload),
 		Status: "pending", ScheduledFor: scheduledFor,
 	})
 }
 
+// notificationDetails describes an event for its notifications. ep is nil
+// for events that aren't about one episode.
+func notificationDetails(event *timeline.TimelineEvent, localShow *show.Show, ep *show.Episode) notification.Details {
+	details := notification.Details{
+		Type: event.EventType, EventID: &event.ID, ShowID: event.ShowID, ShowTitle: localShow.Title,
+		Season: event.SeasonNumber, Episode: event.EpisodeNumber,
+		PosterPath: show.SafeString(localShow.PosterURL),
+	}
+	if event.EventType == "status_change" {
+		var status struct {
+			From string `json:"status_from"`
+			To   string `json:"status_to"`
+		}
+		_ = json.Unmarshal(event.Metadata, &status)
+		details.StatusFrom, details.StatusTo = status.From, status.To
+	} else {
+		airTime := event.EventDate
+		details.AirTime = &airTime
+	}
+	if ep != nil {
+		details.EpisodeTitle = show.SafeString(ep.Title)
+		details.StillPath = show.SafeString(ep.StillURL)
+	}
+	return details
+}
+
+// backfillAirDates fills in air dates TMDB lacks before the episodes are
+// saved, so backfilled upcoming episodes get timeline events too.
 func (s *Syncer) backfillAirDates(ctx context.Context, tvdbID int, episodes []*show.Episode) error {
-	s.logger.Info("Backfilling air dates from TheTVDB", "tvdb_id", tvdbID, "count", len(episodes))
+	s.logger.InfoContext(ctx, "Backfilling air dates from TheTVDB", "tvdb_id", tvdbID, "count", len(episodes))
 
 	resp, err := s.thetvdb.GetSeriesEpisodes(ctx, tvdbID, "default", "eng")
 	if err != nil {
@@ -483,21 +567,21 @@ func (s *Syncer) backfillAirDates(ctx context.Context, tvdbID int, episodes []*s
 		if dateStr, ok := dateMap[key]; ok && dateStr != "" {
 			if t, err := time.Parse("2006-01-02", dateStr); err == nil {
 				ep.AirDate = &t
-				// Update in DB
-				s.showRepo.UpsertEpisode(ep)
-
-				// Check if we should generate timeline event now
-				if ep.AirDate.After(time.Now()) {
-					// We need the season object here, but we don't have it easily.
-					// Simplified: Skip timeline event for backfilled episodes to avoid complexity or duplicate query
-					// Or fetch season? Let's just update the DB for now.
-				}
 			}
 		}
 	}
 	return nil
 }
 
+// alternativeTitles keeps the original-language title searchable when it
+// differs from the localised one.
+func alternativeTitles(title, original string) pq.StringArray {
+	if original == "" || strings.EqualFold(original, title) {
+		return pq.StringArray{}
+	}
+	return pq.StringArray{original}
+}
+
 func parseDate(dateStr string) *time.Time {
 	if dateStr == "" {
 		return nil
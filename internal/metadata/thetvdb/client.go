@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/tashifkhan/bingebeacon/internal/config"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type Client struct {
@@ -27,7 +29,8 @@ type Client struct {
 func NewClient(cfg config.TheTVDBConfig, logger *slog.Logger) *Client {
 	return &Client{
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(metrics.InstrumentTransport("thetvdb", nil)),
 		},
 		apiKey:  cfg.APIKey,
 		pin:     cfg.PIN,
@@ -80,7 +83,7 @@ func (c *Client) authenticate(ctx context.Context) error {
 	c.token = result.Data.Token
 	// Token valid for 30 days, let's set expiry to 25 days to be safe
 	c.tokenExp = time.Now().Add(25 * 24 * time.Hour)
-	c.logger.Info("TheTVDB authenticated successfully")
+	c.logger.InfoContext(ctx, "TheTVDB authenticated successfully")
 
 	return nil
 }
@@ -99,7 +102,7 @@ func (c *Client) do(ctx context.Context, method, path string, dest interface{})
 	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Content-Type", "application/json")
 
-	c.logger.Debug("Calling TheTVDB API", "url", url)
+	c.logger.DebugContext(ctx, "Calling TheTVDB API", "url", url)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/tashifkhan/bingebeacon/internal/config"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/time/rate"
 )
 
@@ -29,7 +31,8 @@ type Client struct {
 func NewClient(cfg config.TMDBConfig, logger *slog.Logger) *Client {
 	return &Client{
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(metrics.InstrumentTransport("tmdb", nil)),
 		},
 		apiKey:  cfg.APIKey,
 		baseURL: cfg.BaseURL,
@@ -48,6 +51,9 @@ func (c *Client) do(ctx context.Context, method, path string, queryParams map[st
 
 	var lastErr error
 	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			metrics.ProviderRetry("tmdb")
+		}
 		if err := c.limiter.Wait(ctx); err != nil {
 			return err
 		}
@@ -55,7 +61,7 @@ func (c *Client) do(ctx context.Context, method, path string, queryParams map[st
 		if err != nil {
 			return err
 		}
-		c.logger.Debug("Calling TMDB API", "path", path, "attempt", attempt+1)
+		c.logger.DebugContext(ctx, "Calling TMDB API", "path", path, "attempt", attempt+1)
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
 			lastErr = err
@@ -85,7 +91,7 @@ func (c *Client) do(ctx context.Context, method, path string, queryParams map[st
 			return lastErr
 		}
 		delay := retryDelay(resp.Header.Get("Retry-After"), attempt)
-		c.logger.Warn("TMDB request will retry", "status", resp.StatusCode, "delay", delay)
+		c.logger.WarnContext(ctx, "TMDB request will retry", "status", resp.StatusCode, "delay", delay)
 		if err := waitForRetry(ctx, delay); err != nil {
 			return err
 		}
@@ -112,8 +118,10 @@ func (c *Client) checkCircuit() error {
 	c.breakerMu.Lock()
 	defer c.breakerMu.Unlock()
 	if time.Now().Before(c.openUntil) {
+		metrics.ProviderRejected("tmdb")
 		return fmt.Errorf("tmdb circuit is open until %s", c.openUntil.Format(time.RFC3339))
 	}
+	metrics.SetCircuitOpen("tmdb", false)
 	return nil
 }
 
@@ -131,6 +139,7 @@ func (c *Client) recordFailure() {
 	if c.failures >= 5 {
 		c.openUntil = time.Now().Add(30 * time.Second)
 		c.failures = 0
+		metrics.SetCircuitOpen("tmdb", true)
 	}
 }
 
@@ -211,6 +220,31 @@ func (c *Client) GetPopular(ctx context.Context, mediaType string, page int) (*P
 	return &resp, err
 }
 
+// GetRecommendations returns TMDB's "recommended" list for a title. The
+// results omit media_type, so it is filled in from mediaType.
+func (c *Client) GetRecommendations(ctx context.Context, mediaType string, tmdbID int) (*SearchResponse, error) {
+	return c.getRelated(ctx, mediaType, tmdbID, "recommendations")
+}
+
+// GetSimilar returns titles TMDB considers similar by keywords and genres.
+func (c *Client) GetSimilar(ctx context.Context, mediaType string, tmdbID int) (*SearchResponse, error) {
+	return c.getRelated(ctx, mediaType, tmdbID, "similar")
+}
+
+func (c *Client) getRelated(ctx context.Context, mediaType string, tmdbID int, list string) (*SearchResponse, error) {
+	if mediaType != "tv" && mediaType != "movie" {
+		return nil, fmt.Errorf("unsupported media type %q", mediaType)
+	}
+	var resp SearchResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/%s/%d/%s", mediaType, tmdbID, list), nil, &resp); err != nil {
+		return nil, err
+	}
+	for i := range resp.Results {
+		resp.Results[i].MediaType = mediaType
+	}
+	return &resp, nil
+}
+
 func (c *Client) GetWatchProviders(ctx context.Context, mediaType string, tmdbID int) (*WatchProvidersResponse, error) {
 	var resp WatchProvidersResponse
 	path := fmt.Sprintf("/%s/%d/watch/providers", mediaType, tmdbID)
@@ -53,6 +53,7 @@ type WatchProvider struct {
 type TVShowDetail struct {
 	ID           int       `json:"id"`
 	Name         string    `json:"name"`
+	OriginalName string    `json:"original_name"`
 	Overview     string    `json:"overview"`
 	PosterPath   string    `json:"poster_path"`
 	BackdropPath string    `json:"backdrop_path"`
@@ -64,15 +65,16 @@ type TVShowDetail struct {
 }
 
 type MovieDetail struct {
-	ID           int     `json:"id"`
-	Title        string  `json:"title"`
-	Overview     string  `json:"overview"`
-	PosterPath   string  `json:"poster_path"`
-	BackdropPath string  `json:"backdrop_path"`
-	Status       string  `json:"status"`
-	ReleaseDate  string  `json:"release_date"`
-	Runtime      int     `json:"runtime"`
-	Genres       []Genre `json:"genres"`
+	ID            int     `json:"id"`
+	Title         string  `json:"title"`
+	OriginalTitle string  `json:"original_title"`
+	Overview      string  `json:"overview"`
+	PosterPath    string  `json:"poster_path"`
+	BackdropPath  string  `json:"backdrop_path"`
+	Status        string  `json:"status"`
+	ReleaseDate   string  `json:"release_date"`
+	Runtime       int     `json:"runtime"`
+	Genres        []Genre `json:"genres"`
 }
 
 type Genre struct {
@@ -0,0 +1,122 @@
+package notification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Actions a push notification offers as buttons.
+const (
+	ActionMarkWatched = "mark_watched"
+	ActionRemindLater = "remind_later"
+	ActionMuteShow    = "mute_show"
+)
+
+// ErrInvalidAction is returned for action tokens that are forged, expired,
+// already used or no longer match their notification.
+var ErrInvalidAction = errors.New("invalid or expired notification action")
+
+// Action is one button in a push payload. The app posts Token to
+// /api/v1/notifications/actions when it is tapped; no session is needed.
+type Action struct {
+	Action string `json:"action"`
+	Title  string `json:"title"`
+	Token  string `json:"token"`
+}
+
+// ActionClaims is what an action token authorizes.
+type ActionClaims struct {
+	Action         string
+	NotificationID uuid.UUID
+	UserID         uuid.UUID
+	ProfileID      uuid.UUID
+	JTI            string
+	ExpiresAt      time.Time
+}
+
+// ActionSigner issues and checks action tokens, HMAC-signed with the JWT
+// secret and scoped to one notification and one action.
+type ActionSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewActionSigner(secret string, ttl time.Duration) *ActionSigner {
+	return &ActionSigner{secret: []byte(secret), ttl: ttl}
+}
+
+func (s *ActionSigner) Sign(n *Notification, action string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":             n.UserID.String(),
+		"type":            "notification_action",
+		"action":          action,
+		"notification_id": n.ID.String(),
+		"profile_id":      n.ProfileID.String(),
+		"jti":             uuid.NewString(),
+		"iat":             now.Unix(),
+		"exp":             now.Add(s.ttl).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+func (s *ActionSigner) Parse(tokenStr string) (*ActionClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidAction
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["type"] != "notification_action" {
+		return nil, ErrInvalidAction
+	}
+
+	action, _ := claims["action"].(string)
+	jti, _ := claims["jti"].(string)
+	notifID, _ := claims["notification_id"].(string)
+	userID, _ := claims["sub"].(string)
+	profileID, _ := claims["profile_id"].(string)
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil || jti == "" || !validAction(action) {
+		return nil, ErrInvalidAction
+	}
+
+	parsed := &ActionClaims{Action: action, JTI: jti, ExpiresAt: exp.Time}
+	if parsed.NotificationID, err = uuid.Parse(notifID); err != nil {
+		return nil, ErrInvalidAction
+	}
+	if parsed.UserID, err = uuid.Parse(userID); err != nil {
+		return nil, ErrInvalidAction
+	}
+	if parsed.ProfileID, err = uuid.Parse(profileID); err != nil {
+		return nil, ErrInvalidAction
+	}
+	return parsed, nil
+}
+
+func validAction(action string) bool {
+	return action == ActionMarkWatched || action == ActionRemindLater || action == ActionMuteShow
+}
+
+// actionsFor lists the actions that make sense for a notification: episodes
+// can be marked watched and their show muted, and anything still to come
+// can be snoozed. Status changes get none.
+func actionsFor(d Details) []string {
+	switch {
+	case d.Type == GroupPayloadType:
+		return []string{ActionMarkWatched, ActionRemindLater, ActionMuteShow}
+	case coalescedTypes[d.Type]:
+		if d.Season != nil && d.Episode != nil {
+			return []string{ActionMarkWatched, ActionRemindLater, ActionMuteShow}
+		}
+		return []string{ActionRemindLater, ActionMuteShow}
+	case d.Type == "movie_release":
+		return []string{ActionRemindLater}
+	default:
+		return nil
+	}
+}
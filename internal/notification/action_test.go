@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestActionTokens(t *testing.T) {
+	signer := NewActionSigner("0123456789abcdef0123456789abcdef", time.Hour)
+	n := &Notification{ID: uuid.New(), UserID: uuid.New(), ProfileID: uuid.New()}
+
+	token, err := signer.Sign(n, ActionMuteShow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := signer.Parse(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Action != ActionMuteShow || claims.NotificationID != n.ID || claims.UserID != n.UserID || claims.ProfileID != n.ProfileID {
+		t.Fatalf("claims = %+v", claims)
+	}
+
+	other := NewActionSigner("another-secret-another-secret-xx", time.Hour)
+	if _, err := other.Parse(token); err != ErrInvalidAction {
+		t.Fatalf("token from another secret: %v", err)
+	}
+	expired := NewActionSigner("0123456789abcdef0123456789abcdef", -time.Minute)
+	stale, _ := expired.Sign(n, ActionMuteShow)
+	if _, err := signer.Parse(stale); err != ErrInvalidAction {
+		t.Fatalf("expired token: %v", err)
+	}
+
+	// Access tokens signed with the same secret are not action tokens.
+	access, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": n.UserID.String(), "type": "access", "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("0123456789abcdef0123456789abcdef"))
+	if _, err := signer.Parse(access); err != ErrInvalidAction {
+		t.Fatalf("access token accepted: %v", err)
+	}
+}
+
+func TestPushActions(t *testing.T) {
+	season, episode := 1, 4
+	episodeNotif, _ := json.Marshal(Details{Type: "new_episode", ShowID: uuid.New(), ShowTitle: "Andor", Season: &season, Episode: &episode})
+	statusNotif, _ := json.Marshal(Details{Type: "status_change", ShowID: uuid.New(), ShowTitle: "Andor", StatusTo: "Ended"})
+	r := NewRenderer("", NewActionSigner("0123456789abcdef0123456789abcdef", time.Hour))
+
+	push := r.Render(&Notification{ID: uuid.New(), Payload: episodeNotif}, ChannelPush, "es", time.UTC)
+	if len(push.Actions) != 3 || push.Actions[0].Action != ActionMarkWatched || push.Actions[0].Title != "Marcar como visto" {
+		t.Fatalf("actions = %+v", push.Actions)
+	}
+	var listed []Action
+	if err := json.Unmarshal([]byte(push.Data["actions"]), &listed); err != nil || len(listed) != 3 {
+		t.Fatalf("data actions = %q", push.Data["actions"])
+	}
+
+	if push := r.Render(&Notification{ID: uuid.New(), Payload: statusNotif}, ChannelPush, "en", time.UTC); push.Actions != nil || push.Data["actions"] != "" {
+		t.Fatalf("status change offered actions: %+v", push.Actions)
+	}
+	if inApp := r.Render(&Notification{ID: uuid.New(), Payload: episodeNotif}, ChannelInApp, "en", time.UTC); inApp.Actions != nil {
+		t.Fatal("actions rendered outside push")
+	}
+}
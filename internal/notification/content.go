@@ -0,0 +1,228 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery channels, each rendered from the same Details.
+const (
+	ChannelPush  = "push"
+	ChannelEmail = "email"
+	ChannelInApp = "in_app"
+)
+
+const (
+	defaultLanguage = "en"
+	tmdbImageBase   = "https://image.tmdb.org/t/p"
+)
+
+// Details is what a notification is about. It is stored as the payload and
+// rendered per channel, language and time zone when the notification is
+// sent, so the text follows the recipient's current settings.
+type Details struct {
+	Type         string      `json:"type"`
+	EventID      *uuid.UUID  `json:"event_id,omitempty"`
+	ShowID       uuid.UUID   `json:"show_id"`
+	ShowTitle    string      `json:"show_title,omitempty"`
+	EpisodeTitle string      `json:"episode_title,omitempty"`
+	Season       *int        `json:"season,omitempty"`
+	Episode      *int        `json:"episode,omitempty"`
+	AirTime      *time.Time  `json:"air_time,omitempty"`
+	PosterPath   string      `json:"poster_path,omitempty"`
+	StillPath    string      `json:"still_path,omitempty"`
+	StatusFrom   string      `json:"status_from,omitempty"`
+	StatusTo     string      `json:"status_to,omitempty"`
+	EventIDs     []uuid.UUID `json:"event_ids,omitempty"`
+	Count        int         `json:"count,omitempty"`
+	FirstEpisode *int        `json:"first_episode,omitempty"`
+	LastEpisode  *int        `json:"last_episode,omitempty"`
+	// Reminder marks a notification resent by "remind me later".
+	Reminder bool `json:"reminder,omitempty"`
+	// Replayed marks a dead letter requeued by an admin; its email copy
+	// already went out on the first attempt.
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// ParseDetails reads a notification's payload. Payloads from before
+// Details existed only carry the IDs and type.
+func ParseDetails(n *Notification) Details {
+	var d Details
+	_ = json.Unmarshal(n.Payload, &d)
+	return d
+}
+
+// Content is a notification rendered for one channel.
+type Content struct {
+	Title    string
+	Body     string
+	ImageURL string
+	Link     string
+	// Actions are the push buttons, also listed in Data as JSON.
+	Actions []Action
+	// Data is the push payload the app reads to route the tap.
+	Data map[string]string
+}
+
+// Renderer renders notifications. appURL is the web app's origin, used for
+// deep links; without it links are left out. Without a signer pushes carry
+// no actions.
+type Renderer struct {
+	appURL string
+	signer *ActionSigner
+}
+
+func NewRenderer(appURL string, signer *ActionSigner) *Renderer {
+	return &Renderer{appURL: strings.TrimRight(appURL, "/"), signer: signer}
+}
+
+// Render renders n for a channel in the given language, showing times in
+// loc. Unknown languages fall back to English and notifications without
+// details keep their stored title and body.
+func (r *Renderer) Render(n *Notification, channel, lang string, loc *time.Location) Content {
+	d := ParseDetails(n)
+	msgs, ok := catalog[lang]
+	if !ok {
+		msgs = catalog[defaultLanguage]
+		lang = defaultLanguage
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	content := Content{Title: n.Title, Body: n.Body, Link: r.link(d), ImageURL: imageURL(d, channel)}
+	if tmpl, ok := msgs.templates[d.Type]; ok && d.ShowTitle != "" {
+		view := templateView{Details: d}
+		if d.AirTime != nil {
+			view.When = when(*d.AirTime, loc, msgs)
+		}
+		content.Title = execute(tmpl.title, view)
+		content.Body = execute(tmpl.body, view)
+	}
+
+	switch channel {
+	case ChannelEmail:
+		var b strings.Builder
+		b.WriteString(content.Body)
+		if content.Link != "" {
+			b.WriteString("\n\n")
+			b.WriteString(fmt.Sprintf(msgs.emailLink, content.Link))
+		}
+		b.WriteString("\n\n")
+		b.WriteString(msgs.emailFooter)
+		content.Body = b.String()
+	case ChannelPush:
+		content.Data = map[string]string{"notification_id": n.ID.String(), "type": d.Type, "lang": lang}
+		if d.ShowID != uuid.Nil {
+			content.Data["show_id"] = d.ShowID.String()
+		}
+		if d.EventID != nil {
+			content.Data["event_id"] = d.EventID.String()
+		}
+		if d.Season != nil {
+			content.Data["season"] = fmt.Sprint(*d.Season)
+		}
+		if d.Episode != nil {
+			content.Data["episode"] = fmt.Sprint(*d.Episode)
+		}
+		if content.Link != "" {
+			content.Data["link"] = content.Link
+		}
+		if content.ImageURL != "" {
+			content.Data["image"] = content.ImageURL
+		}
+		content.Actions = r.actions(n, d, msgs)
+		if len(content.Actions) > 0 {
+			encoded, _ := json.Marshal(content.Actions)
+			content.Data["actions"] = string(encoded)
+		}
+	}
+	return content
+}
+
+func (r *Renderer) actions(n *Notification, d Details, msgs messages) []Action {
+	if r.signer == nil {
+		return nil
+	}
+	var actions []Action
+	for _, action := range actionsFor(d) {
+		token, err := r.signer.Sign(n, action)
+		if err != nil {
+			continue
+		}
+		actions = append(actions, Action{Action: action, Title: msgs.actions[action], Token: token})
+	}
+	return actions
+}
+
+// DefaultContent is the English in-app text stored when a notification is
+// queued; dispatch re-renders it for the recipient.
+func DefaultContent(d Details) (title, body string) {
+	payload, _ := json.Marshal(d)
+	n := &Notification{Payload: payload}
+	c := (&Renderer{}).Render(n, ChannelInApp, defaultLanguage, time.UTC)
+	return c.Title, c.Body
+}
+
+func (r *Renderer) link(d Details) string {
+	if r.appURL == "" || d.ShowID == uuid.Nil {
+		return ""
+	}
+	link := fmt.Sprintf("%s/shows/%s", r.appURL, d.ShowID)
+	if d.Season != nil {
+		q := url.Values{"season": {fmt.Sprint(*d.Season)}}
+		if d.Episode != nil {
+			q.Set("episode", fmt.Sprint(*d.Episode))
+		}
+		link += "?" + q.Encode()
+	}
+	return link
+}
+
+// imageURL prefers the episode still for push, where the image is shown
+// wide, and the poster elsewhere.
+func imageURL(d Details, channel string) string {
+	path, size := d.PosterPath, "w500"
+	if channel == ChannelPush && d.StillPath != "" {
+		path, size = d.StillPath, "w780"
+	}
+	switch {
+	case path == "":
+		return ""
+	case strings.HasPrefix(path, "http"):
+		return path
+	default:
+		return fmt.Sprintf("%s/%s%s", tmdbImageBase, size, path)
+	}
+}
+
+// when formats an air time for the recipient. TMDB only gives episode air
+// dates, stored as UTC midnight; those are shown as the date itself rather
+// than shifted into the previous evening west of UTC.
+func when(t time.Time, loc *time.Location, msgs messages) string {
+	utc := t.UTC()
+	if utc.Equal(utc.Truncate(24 * time.Hour)) {
+		return utc.Format(msgs.dateLayout)
+	}
+	return t.In(loc).Format(msgs.timeLayout)
+}
+
+type templateView struct {
+	Details
+	When string
+}
+
+func execute(t *template.Template, view templateView) string {
+	var b bytes.Buffer
+	if err := t.Execute(&b, view); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(b.String())
+}
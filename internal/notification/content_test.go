@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/user"
+)
+
+func TestCatalogCoversLanguages(t *testing.T) {
+	types := append(coalescedTypeList(), "movie_release", "status_change", GroupPayloadType)
+	for _, lang := range user.Languages {
+		msgs, ok := catalog[lang]
+		if !ok {
+			t.Fatalf("no messages for %q", lang)
+		}
+		for _, eventType := range types {
+			if _, ok := msgs.templates[eventType]; !ok {
+				t.Errorf("%s has no %s template", lang, eventType)
+			}
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	season, episode := 2, 5
+	airTime := time.Date(2026, 3, 14, 1, 0, 0, 0, time.UTC)
+	showID := uuid.New()
+	payload, _ := json.Marshal(Details{
+		Type: "new_episode", ShowID: showID, ShowTitle: "Severance", EpisodeTitle: "Trojan's Horse",
+		Season: &season, Episode: &episode, AirTime: &airTime, PosterPath: "/poster.jpg", StillPath: "/still.jpg",
+	})
+	n := &Notification{ID: uuid.New(), Title: "stored", Body: "stored", Payload: payload}
+	newYork, _ := time.LoadLocation("America/New_York")
+	r := NewRenderer("https://app.example.com/", nil)
+
+	push := r.Render(n, ChannelPush, "en", newYork)
+	if push.Title != "Severance S02E05" || push.Body != "“Trojan's Horse” airs on Fri, Mar 13 at 9:00 PM EDT." {
+		t.Fatalf("push = %q / %q", push.Title, push.Body)
+	}
+	if push.ImageURL != "https://image.tmdb.org/t/p/w780/still.jpg" || push.Data["show_id"] != showID.String() {
+		t.Fatalf("push payload = %q %v", push.ImageURL, push.Data)
+	}
+	wantLink := "https://app.example.com/shows/" + showID.String() + "?episode=5&season=2"
+	if push.Link != wantLink {
+		t.Fatalf("link = %q", push.Link)
+	}
+
+	mail := r.Render(n, ChannelEmail, "de", time.UTC)
+	if !strings.HasPrefix(mail.Body, "„Trojan's Horse“ läuft am 14.03.2026 um 01:00 UTC.") || !strings.Contains(mail.Body, wantLink) {
+		t.Fatalf("email body = %q", mail.Body)
+	}
+
+	// Unknown languages fall back to English.
+	if c := r.Render(n, ChannelInApp, "xx", time.UTC); c.Title != "Severance S02E05" || c.ImageURL != "https://image.tmdb.org/t/p/w500/poster.jpg" {
+		t.Fatalf("fallback = %+v", c)
+	}
+
+	// Notifications queued before templates keep their stored text.
+	legacy := &Notification{Title: "Old", Body: "A release you track is coming up.", Payload: []byte(`{"type":"new_episode"}`)}
+	if c := r.Render(legacy, ChannelInApp, "fr", time.UTC); c.Title != "Old" || c.Body != legacy.Body {
+		t.Fatalf("legacy = %+v", c)
+	}
+}
+
+func TestRenderAirDate(t *testing.T) {
+	// Date-only air times stay on their day west of UTC.
+	airDate := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	payload, _ := json.Marshal(Details{Type: "movie_release", ShowTitle: "Dune", AirTime: &airDate})
+	losAngeles, _ := time.LoadLocation("America/Los_Angeles")
+	c := NewRenderer("", nil).Render(&Notification{Payload: payload}, ChannelInApp, "fr", losAngeles)
+	if c.Body != "Dune sort le 14/03/2026." || c.Link != "" {
+		t.Fatalf("got %q link %q", c.Body, c.Link)
+	}
+}
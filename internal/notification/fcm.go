@@ -2,6 +2,7 @@ package notification
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -41,14 +42,15 @@ func NewFCMClient(credentialsFile string, logger *slog.Logger) (*FCMClient, erro
 	}, nil
 }
 
-func (c *FCMClient) SendToDevice(ctx context.Context, token string, title, body string, data map[string]string) error {
+func (c *FCMClient) SendToDevice(ctx context.Context, token string, content Content) error {
 	message := &messaging.Message{
 		Token: token,
 		Notification: &messaging.Notification{
-			Title: title,
-			Body:  body,
+			Title:    content.Title,
+			Body:     content.Body,
+			ImageURL: content.ImageURL,
 		},
-		Data: data,
+		Data: content.Data,
 	}
 
 	response, err := c.client.Send(ctx, message)
@@ -56,11 +58,11 @@ func (c *FCMClient) SendToDevice(ctx context.Context, token string, title, body
 		return err
 	}
 
-	c.logger.Debug("Successfully sent message", "response_id", response)
+	c.logger.DebugContext(ctx, "Successfully sent message", "response_id", response)
 	return nil
 }
 
-func (c *FCMClient) SendToMultiple(ctx context.Context, tokens []string, title, body string, data map[string]string) (*messaging.BatchResponse, error) {
+func (c *FCMClient) SendToMultiple(ctx context.Context, tokens []string, content Content) (*messaging.BatchResponse, error) {
 	if len(tokens) == 0 {
 		return nil, nil
 	}
@@ -68,10 +70,11 @@ func (c *FCMClient) SendToMultiple(ctx context.Context, tokens []string, title,
 	message := &messaging.MulticastMessage{
 		Tokens: tokens,
 		Notification: &messaging.Notification{
-			Title: title,
-			Body:  body,
+			Title:    content.Title,
+			Body:     content.Body,
+			ImageURL: content.ImageURL,
 		},
-		Data: data,
+		Data: content.Data,
 	}
 
 	br, err := c.client.SendMulticast(ctx, message)
@@ -79,10 +82,16 @@ func (c *FCMClient) SendToMultiple(ctx context.Context, tokens []string, title,
 		return nil, err
 	}
 
-	c.logger.Debug("Sent multicast message", "success_count", br.SuccessCount, "failure_count", br.FailureCount)
+	c.logger.DebugContext(ctx, "Sent multicast message", "success_count", br.SuccessCount, "failure_count", br.FailureCount)
 	return br, nil
 }
 
+// IsUnregisteredToken reports whether a push failed because the device's
+// FCM token or Web Push subscription no longer exists.
 func IsUnregisteredToken(err error) bool {
+	var delivery *DeliveryError
+	if errors.As(err, &delivery) {
+		return delivery.Class == ErrorClassUnregistered
+	}
 	return messaging.IsRegistrationTokenNotRegistered(err)
 }
@@ -0,0 +1,110 @@
+package notification
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// GroupPayloadType is the payload type of a coalesced notification.
+const GroupPayloadType = "episode_group"
+
+// coalescedTypes are the event types that are grouped when several arrive
+// for the same show at once; status changes and movie releases always go
+// out alone.
+var coalescedTypes = map[string]bool{
+	"new_episode": true, "season_premiere": true, "series_premiere": true,
+	"season_finale": true, "series_finale": true,
+}
+
+func coalescedTypeList() []string {
+	types := make([]string, 0, len(coalescedTypes))
+	for t := range coalescedTypes {
+		types = append(types, t)
+	}
+	return types
+}
+
+type groupKey struct {
+	profileID uuid.UUID
+	showID    string
+}
+
+// Coalesce splits claimed notifications into those sent alone and groups of
+// two or more episode releases of one show for one profile. Order within
+// each result follows the input. Retries, snoozed reminders and replayed
+// dead letters already went out once and always stay single, so the email
+// copy is not resent through a fresh group.
+func Coalesce(notifs []Notification) (singles []Notification, groups [][]Notification) {
+	byKey := make(map[groupKey][]Notification)
+	var order []groupKey
+	for _, n := range notifs {
+		var payload struct {
+			ShowID   string `json:"show_id"`
+			Type     string `json:"type"`
+			Reminder bool   `json:"reminder"`
+			Replayed bool   `json:"replayed"`
+		}
+		_ = json.Unmarshal(n.Payload, &payload)
+		if n.TimelineEventID == nil || payload.ShowID == "" || !coalescedTypes[payload.Type] ||
+			n.RetryCount > 0 || payload.Reminder || payload.Replayed {
+			singles = append(singles, n)
+			continue
+		}
+		key := groupKey{n.ProfileID, payload.ShowID}
+		if _, ok := byKey[key]; !ok {
+			order = append(order, key)
+		}
+		byKey[key] = append(byKey[key], n)
+	}
+	for _, key := range order {
+		if members := byKey[key]; len(members) == 1 {
+			singles = append(singles, members[0])
+		} else {
+			groups = append(groups, members)
+		}
+	}
+	return singles, groups
+}
+
+// episodeRef is the season and episode of a grouped member's event.
+type episodeRef struct {
+	Season  *int
+	Episode *int
+}
+
+// groupDetails describes a group by its show, and by its season and episode
+// range when all the members share a season and run consecutively.
+func groupDetails(d Details, episodes []episodeRef) Details {
+	d.Type = GroupPayloadType
+	d.Count = len(episodes)
+	seasons := make(map[int]bool)
+	var numbers []int
+	for _, ep := range episodes {
+		if ep.Season == nil || ep.Episode == nil {
+			seasons[-1] = true
+			continue
+		}
+		seasons[*ep.Season] = true
+		numbers = append(numbers, *ep.Episode)
+	}
+
+	if len(seasons) == 1 && len(numbers) == d.Count {
+		season := *episodes[0].Season
+		d.Season = &season
+		sort.Ints(numbers)
+		if first, last := numbers[0], numbers[d.Count-1]; last-first == d.Count-1 {
+			d.FirstEpisode, d.LastEpisode = &first, &last
+		}
+	}
+	return d
+}
+
+func isGroup(n Notification) bool {
+	var payload struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal(n.Payload, &payload)
+	return payload.Type == GroupPayloadType
+}
@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestCoalesce(t *testing.T) {
+	profile, other := uuid.New(), uuid.New()
+	event := func() *uuid.UUID { id := uuid.New(); return &id }
+	payload := func(show, kind string) datatypes.JSON {
+		return datatypes.JSON(`{"show_id":"` + show + `","type":"` + kind + `"}`)
+	}
+
+	notifs := []Notification{
+		{ProfileID: profile, TimelineEventID: event(), Payload: payload("a", "new_episode")},
+		{ProfileID: profile, TimelineEventID: event(), Payload: payload("b", "new_episode")},
+		{ProfileID: profile, TimelineEventID: event(), Payload: payload("a", "season_finale")},
+		{ProfileID: other, TimelineEventID: event(), Payload: payload("a", "new_episode")},
+		{ProfileID: profile, TimelineEventID: event(), Payload: payload("a", "status_change")},
+	}
+	singles, groups := Coalesce(notifs)
+	if len(groups) != 1 || len(groups[0]) != 2 {
+		t.Fatalf("groups = %v", groups)
+	}
+	if len(singles) != 3 {
+		t.Fatalf("singles = %d, want 3", len(singles))
+	}
+
+	// Notifications that already went out once are never folded into a
+	// new group, which would resend the email copy and lose their flags.
+	flagged := func(flag string) datatypes.JSON {
+		return datatypes.JSON(`{"show_id":"c","type":"new_episode","` + flag + `":true}`)
+	}
+	notifs = []Notification{
+		{ProfileID: profile, TimelineEventID: event(), Payload: payload("c", "new_episode")},
+		{ProfileID: profile, TimelineEventID: event(), Payload: payload("c", "new_episode"), RetryCount: 2},
+		{ProfileID: profile, TimelineEventID: event(), Payload: flagged("reminder")},
+		{ProfileID: profile, TimelineEventID: event(), Payload: flagged("replayed")},
+	}
+	singles, groups = Coalesce(notifs)
+	if len(groups) != 0 || len(singles) != 4 {
+		t.Fatalf("retries and flagged notifications were grouped: %d singles, groups %v", len(singles), groups)
+	}
+}
+
+func TestGroupContent(t *testing.T) {
+	ep := func(season, episode int) episodeRef { return episodeRef{Season: &season, Episode: &episode} }
+
+	show := Details{ShowTitle: "Severance"}
+
+	title, body := DefaultContent(groupDetails(show, []episodeRef{ep(3, 2), ep(3, 1), ep(3, 3)}))
+	if title != "Season 3 of Severance: 3 episodes now available" || body != "Episodes 1–3 are ready to watch." {
+		t.Fatalf("got %q / %q", title, body)
+	}
+
+	title, body = DefaultContent(groupDetails(show, []episodeRef{ep(2, 10), ep(3, 1)}))
+	if title != "Severance: 2 episodes now available" || body != "2 new episodes are ready to watch." {
+		t.Fatalf("got %q / %q", title, body)
+	}
+}
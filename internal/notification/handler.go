@@ -1,6 +1,8 @@
 package notification
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,15 +14,18 @@ import (
 )
 
 type Handler struct {
-	svc *Service
+	svc     *Service
+	webPush *WebPushClient
 }
 
-func NewHandler(svc *Service) *Handler {
-	return &Handler{svc: svc}
+// NewHandler takes the Web Push client to publish its VAPID key; it may be
+// nil when Web Push isn't configured.
+func NewHandler(svc *Service, webPush *WebPushClient) *Handler {
+	return &Handler{svc: svc, webPush: webPush}
 }
 
 func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
@@ -63,7 +68,7 @@ func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	result, err := h.svc.GetNotifications(r.Context(), userID, status, notifType, from, to, page, limit)
+	result, err := h.svc.GetNotifications(r.Context(), profileID, status, notifType, from, to, page, limit)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -74,7 +79,7 @@ func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
@@ -88,7 +93,7 @@ func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.MarkRead(r.Context(), userID, id); err != nil {
+	if err := h.svc.MarkRead(r.Context(), profileID, id); err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -97,13 +102,13 @@ func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
 
-	if err := h.svc.MarkAllRead(r.Context(), userID); err != nil {
+	if err := h.svc.MarkAllRead(r.Context(), profileID); err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -112,13 +117,13 @@ func (h *Handler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
 
-	count, err := h.svc.GetUnreadCount(r.Context(), userID)
+	count, err := h.svc.GetUnreadCount(r.Context(), profileID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -126,3 +131,35 @@ func (h *Handler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
 
 	httputil.JSONWithCache(w, r, http.StatusOK, map[string]int64{"count": count}, 30, 0)
 }
+
+// PerformAction runs a push action button. It is authenticated by the
+// action token alone, so it works from a notification without a session.
+func (h *Handler) PerformAction(w http.ResponseWriter, r *http.Request) {
+	var req ActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		httputil.Error(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	result, err := h.svc.PerformAction(r.Context(), req.Token)
+	if errors.Is(err, ErrInvalidAction) {
+		httputil.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, result)
+}
+
+// WebPushKey returns the VAPID public key the browser app subscribes with
+// before registering a webpush device.
+func (h *Handler) WebPushKey(w http.ResponseWriter, r *http.Request) {
+	if h.webPush == nil {
+		httputil.Error(w, http.StatusNotFound, "web push is not configured")
+		return
+	}
+	httputil.JSONWithCache(w, r, http.StatusOK, map[string]string{"public_key": h.webPush.PublicKey()}, 3600, 0)
+}
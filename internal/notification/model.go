@@ -10,15 +10,33 @@ import (
 type Notification struct {
 	ID              uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
 	UserID          uuid.UUID      `gorm:"type:uuid;not null;index"`
+	ProfileID       uuid.UUID      `gorm:"type:uuid;not null;index"`
 	TimelineEventID *uuid.UUID     `gorm:"type:uuid"`
+	GroupID         *uuid.UUID     `gorm:"type:uuid"`
 	Title           string         `gorm:"type:text;not null"`
 	Body            string         `gorm:"type:text;not null"`
 	Payload         datatypes.JSON `gorm:"type:jsonb;default:'{}'"`
-	Status          string         `gorm:"type:text;not null;default:'pending'"` // pending, sent, failed, read
+	Status          string         `gorm:"type:text;not null;default:'pending'"` // pending, sent, failed, read, discarded, grouped
 	ScheduledFor    time.Time      `gorm:"not null;index"`
 	SentAt          *time.Time
 	ReadAt          *time.Time
 	RetryCount      int `gorm:"not null;default:0"`
 	LastError       *string
+	ErrorClass      *string
+	FailedAt        *time.Time
 	CreatedAt       time.Time
 }
+
+// DeadLetterFilter selects failed notifications. Bulk actions require at
+// least one of IDs, ErrorClass or UserID.
+type DeadLetterFilter struct {
+	IDs        []uuid.UUID
+	ErrorClass string
+	UserID     *uuid.UUID
+	Limit      int
+	Offset     int
+}
+
+func (f DeadLetterFilter) Empty() bool {
+	return len(f.IDs) == 0 && f.ErrorClass == "" && f.UserID == nil
+}
@@ -1,10 +1,13 @@
 package notification
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -17,24 +20,13 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Create(notif *Notification) error {
-	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(notif).Error
+func (r *Repository) Create(ctx context.Context, notif *Notification) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(notif).Error
 }
 
-func (r *Repository) GetPendingDue(limit int) ([]Notification, error) {
-	var notifs []Notification
-	err := r.db.Where("status = ? AND scheduled_for <= ?", "pending", time.Now()).
-		Limit(limit).
-		Find(&notifs).Error
-	if err != nil {
-		return nil, err
-	}
-	return notifs, nil
-}
-
-func (r *Repository) MarkSent(id uuid.UUID) error {
+func (r *Repository) MarkSent(ctx context.Context, id uuid.UUID) error {
 	now := time.Now()
-	return r.db.Model(&Notification{}).
+	return r.db.WithContext(ctx).Model(&Notification{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
 			"status":  "sent",
@@ -42,85 +34,302 @@ func (r *Repository) MarkSent(id uuid.UUID) error {
 		}).Error
 }
 
-func (r *Repository) MarkFailed(id uuid.UUID) error {
-	return r.db.Model(&Notification{}).
+// SetContent replaces the stored in-app text with the recipient's rendering.
+func (r *Repository) SetContent(ctx context.Context, id uuid.UUID, title, body string) error {
+	return r.db.WithContext(ctx).Model(&Notification{}).
 		Where("id = ?", id).
-		Update("status", "failed").Error
+		Updates(map[string]interface{}{"title": title, "body": body}).Error
 }
 
-func (r *Repository) RetryOrFail(id uuid.UUID, dispatchErr error, maxAttempts int) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+// ShiftPending moves the unsent notifications for a timeline event by
+// delta, keeping each one's lead time when the event is rescheduled.
+func (r *Repository) ShiftPending(ctx context.Context, eventID uuid.UUID, delta time.Duration) error {
+	return r.db.WithContext(ctx).Model(&Notification{}).
+		Where("timeline_event_id = ? AND status = ?", eventID, "pending").
+		Update("scheduled_for", gorm.Expr("scheduled_for + make_interval(secs => ?)", delta.Seconds())).Error
+}
+
+// RetryOrFail records a failed delivery and either reschedules it under
+// its error class's retry policy or moves it to the dead-letter queue,
+// reporting which.
+func (r *Repository) RetryOrFail(ctx context.Context, id uuid.UUID, dispatchErr error) (bool, error) {
+	class := Classify(dispatchErr)
+	message := "notification delivery failed"
+	if dispatchErr != nil {
+		message = dispatchErr.Error()
+	}
+
+	dead := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var notif Notification
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&notif, "id = ?", id).Error; err != nil {
 			return err
 		}
 		notif.RetryCount++
-		message := "notification delivery failed"
-		if dispatchErr != nil {
-			message = dispatchErr.Error()
+		updates := map[string]interface{}{
+			"retry_count": notif.RetryCount, "last_error": message, "error_class": class,
 		}
-		notif.LastError = &message
-		if notif.RetryCount >= maxAttempts {
-			notif.Status = "failed"
+		if delay, ok := PolicyFor(class).Next(notif.RetryCount); ok {
+			updates["status"] = "pending"
+			updates["scheduled_for"] = time.Now().Add(delay)
 		} else {
-			notif.Status = "pending"
-			notif.ScheduledFor = time.Now().Add(time.Duration(1<<notif.RetryCount) * time.Minute)
+			dead = true
+			updates["status"] = "failed"
+			updates["failed_at"] = time.Now()
 		}
-		return tx.Model(&Notification{}).Where("id = ?", id).Updates(map[string]interface{}{
-			"status": notif.Status, "retry_count": notif.RetryCount,
-			"last_error": message, "scheduled_for": notif.ScheduledFor,
-		}).Error
+		return tx.Model(&Notification{}).Where("id = ?", id).Updates(updates).Error
 	})
+	return dead, err
+}
+
+func (r *Repository) deadLetters(ctx context.Context, f DeadLetterFilter) *gorm.DB {
+	db := r.db.WithContext(ctx).Model(&Notification{}).Where("status = ?", "failed")
+	if len(f.IDs) > 0 {
+		db = db.Where("id IN ?", f.IDs)
+	}
+	if f.ErrorClass != "" {
+		db = db.Where("error_class = ?", f.ErrorClass)
+	}
+	if f.UserID != nil {
+		db = db.Where("user_id = ?", *f.UserID)
+	}
+	return db
+}
+
+func (r *Repository) ListDeadLetters(ctx context.Context, f DeadLetterFilter) ([]Notification, int64, error) {
+	var total int64
+	if err := r.deadLetters(ctx, f).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var notifs []Notification
+	err := r.deadLetters(ctx, f).Order("failed_at DESC, id").
+		Limit(f.Limit).Offset(f.Offset).Find(&notifs).Error
+	return notifs, total, err
+}
+
+// CountDeadLetters returns the size of the dead-letter queue by error class.
+func (r *Repository) CountDeadLetters(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		ErrorClass string
+		Count      int64
+	}
+	err := r.deadLetters(ctx, DeadLetterFilter{}).
+		Select("COALESCE(error_class, ?) AS error_class, COUNT(*) AS count", ErrorClassUnknown).
+		Group("1").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ErrorClass] += row.Count
+	}
+	return counts, nil
+}
+
+// ReplayDeadLetters returns the selected dead letters to the queue with a
+// fresh retry budget. last_error is kept for reference, and the payload is
+// flagged so only push is retried.
+func (r *Repository) ReplayDeadLetters(ctx context.Context, f DeadLetterFilter) (int64, error) {
+	result := r.deadLetters(ctx, f).Updates(map[string]interface{}{
+		"status": "pending", "retry_count": 0, "scheduled_for": time.Now(),
+		"error_class": nil, "failed_at": nil,
+		"payload": gorm.Expr(`payload || '{"replayed": true}'::jsonb`),
+	})
+	return result.RowsAffected, result.Error
+}
+
+func (r *Repository) DiscardDeadLetters(ctx context.Context, f DeadLetterFilter) (int64, error) {
+	result := r.deadLetters(ctx, f).Update("status", "discarded")
+	return result.RowsAffected, result.Error
+}
+
+// CountPendingDue reports how many pending notifications are ready to send.
+func (r *Repository) CountPendingDue(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Notification{}).
+		Where("status = ? AND scheduled_for <= ?", "pending", time.Now()).
+		Count(&count).Error
+	return count, err
 }
 
-func (r *Repository) ClaimPendingDue(limit int) ([]Notification, error) {
+// claimLease is how long a claimed notification is hidden from other
+// dispatchers before it is considered abandoned and claimed again.
+const claimLease = 5 * time.Minute
+
+// ClaimPendingDue claims up to limit due notifications, plus any pending
+// ones for the same profile and show scheduled within window of now, so a
+// batch of releases can be coalesced into one. Retries, reminders and
+// replayed dead letters are never coalesced, so they pull nothing forward
+// and are not pulled forward themselves.
+func (r *Repository) ClaimPendingDue(ctx context.Context, limit int, window time.Duration) ([]Notification, error) {
 	if limit <= 0 {
 		return nil, fmt.Errorf("limit must be positive")
 	}
 	var notifications []Notification
-	err := r.db.Raw(`
+	err := r.db.WithContext(ctx).Raw(`
 		WITH due AS (
-			SELECT id FROM notifications
+			SELECT id, profile_id, payload->>'show_id' AS show_id, payload->>'type' AS type,
+				retry_count > 0 OR COALESCE((payload->>'reminder')::boolean, FALSE)
+					OR COALESCE((payload->>'replayed')::boolean, FALSE) AS resent
+			FROM notifications
 			WHERE status = 'pending' AND scheduled_for <= NOW()
 			ORDER BY scheduled_for ASC
 			FOR UPDATE SKIP LOCKED
 			LIMIT ?
+		), early AS (
+			SELECT n.id FROM notifications n
+			JOIN due ON due.profile_id = n.profile_id AND due.show_id = n.payload->>'show_id'
+			WHERE n.status = 'pending' AND n.scheduled_for > NOW()
+			  AND n.scheduled_for <= NOW() + make_interval(secs => ?)
+			  AND due.type IN ? AND n.payload->>'type' IN ?
+			  AND NOT due.resent AND n.retry_count = 0
+			  AND NOT COALESCE((n.payload->>'reminder')::boolean, FALSE)
+			  AND NOT COALESCE((n.payload->>'replayed')::boolean, FALSE)
+			FOR UPDATE OF n SKIP LOCKED
 		)
 		UPDATE notifications AS n
-		SET scheduled_for = NOW() + INTERVAL '5 minutes'
-		FROM due
-		WHERE n.id = due.id
+		SET scheduled_for = NOW() + make_interval(secs => ?)
+		WHERE n.id IN (SELECT id FROM due UNION SELECT id FROM early)
 		RETURNING n.*
-	`, limit).Scan(&notifications).Error
+	`, limit, window.Seconds(), coalescedTypeList(), coalescedTypeList(), claimLease.Seconds()).Scan(&notifications).Error
 	return notifications, err
 }
 
-func (r *Repository) MarkRead(id uuid.UUID, userID uuid.UUID) error {
+// CreateGroup replaces claimed members with one notification for them all,
+// claimed by the caller. Members keep their timeline events and link to the
+// group through group_id.
+func (r *Repository) CreateGroup(ctx context.Context, members []Notification) (*Notification, error) {
+	first := members[0]
+	showID := ParseDetails(&first).ShowID
+
+	ids := make([]uuid.UUID, len(members))
+	eventIDs := make([]uuid.UUID, 0, len(members))
+	for i, n := range members {
+		ids[i] = n.ID
+		if n.TimelineEventID != nil {
+			eventIDs = append(eventIDs, *n.TimelineEventID)
+		}
+	}
+
+	var group *Notification
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var show struct {
+			Title     string
+			PosterURL *string
+		}
+		if err := tx.Table("shows").Select("title, poster_url").Where("id = ?", showID).Scan(&show).Error; err != nil {
+			return err
+		}
+		var episodes []episodeRef
+		if err := tx.Table("timeline_events").Select("season_number AS season, episode_number AS episode").
+			Where("id IN ?", eventIDs).Order("season_number, episode_number").Scan(&episodes).Error; err != nil {
+			return err
+		}
+		if len(episodes) < len(members) {
+			episodes = append(episodes, make([]episodeRef, len(members)-len(episodes))...)
+		}
+		details := Details{ShowID: showID, ShowTitle: show.Title, EventIDs: eventIDs}
+		if show.PosterURL != nil {
+			details.PosterPath = *show.PosterURL
+		}
+		details = groupDetails(details, episodes)
+		title, body := DefaultContent(details)
+
+		encoded, _ := json.Marshal(details)
+		group = &Notification{
+			UserID: first.UserID, ProfileID: first.ProfileID, Title: title, Body: body,
+			Payload: datatypes.JSON(encoded), Status: "pending", ScheduledFor: time.Now().Add(claimLease),
+		}
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Notification{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status": "grouped", "group_id": group.ID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// GetGroupMembers returns the members of the given groups in release order.
+func (r *Repository) GetGroupMembers(ctx context.Context, groupIDs []uuid.UUID) ([]Notification, error) {
+	var members []Notification
+	if len(groupIDs) == 0 {
+		return members, nil
+	}
+	err := r.db.WithContext(ctx).Where("group_id IN ?", groupIDs).
+		Order("scheduled_for ASC, title ASC").Find(&members).Error
+	return members, err
+}
+
+// FindDelivered returns a sent or read notification belonging to the
+// profile.
+func (r *Repository) FindDelivered(ctx context.Context, id, userID, profileID uuid.UUID) (*Notification, error) {
+	var n Notification
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ? AND profile_id = ? AND status IN ?", id, userID, profileID, []string{"sent", "read"}).
+		First(&n).Error
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Snooze queues a delivered notification to be sent again at until,
+// flagged as a reminder. It reports false if it was no longer delivered.
+func (r *Repository) Snooze(ctx context.Context, id uuid.UUID, until time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&Notification{}).
+		Where("id = ? AND status IN ?", id, []string{"sent", "read"}).
+		Updates(map[string]interface{}{
+			"status":        "pending",
+			"scheduled_for": until,
+			"sent_at":       nil,
+			"read_at":       nil,
+			"retry_count":   0,
+			"last_error":    nil,
+			"payload":       gorm.Expr(`payload || '{"reminder": true}'::jsonb`),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// DiscardPendingForShow drops a profile's unsent notifications of one type
+// for a show, so muting it also silences what was already queued.
+func (r *Repository) DiscardPendingForShow(ctx context.Context, profileID, showID uuid.UUID, eventType string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&Notification{}).
+		Where("profile_id = ? AND status = ? AND payload->>'show_id' = ? AND payload->>'type' = ?",
+			profileID, "pending", showID.String(), eventType).
+		Update("status", "discarded")
+	return res.RowsAffected, res.Error
+}
+
+func (r *Repository) MarkRead(ctx context.Context, id uuid.UUID, profileID uuid.UUID) error {
 	now := time.Now()
-	return r.db.Model(&Notification{}).
-		Where("id = ? AND user_id = ? AND status = ?", id, userID, "sent").
+	return r.db.WithContext(ctx).Model(&Notification{}).
+		Where("id = ? AND profile_id = ? AND status = ?", id, profileID, "sent").
 		Updates(map[string]interface{}{
 			"status":  "read",
 			"read_at": now,
 		}).Error
 }
 
-func (r *Repository) MarkAllRead(userID uuid.UUID) error {
+func (r *Repository) MarkAllRead(ctx context.Context, profileID uuid.UUID) error {
 	now := time.Now()
 	// Update all unread notifications to 'read'
-	return r.db.Model(&Notification{}).
-		Where("user_id = ? AND status = ?", userID, "sent").
+	return r.db.WithContext(ctx).Model(&Notification{}).
+		Where("profile_id = ? AND status = ?", profileID, "sent").
 		Updates(map[string]interface{}{
 			"status":  "read",
 			"read_at": now,
 		}).Error
 }
 
-func (r *Repository) GetByUser(userID uuid.UUID, status string, notifType string, from, to *time.Time, page, perPage int) ([]Notification, int64, error) {
+func (r *Repository) GetByProfile(ctx context.Context, profileID uuid.UUID, status string, notifType string, from, to *time.Time, page, perPage int) ([]Notification, int64, error) {
 	var notifs []Notification
 	var total int64
 
-	db := r.db.Model(&Notification{}).Where("user_id = ?", userID)
+	db := r.db.WithContext(ctx).Model(&Notification{}).Where("profile_id = ? AND status NOT IN ?", profileID, []string{"discarded", "grouped"})
 
 	if status != "" {
 		db = db.Where("status = ?", status)
@@ -155,18 +364,20 @@ func (r *Repository) GetByUser(userID uuid.UUID, status string, notifType string
 	return notifs, total, nil
 }
 
-func (r *Repository) GetUnreadCount(userID uuid.UUID) (int64, error) {
+func (r *Repository) GetUnreadCount(ctx context.Context, profileID uuid.UUID) (int64, error) {
 	var count int64
 	// Count all non-read notifications? Or just 'sent'?
 	// Usually badge count assumes 'sent' notifications that haven't been 'read'.
-	err := r.db.Model(&Notification{}).
-		Where("user_id = ? AND status = ?", userID, "sent").
+	err := r.db.WithContext(ctx).Model(&Notification{}).
+		Where("profile_id = ? AND status = ?", profileID, "sent").
 		Count(&count).Error
 	return count, err
 }
 
-func (r *Repository) DeleteOldRead(olderThan time.Time) (int64, error) {
-	result := r.db.Where("status = ? AND created_at < ?", "read", olderThan).
+// DeleteOldRead removes read and discarded notifications created before
+// olderThan.
+func (r *Repository) DeleteOldRead(ctx context.Context, olderThan time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("status IN ? AND created_at < ?", []string{"read", "discarded"}, olderThan).
 		Delete(&Notification{})
 	return result.RowsAffected, result.Error
 }
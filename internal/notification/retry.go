@@ -0,0 +1,112 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// Error classes recorded on failed deliveries. Permanent classes are
+// dead-lettered on the first failure; transient ones are retried under
+// their class's policy.
+const (
+	ErrorClassUnregistered = "unregistered"
+	ErrorClassRejected     = "rejected"
+	ErrorClassQuota        = "quota"
+	ErrorClassUnavailable  = "unavailable"
+	ErrorClassInternal     = "internal"
+	ErrorClassUnknown      = "unknown"
+)
+
+// RetryPolicy bounds the attempts for one error class. Delays double from
+// BaseDelay up to MaxDelay.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var retryPolicies = map[string]RetryPolicy{
+	// The token or payload will never be accepted, so retrying only delays
+	// the dead letter.
+	ErrorClassUnregistered: {MaxAttempts: 1},
+	ErrorClassRejected:     {MaxAttempts: 1},
+	// FCM quotas refill over minutes, so back off further and for longer.
+	ErrorClassQuota:       {MaxAttempts: 8, BaseDelay: 5 * time.Minute, MaxDelay: 2 * time.Hour},
+	ErrorClassUnavailable: {MaxAttempts: 6, BaseDelay: time.Minute, MaxDelay: 30 * time.Minute},
+	// Our own failures, such as a device lookup, usually clear quickly.
+	ErrorClassInternal: {MaxAttempts: 5, BaseDelay: 30 * time.Second, MaxDelay: 10 * time.Minute},
+	ErrorClassUnknown:  {MaxAttempts: 3, BaseDelay: 2 * time.Minute, MaxDelay: 30 * time.Minute},
+}
+
+// ErrorClasses lists every class, for filters and metrics.
+var ErrorClasses = []string{
+	ErrorClassUnregistered, ErrorClassRejected, ErrorClassQuota,
+	ErrorClassUnavailable, ErrorClassInternal, ErrorClassUnknown,
+}
+
+func ValidErrorClass(class string) bool {
+	_, ok := retryPolicies[class]
+	return ok
+}
+
+// PolicyFor returns the retry policy for an error class, falling back to
+// the unknown class's.
+func PolicyFor(class string) RetryPolicy {
+	if p, ok := retryPolicies[class]; ok {
+		return p
+	}
+	return retryPolicies[ErrorClassUnknown]
+}
+
+// Next returns the delay before the next attempt after attempts failures,
+// or ok=false when the notification should be dead-lettered.
+func (p RetryPolicy) Next(attempts int) (delay time.Duration, ok bool) {
+	if attempts >= p.MaxAttempts {
+		return 0, false
+	}
+	delay = p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay, true
+}
+
+// DeliveryError tags a dispatch failure with its class, for failures that
+// don't come from FCM.
+type DeliveryError struct {
+	Class string
+	Err   error
+}
+
+func (e *DeliveryError) Error() string { return e.Err.Error() }
+func (e *DeliveryError) Unwrap() error { return e.Err }
+
+// Classify maps a delivery error to its error class.
+func Classify(err error) string {
+	var delivery *DeliveryError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ErrorClassUnknown
+	case errors.As(err, &delivery):
+		return delivery.Class
+	case messaging.IsUnregistered(err):
+		return ErrorClassUnregistered
+	case messaging.IsInvalidArgument(err), messaging.IsSenderIDMismatch(err), messaging.IsThirdPartyAuthError(err):
+		return ErrorClassRejected
+	case messaging.IsQuotaExceeded(err):
+		return ErrorClassQuota
+	case messaging.IsUnavailable(err), messaging.IsInternal(err),
+		errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return ErrorClassUnavailable
+	default:
+		return ErrorClassUnknown
+	}
+}
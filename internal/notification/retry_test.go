@@ -0,0 +1,40 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyNext(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 4, BaseDelay: time.Minute, MaxDelay: 3 * time.Minute}
+	want := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute}
+	for i, w := range want {
+		got, ok := p.Next(i + 1)
+		if !ok || got != w {
+			t.Fatalf("Next(%d) = %v, %v; want %v", i+1, got, ok, w)
+		}
+	}
+	if _, ok := p.Next(4); ok {
+		t.Fatal("expected a dead letter after the last attempt")
+	}
+
+	if _, ok := PolicyFor(ErrorClassUnregistered).Next(1); ok {
+		t.Fatal("unregistered tokens should not be retried")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cases := map[error]string{
+		errors.New("boom"): ErrorClassUnknown,
+		fmt.Errorf("send: %w", context.DeadlineExceeded):                ErrorClassUnavailable,
+		&DeliveryError{Class: ErrorClassInternal, Err: errors.New("x")}: ErrorClassInternal,
+	}
+	for err, want := range cases {
+		if got := Classify(err); got != want {
+			t.Errorf("Classify(%v) = %q, want %q", err, got, want)
+		}
+	}
+}
@@ -2,23 +2,36 @@ package notification
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
+	"github.com/tashifkhan/bingebeacon/internal/alert"
+	"github.com/tashifkhan/bingebeacon/internal/history"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
+	"gorm.io/gorm"
 )
 
 type Service struct {
-	repo  *Repository
-	redis *redis.Client
+	repo       *Repository
+	cache      cache.Store
+	historySvc *history.Service
+	alertRepo  *alert.Repository
+	signer     *ActionSigner
+	snooze     time.Duration
 }
 
-func NewService(repo *Repository, rdb *redis.Client) *Service {
+func NewService(repo *Repository, store cache.Store, historySvc *history.Service, alertRepo *alert.Repository, signer *ActionSigner, snooze time.Duration) *Service {
 	return &Service{
-		repo:  repo,
-		redis: rdb,
+		repo:       repo,
+		cache:      store,
+		historySvc: historySvc,
+		alertRepo:  alertRepo,
+		signer:     signer,
+		snooze:     snooze,
 	}
 }
 
@@ -36,9 +49,34 @@ type NotificationResponse struct {
 	Status    string    `json:"status"`
 	CreatedAt string    `json:"created_at"`
 	ReadAt    *string   `json:"read_at,omitempty"`
+	// ImageURL and Details let the app show artwork and open the show or
+	// episode the notification is about.
+	ImageURL string   `json:"image_url,omitempty"`
+	Details  *Details `json:"details,omitempty"`
+	// Items lists the releases a grouped notification covers.
+	Items []NotificationItem `json:"items,omitempty"`
 }
 
-func (s *Service) GetNotifications(ctx context.Context, userID uuid.UUID, status string, notifType string, from, to *time.Time, page, limit int) (*PaginatedNotifications, error) {
+type ActionRequest struct {
+	Token string `json:"token"`
+}
+
+type ActionResult struct {
+	Action         string     `json:"action"`
+	NotificationID uuid.UUID  `json:"notification_id"`
+	RemindAt       *time.Time `json:"remind_at,omitempty"`
+}
+
+type NotificationItem struct {
+	ID              uuid.UUID  `json:"id"`
+	Title           string     `json:"title"`
+	TimelineEventID *uuid.UUID `json:"timeline_event_id,omitempty"`
+}
+
+func (s *Service) GetNotifications(ctx context.Context, profileID uuid.UUID, status string, notifType string, from, to *time.Time, page, limit int) (*PaginatedNotifications, error) {
+	ctx, span := tracing.Start(ctx, "notification.GetNotifications")
+	defer span.End()
+
 	if limit <= 0 {
 		limit = 20
 	}
@@ -46,11 +84,26 @@ func (s *Service) GetNotifications(ctx context.Context, userID uuid.UUID, status
 		limit = 50
 	}
 
-	notifs, total, err := s.repo.GetByUser(userID, status, notifType, from, to, page, limit)
+	notifs, total, err := s.repo.GetByProfile(ctx, profileID, status, notifType, from, to, page, limit)
 	if err != nil {
 		return nil, err
 	}
 
+	var groupIDs []uuid.UUID
+	for _, n := range notifs {
+		if isGroup(n) {
+			groupIDs = append(groupIDs, n.ID)
+		}
+	}
+	members, err := s.repo.GetGroupMembers(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[uuid.UUID][]NotificationItem)
+	for _, m := range members {
+		items[*m.GroupID] = append(items[*m.GroupID], NotificationItem{ID: m.ID, Title: m.Title, TimelineEventID: m.TimelineEventID})
+	}
+
 	resp := make([]NotificationResponse, len(notifs))
 	for i, n := range notifs {
 		var readAt *string
@@ -58,6 +111,11 @@ func (s *Service) GetNotifications(ctx context.Context, userID uuid.UUID, status
 			t := n.ReadAt.Format("2006-01-02T15:04:05Z")
 			readAt = &t
 		}
+		d := ParseDetails(&n)
+		var details *Details
+		if d.Type != "" {
+			details = &d
+		}
 
 		resp[i] = NotificationResponse{
 			ID:        n.ID,
@@ -66,6 +124,9 @@ func (s *Service) GetNotifications(ctx context.Context, userID uuid.UUID, status
 			Status:    n.Status,
 			CreatedAt: n.CreatedAt.Format("2006-01-02T15:04:05Z"),
 			ReadAt:    readAt,
+			ImageURL:  imageURL(d, ChannelInApp),
+			Details:   details,
+			Items:     items[n.ID],
 		}
 	}
 
@@ -77,27 +138,154 @@ func (s *Service) GetNotifications(ctx context.Context, userID uuid.UUID, status
 	}, nil
 }
 
-func (s *Service) MarkRead(ctx context.Context, userID, notifID uuid.UUID) error {
-	if err := s.repo.MarkRead(notifID, userID); err != nil {
+func (s *Service) MarkRead(ctx context.Context, profileID, notifID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "notification.MarkRead")
+	defer span.End()
+
+	if err := s.repo.MarkRead(ctx, notifID, profileID); err != nil {
 		return err
 	}
 	// Invalidate unread count
-	s.redis.Del(ctx, fmt.Sprintf("notif:unread:%s", userID))
+	s.cache.Delete(ctx, fmt.Sprintf("notif:unread:%s", profileID))
 	return nil
 }
 
-func (s *Service) MarkAllRead(ctx context.Context, userID uuid.UUID) error {
-	if err := s.repo.MarkAllRead(userID); err != nil {
+func (s *Service) MarkAllRead(ctx context.Context, profileID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "notification.MarkAllRead")
+	defer span.End()
+
+	if err := s.repo.MarkAllRead(ctx, profileID); err != nil {
 		return err
 	}
 	// Invalidate unread count
-	s.redis.Del(ctx, fmt.Sprintf("notif:unread:%s", userID))
+	s.cache.Delete(ctx, fmt.Sprintf("notif:unread:%s", profileID))
 	return nil
 }
 
-func (s *Service) GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64, error) {
-	key := fmt.Sprintf("notif:unread:%s", userID)
-	return cache.GetOrSet(ctx, s.redis, key, 30*time.Second, func() (int64, error) {
-		return s.repo.GetUnreadCount(userID)
+func (s *Service) GetUnreadCount(ctx context.Context, profileID uuid.UUID) (int64, error) {
+	ctx, span := tracing.Start(ctx, "notification.GetUnreadCount")
+	defer span.End()
+
+	key := fmt.Sprintf("notif:unread:%s", profileID)
+	return cache.GetOrSet(ctx, s.cache, key, 30*time.Second, func(ctx context.Context) (int64, error) {
+		return s.repo.GetUnreadCount(ctx, profileID)
 	})
 }
+
+// PerformAction carries out a push action. The token stands in for a
+// session, so it is checked against the notification it was issued for
+// and can be redeemed only once.
+func (s *Service) PerformAction(ctx context.Context, token string) (*ActionResult, error) {
+	ctx, span := tracing.Start(ctx, "notification.PerformAction")
+	defer span.End()
+
+	claims, err := s.signer.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	uses, _, err := s.cache.Incr(ctx, "notif:action:"+claims.JTI, time.Until(claims.ExpiresAt))
+	if err != nil {
+		return nil, err
+	}
+	if uses > 1 {
+		return nil, ErrInvalidAction
+	}
+
+	n, err := s.repo.FindDelivered(ctx, claims.NotificationID, claims.UserID, claims.ProfileID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAction
+	}
+	if err != nil {
+		return nil, err
+	}
+	d := ParseDetails(n)
+	if !offers(d, claims.Action) {
+		return nil, ErrInvalidAction
+	}
+
+	result := &ActionResult{Action: claims.Action, NotificationID: n.ID}
+	switch claims.Action {
+	case ActionMarkWatched:
+		err = s.markWatched(ctx, n, d)
+	case ActionRemindLater:
+		remindAt := time.Now().Add(s.snooze)
+		var snoozed bool
+		if snoozed, err = s.repo.Snooze(ctx, n.ID, remindAt); err == nil && !snoozed {
+			err = ErrInvalidAction
+		}
+		result.RemindAt = &remindAt
+	case ActionMuteShow:
+		err = s.muteShow(ctx, n, d)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if claims.Action != ActionRemindLater {
+		err = s.repo.MarkRead(ctx, n.ID, n.ProfileID)
+	}
+	s.cache.Delete(ctx, fmt.Sprintf("notif:unread:%s", n.ProfileID))
+	return result, err
+}
+
+func offers(d Details, action string) bool {
+	for _, a := range actionsFor(d) {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// markWatched records the notification's episode, or every episode of a
+// group, in the profile's history.
+func (s *Service) markWatched(ctx context.Context, n *Notification, d Details) error {
+	if d.Type != GroupPayloadType {
+		return s.historySvc.Create(ctx, n.UserID, n.ProfileID, history.CreateEntryRequest{
+			ShowID: d.ShowID, SeasonNumber: *d.Season, EpisodeNumber: *d.Episode,
+		})
+	}
+
+	members, err := s.repo.GetGroupMembers(ctx, []uuid.UUID{n.ID})
+	if err != nil {
+		return err
+	}
+	bySeason := make(map[int][]int)
+	for i := range members {
+		md := ParseDetails(&members[i])
+		if md.Season != nil && md.Episode != nil {
+			bySeason[*md.Season] = append(bySeason[*md.Season], *md.Episode)
+		}
+	}
+	seasons := make([]int, 0, len(bySeason))
+	for season := range bySeason {
+		seasons = append(seasons, season)
+	}
+	sort.Ints(seasons)
+	for _, season := range seasons {
+		req := history.BatchCreateRequest{ShowID: d.ShowID, SeasonNumber: season, EpisodeNumbers: bySeason[season]}
+		if err := s.historySvc.CreateBatch(ctx, n.UserID, n.ProfileID, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// muteShow turns off new-episode alerts for the show and drops the ones
+// already queued. Premieres still follow the season setting.
+func (s *Service) muteShow(ctx context.Context, n *Notification, d Details) error {
+	track, err := s.alertRepo.FindByProfileAndShow(ctx, n.ProfileID, d.ShowID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidAction
+	}
+	if err != nil {
+		return err
+	}
+	if track.NotifyNewEpisode {
+		track.NotifyNewEpisode = false
+		if err := s.alertRepo.Update(ctx, track); err != nil {
+			return err
+		}
+	}
+	_, err = s.repo.DiscardPendingForShow(ctx, n.ProfileID, d.ShowID, "new_episode")
+	return err
+}
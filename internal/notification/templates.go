@@ -0,0 +1,134 @@
+package notification
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// messages is one language's notification text. Templates see Details
+// plus When, the air time formatted with dateLayout or timeLayout. actions
+// labels the push buttons.
+type messages struct {
+	dateLayout  string
+	timeLayout  string
+	emailLink   string
+	emailFooter string
+	actions     map[string]string
+	templates   map[string]messageTemplate
+}
+
+type messageTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"n": func(v *int) int {
+		if v == nil {
+			return 0
+		}
+		return *v
+	},
+	"code": func(season, episode *int) string {
+		if season == nil || episode == nil {
+			return ""
+		}
+		return fmt.Sprintf("S%02dE%02d", *season, *episode)
+	},
+}
+
+func compile(texts map[string][2]string) map[string]messageTemplate {
+	compiled := make(map[string]messageTemplate, len(texts))
+	for eventType, text := range texts {
+		compiled[eventType] = messageTemplate{
+			title: template.Must(template.New(eventType + ".title").Funcs(templateFuncs).Parse(text[0])),
+			body:  template.Must(template.New(eventType + ".body").Funcs(templateFuncs).Parse(text[1])),
+		}
+	}
+	return compiled
+}
+
+// catalog holds every supported language; keep it in step with
+// user.Languages.
+var catalog = map[string]messages{
+	"en": {
+		dateLayout:  "Mon, Jan 2",
+		timeLayout:  "Mon, Jan 2 at 3:04 PM MST",
+		emailLink:   "Open in BingeBeacon: %s",
+		emailFooter: "You're receiving this because you track this title on BingeBeacon. Turn off email alerts in your account settings.",
+		actions:     map[string]string{ActionMarkWatched: "Mark watched", ActionRemindLater: "Remind me later", ActionMuteShow: "Mute show"},
+		templates: compile(map[string][2]string{
+			"new_episode":     {`{{.ShowTitle}} {{code .Season .Episode}}`, `{{if .EpisodeTitle}}“{{.EpisodeTitle}}” airs{{else}}A new episode airs{{end}} on {{.When}}.`},
+			"season_premiere": {`{{.ShowTitle}} season {{n .Season}} premieres`, `Season {{n .Season}} starts on {{.When}}{{with .EpisodeTitle}} with “{{.}}”{{end}}.`},
+			"series_premiere": {`{{.ShowTitle}} premieres`, `The series starts on {{.When}}{{with .EpisodeTitle}} with “{{.}}”{{end}}.`},
+			"season_finale":   {`{{.ShowTitle}} season {{n .Season}} finale`, `The season finale{{with .EpisodeTitle}}, “{{.}}”,{{end}} airs on {{.When}}.`},
+			"series_finale":   {`{{.ShowTitle}} series finale`, `The final episode{{with .EpisodeTitle}}, “{{.}}”,{{end}} airs on {{.When}}.`},
+			"movie_release":   {`{{.ShowTitle}} releases`, `{{.ShowTitle}} is out on {{.When}}.`},
+			"status_change":   {`{{.ShowTitle}} status updated`, `{{.ShowTitle}} is now {{.StatusTo}}{{with .StatusFrom}} (was {{.}}){{end}}.`},
+			GroupPayloadType: {
+				`{{if .Season}}Season {{n .Season}} of {{.ShowTitle}}{{else}}{{.ShowTitle}}{{end}}: {{.Count}} episodes now available`,
+				`{{if .FirstEpisode}}Episodes {{n .FirstEpisode}}–{{n .LastEpisode}} are ready to watch.{{else}}{{.Count}} new episodes are ready to watch.{{end}}`,
+			},
+		}),
+	},
+	"es": {
+		dateLayout:  "02/01/2006",
+		timeLayout:  "02/01/2006 a las 15:04 MST",
+		emailLink:   "Ábrelo en BingeBeacon: %s",
+		emailFooter: "Recibes este correo porque sigues este título en BingeBeacon. Puedes desactivar los avisos por correo en los ajustes de tu cuenta.",
+		actions:     map[string]string{ActionMarkWatched: "Marcar como visto", ActionRemindLater: "Recordármelo luego", ActionMuteShow: "Silenciar serie"},
+		templates: compile(map[string][2]string{
+			"new_episode":     {`{{.ShowTitle}} {{code .Season .Episode}}`, `{{if .EpisodeTitle}}«{{.EpisodeTitle}}» se emite{{else}}Un nuevo episodio se emite{{end}} el {{.When}}.`},
+			"season_premiere": {`{{.ShowTitle}}: estreno de la temporada {{n .Season}}`, `La temporada {{n .Season}} empieza el {{.When}}{{with .EpisodeTitle}} con «{{.}}»{{end}}.`},
+			"series_premiere": {`Estreno de {{.ShowTitle}}`, `La serie empieza el {{.When}}{{with .EpisodeTitle}} con «{{.}}»{{end}}.`},
+			"season_finale":   {`{{.ShowTitle}}: final de la temporada {{n .Season}}`, `El final de temporada{{with .EpisodeTitle}}, «{{.}}»,{{end}} se emite el {{.When}}.`},
+			"series_finale":   {`{{.ShowTitle}}: final de la serie`, `El último episodio{{with .EpisodeTitle}}, «{{.}}»,{{end}} se emite el {{.When}}.`},
+			"movie_release":   {`Estreno de {{.ShowTitle}}`, `{{.ShowTitle}} se estrena el {{.When}}.`},
+			"status_change":   {`{{.ShowTitle}} ha cambiado de estado`, `{{.ShowTitle}} ahora está en estado «{{.StatusTo}}»{{with .StatusFrom}} (antes «{{.}}»){{end}}.`},
+			GroupPayloadType: {
+				`{{if .Season}}Temporada {{n .Season}} de {{.ShowTitle}}{{else}}{{.ShowTitle}}{{end}}: {{.Count}} episodios disponibles`,
+				`{{if .FirstEpisode}}Los episodios {{n .FirstEpisode}}–{{n .LastEpisode}} ya están disponibles.{{else}}{{.Count}} episodios nuevos ya están disponibles.{{end}}`,
+			},
+		}),
+	},
+	"fr": {
+		dateLayout:  "02/01/2006",
+		timeLayout:  "02/01/2006 à 15:04 MST",
+		emailLink:   "Ouvrir dans BingeBeacon : %s",
+		emailFooter: "Vous recevez cet e-mail car vous suivez ce titre sur BingeBeacon. Désactivez les alertes e-mail dans les paramètres de votre compte.",
+		actions:     map[string]string{ActionMarkWatched: "Marquer comme vu", ActionRemindLater: "Me le rappeler plus tard", ActionMuteShow: "Mettre la série en sourdine"},
+		templates: compile(map[string][2]string{
+			"new_episode":     {`{{.ShowTitle}} {{code .Season .Episode}}`, `{{if .EpisodeTitle}}« {{.EpisodeTitle}} » sera diffusé{{else}}Un nouvel épisode sera diffusé{{end}} le {{.When}}.`},
+			"season_premiere": {`{{.ShowTitle}} : début de la saison {{n .Season}}`, `La saison {{n .Season}} commence le {{.When}}{{with .EpisodeTitle}} avec « {{.}} »{{end}}.`},
+			"series_premiere": {`Lancement de {{.ShowTitle}}`, `La série commence le {{.When}}{{with .EpisodeTitle}} avec « {{.}} »{{end}}.`},
+			"season_finale":   {`{{.ShowTitle}} : finale de la saison {{n .Season}}`, `Le dernier épisode de la saison{{with .EpisodeTitle}}, « {{.}} »,{{end}} sera diffusé le {{.When}}.`},
+			"series_finale":   {`{{.ShowTitle}} : fin de la série`, `L'ultime épisode{{with .EpisodeTitle}}, « {{.}} »,{{end}} sera diffusé le {{.When}}.`},
+			"movie_release":   {`Sortie de {{.ShowTitle}}`, `{{.ShowTitle}} sort le {{.When}}.`},
+			"status_change":   {`{{.ShowTitle}} a changé de statut`, `{{.ShowTitle}} est désormais « {{.StatusTo}} »{{with .StatusFrom}} (auparavant « {{.}} »){{end}}.`},
+			GroupPayloadType: {
+				`{{if .Season}}Saison {{n .Season}} de {{.ShowTitle}}{{else}}{{.ShowTitle}}{{end}} : {{.Count}} épisodes disponibles`,
+				`{{if .FirstEpisode}}Les épisodes {{n .FirstEpisode}} à {{n .LastEpisode}} sont disponibles.{{else}}{{.Count}} nouveaux épisodes sont disponibles.{{end}}`,
+			},
+		}),
+	},
+	"de": {
+		dateLayout:  "02.01.2006",
+		timeLayout:  "02.01.2006 um 15:04 MST",
+		emailLink:   "In BingeBeacon öffnen: %s",
+		emailFooter: "Du erhältst diese E-Mail, weil du diesen Titel in BingeBeacon verfolgst. E-Mail-Benachrichtigungen kannst du in deinen Kontoeinstellungen abschalten.",
+		actions:     map[string]string{ActionMarkWatched: "Als gesehen markieren", ActionRemindLater: "Später erinnern", ActionMuteShow: "Serie stummschalten"},
+		templates: compile(map[string][2]string{
+			"new_episode":     {`{{.ShowTitle}} {{code .Season .Episode}}`, `{{if .EpisodeTitle}}„{{.EpisodeTitle}}“ läuft{{else}}Eine neue Folge läuft{{end}} am {{.When}}.`},
+			"season_premiere": {`{{.ShowTitle}}: Staffel {{n .Season}} startet`, `Staffel {{n .Season}} startet am {{.When}}{{with .EpisodeTitle}} mit „{{.}}“{{end}}.`},
+			"series_premiere": {`{{.ShowTitle}} startet`, `Die Serie startet am {{.When}}{{with .EpisodeTitle}} mit „{{.}}“{{end}}.`},
+			"season_finale":   {`{{.ShowTitle}}: Finale der Staffel {{n .Season}}`, `Das Staffelfinale{{with .EpisodeTitle}} „{{.}}“{{end}} läuft am {{.When}}.`},
+			"series_finale":   {`{{.ShowTitle}}: Serienfinale`, `Die letzte Folge{{with .EpisodeTitle}} „{{.}}“{{end}} läuft am {{.When}}.`},
+			"movie_release":   {`{{.ShowTitle}} erscheint`, `{{.ShowTitle}} erscheint am {{.When}}.`},
+			"status_change":   {`{{.ShowTitle}}: neuer Status`, `{{.ShowTitle}} ist jetzt „{{.StatusTo}}“{{with .StatusFrom}} (vorher „{{.}}“){{end}}.`},
+			GroupPayloadType: {
+				`{{if .Season}}Staffel {{n .Season}} von {{.ShowTitle}}{{else}}{{.ShowTitle}}{{end}}: {{.Count}} Folgen verfügbar`,
+				`{{if .FirstEpisode}}Die Folgen {{n .FirstEpisode}}–{{n .LastEpisode}} sind jetzt verfügbar.{{else}}{{.Count}} neue Folgen sind jetzt verfügbar.{{end}}`,
+			},
+		}),
+	},
+}
@@ -0,0 +1,273 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/webpush"
+)
+
+const (
+	// webPushRecordSize is the aes128gcm record size; the whole message is
+	// sent as one record, which is all push services accept anyway.
+	webPushRecordSize = 4096
+	// webPushTTL is how long the push service holds a message for an
+	// offline browser.
+	webPushTTL = 24 * time.Hour
+	// vapidExpiry is the lifetime of the VAPID JWT; push services reject
+	// anything over 24 hours.
+	vapidExpiry = 12 * time.Hour
+	gcmTagSize  = 16
+)
+
+// WebPushSubscription is a browser PushSubscription: the push service
+// endpoint and the client's P-256 public key and auth secret, base64url.
+type WebPushSubscription struct {
+	Endpoint string
+	P256DH   string
+	Auth     string
+}
+
+// WebPushClient delivers standard Web Push messages (RFC 8030), encrypted
+// for the subscription (RFC 8291) and signed with the server's VAPID key
+// (RFC 8292).
+type WebPushClient struct {
+	http      *http.Client
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+	logger    *slog.Logger
+}
+
+// NewWebPushClient loads a VAPID private key, the base64url 32-byte scalar
+// printed by `npx web-push generate-vapid-keys`. subject is a mailto: or
+// https: contact URL push services can use to reach the operator.
+func NewWebPushClient(privateKey, subject string, logger *slog.Logger) (*WebPushClient, error) {
+	raw, err := webpush.DecodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	private, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	if !strings.HasPrefix(subject, "mailto:") && !strings.HasPrefix(subject, "https://") {
+		return nil, errors.New("VAPID subject must be a mailto: or https:// URL")
+	}
+
+	public := private.PublicKey().Bytes()
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+	return &WebPushClient{
+		http:      webpush.NewHTTPClient(10 * time.Second),
+		key:       key,
+		publicKey: base64.RawURLEncoding.EncodeToString(public),
+		subject:   subject,
+		logger:    logger,
+	}, nil
+}
+
+// PublicKey is the applicationServerKey browsers subscribe with.
+func (c *WebPushClient) PublicKey() string {
+	return c.publicKey
+}
+
+// webPushMessage is the JSON the service worker receives in its push event.
+type webPushMessage struct {
+	Title   string            `json:"title"`
+	Body    string            `json:"body"`
+	Image   string            `json:"image,omitempty"`
+	Link    string            `json:"link,omitempty"`
+	Actions []Action          `json:"actions,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// Send pushes content to one subscription. A subscription the push service
+// no longer knows fails with ErrorClassUnregistered.
+func (c *WebPushClient) Send(ctx context.Context, sub WebPushSubscription, content Content) error {
+	// Actions travel as a list already; repeating them in data would
+	// crowd the 4 KB payload.
+	data := make(map[string]string, len(content.Data))
+	for k, v := range content.Data {
+		if k != "actions" {
+			data[k] = v
+		}
+	}
+	payload, err := json.Marshal(webPushMessage{
+		Title: content.Title, Body: content.Body, Image: content.ImageURL, Link: content.Link,
+		Actions: content.Actions, Data: data,
+	})
+	if err != nil {
+		return err
+	}
+	body, err := encryptWebPush(payload, sub)
+	if err != nil {
+		return &DeliveryError{Class: ErrorClassRejected, Err: err}
+	}
+	authorization, err := c.vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		return &DeliveryError{Class: ErrorClassRejected, Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return &DeliveryError{Class: ErrorClassRejected, Err: err}
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(int(webPushTTL.Seconds())))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Endpoints are user-supplied; one that resolves to an internal
+		// address is never contacted and its device is deactivated.
+		if errors.Is(err, webpush.ErrNonPublicAddress) {
+			return &DeliveryError{Class: ErrorClassUnregistered, Err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		c.logger.DebugContext(ctx, "Sent web push", "status", resp.StatusCode)
+		return nil
+	}
+	sendErr := fmt.Errorf("web push: %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return &DeliveryError{Class: ErrorClassUnregistered, Err: sendErr}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &DeliveryError{Class: ErrorClassQuota, Err: sendErr}
+	case resp.StatusCode >= 500:
+		return &DeliveryError{Class: ErrorClassUnavailable, Err: sendErr}
+	default:
+		return &DeliveryError{Class: ErrorClassRejected, Err: sendErr}
+	}
+}
+
+// vapidAuthorization signs a VAPID JWT for the endpoint's push service.
+func (c *WebPushClient) vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid push endpoint %q", endpoint)
+	}
+	claims := jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(vapidExpiry).Unix(),
+		"sub": c.subject,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(c.key)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", token, c.publicKey), nil
+}
+
+// encryptWebPush encrypts a payload for a subscription with a fresh
+// ephemeral key and salt.
+func encryptWebPush(plaintext []byte, sub WebPushSubscription) ([]byte, error) {
+	uaPublic, err := webpush.DecodeBase64URL(sub.P256DH)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription key: %w", err)
+	}
+	authSecret, err := webpush.DecodeBase64URL(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription auth secret: %w", err)
+	}
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encryptAES128GCM(plaintext, uaPublic, authSecret, asPrivate, salt)
+}
+
+// encryptAES128GCM is the RFC 8291 message encryption: an ECDH secret
+// between the application server's key and the browser's, mixed with the
+// browser's auth secret, keys a single aes128gcm record (RFC 8188) whose
+// header carries the salt and the server's public key.
+func encryptAES128GCM(plaintext, uaPublic, authSecret []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription key: %w", err)
+	}
+	if len(authSecret) != 16 {
+		return nil, errors.New("subscription auth secret must be 16 bytes")
+	}
+	// Push services cap the whole body, header included, at 4 KB.
+	if 16+4+1+65+len(plaintext)+1+gcmTagSize > webPushRecordSize {
+		return nil, errors.New("web push payload is too large")
+	}
+	shared, err := asPrivate.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, key ID length and the server's public key
+	// as the key ID. The 0x02 delimiter marks the last (only) record.
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+	record := append(append([]byte{}, plaintext...), 0x02)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
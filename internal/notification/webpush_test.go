@@ -0,0 +1,215 @@
+package notification
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/webpush"
+)
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := webpush.DecodeBase64URL(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestEncryptRFC8291 checks the example in RFC 8291, section 5.
+func TestEncryptRFC8291(t *testing.T) {
+	asPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := encryptAES128GCM(
+		[]byte("When I grow up, I want to be a watermelon"),
+		mustDecode(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"),
+		mustDecode(t, "BTBZMqHH6r4Tts7J_aSIgg"),
+		asPrivate,
+		mustDecode(t, "DGv6ra1nlYgDCS1FRnbzlw"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if got := base64.RawURLEncoding.EncodeToString(body); got != want {
+		t.Fatalf("body = %s", got)
+	}
+}
+
+// pushService stands in for a browser vendor's push service: it checks the
+// VAPID signature and decrypts messages with the subscriber's keys.
+type pushService struct {
+	t         *testing.T
+	uaPrivate *ecdh.PrivateKey
+	auth      []byte
+	status    int
+	received  []webPushMessage
+}
+
+func (p *pushService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+		http.Error(w, "bad headers", http.StatusBadRequest)
+		return
+	}
+	var token, key string
+	for _, part := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "vapid "), ", ") {
+		if v, ok := strings.CutPrefix(part, "t="); ok {
+			token = v
+		}
+		if v, ok := strings.CutPrefix(part, "k="); ok {
+			key = v
+		}
+	}
+	vapidKey, err := ecdh.P256().NewPublicKey(mustDecode(p.t, key))
+	if err != nil {
+		http.Error(w, "bad vapid key", http.StatusUnauthorized)
+		return
+	}
+	_, err = jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+		return ecdsaPublicKey(vapidKey), nil
+	}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience("http://"+r.Host))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	plaintext, err := decryptWebPush(body, p.uaPrivate, p.auth)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var msg webPushMessage
+	if err := json.Unmarshal(plaintext, &msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.received = append(p.received, msg)
+	w.WriteHeader(p.status)
+}
+
+func decryptWebPush(body []byte, uaPrivate *ecdh.PrivateKey, auth []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, errors.New("short body")
+	}
+	salt, idLen := body[:16], int(body[20])
+	if binary.BigEndian.Uint32(body[16:20]) != webPushRecordSize || len(body) < 21+idLen {
+		return nil, errors.New("bad header")
+	}
+	asPublic, ciphertext := body[21:21+idLen], body[21+idLen:]
+	asKey, err := ecdh.P256().NewPublicKey(asPublic)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := uaPrivate.ECDH(asKey)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo := append([]byte("WebPush: info\x00"), uaPrivate.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, _ := hkdf.Key(sha256.New, shared, auth, string(keyInfo), 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	record, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(record) == 0 || record[len(record)-1] != 0x02 {
+		return nil, errors.New("missing last-record delimiter")
+	}
+	return record[:len(record)-1], nil
+}
+
+func TestWebPushSend(t *testing.T) {
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	service := &pushService{t: t, uaPrivate: uaPrivate, auth: auth, status: http.StatusCreated}
+	server := httptest.NewServer(service)
+	defer server.Close()
+
+	vapid, _ := ecdh.P256().GenerateKey(rand.Reader)
+	client, err := NewWebPushClient(base64.RawURLEncoding.EncodeToString(vapid.Bytes()), "mailto:ops@example.com", slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := WebPushSubscription{
+		Endpoint: server.URL + "/push/abc",
+		P256DH:   base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(auth),
+	}
+	content := Content{
+		Title: "Severance S02E05", Body: "Airs tonight.", Link: "https://app.example.com/shows/1",
+		Actions: []Action{{Action: ActionRemindLater, Title: "Remind me later", Token: "t"}},
+		Data:    map[string]string{"show_id": "1", "actions": `[{"action":"remind_later"}]`},
+	}
+
+	// The stand-in listens on loopback, which the delivery client refuses
+	// to reach; the device is treated as unregistered.
+	err = client.Send(context.Background(), sub, content)
+	if !errors.Is(err, webpush.ErrNonPublicAddress) || !IsUnregisteredToken(err) {
+		t.Fatalf("loopback endpoint: err = %v", err)
+	}
+	if len(service.received) != 0 {
+		t.Fatal("loopback endpoint was contacted")
+	}
+
+	client.http = server.Client()
+	if err := client.Send(context.Background(), sub, content); err != nil {
+		t.Fatal(err)
+	}
+	if len(service.received) != 1 {
+		t.Fatalf("received %d messages", len(service.received))
+	}
+	msg := service.received[0]
+	if msg.Title != content.Title || msg.Link != content.Link || len(msg.Actions) != 1 || msg.Data["show_id"] != "1" || msg.Data["actions"] != "" {
+		t.Fatalf("message = %+v", msg)
+	}
+
+	// Expired subscriptions are reported as unregistered so the device is
+	// deactivated.
+	for status, class := range map[int]string{
+		http.StatusGone:                  ErrorClassUnregistered,
+		http.StatusNotFound:              ErrorClassUnregistered,
+		http.StatusTooManyRequests:       ErrorClassQuota,
+		http.StatusServiceUnavailable:    ErrorClassUnavailable,
+		http.StatusRequestEntityTooLarge: ErrorClassRejected,
+	} {
+		service.status = status
+		err := client.Send(context.Background(), sub, content)
+		if got := Classify(err); got != class {
+			t.Errorf("status %d classified %s, want %s", status, got, class)
+		}
+		if IsUnregisteredToken(err) != (class == ErrorClassUnregistered) {
+			t.Errorf("status %d: IsUnregisteredToken = %v", status, !(class == ErrorClassUnregistered))
+		}
+	}
+}
+
+func ecdsaPublicKey(key *ecdh.PublicKey) *ecdsa.PublicKey {
+	b := key.Bytes()
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(b[1:33]), Y: new(big.Int).SetBytes(b[33:])}
+}
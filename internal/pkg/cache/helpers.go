@@ -4,47 +4,222 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sync"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/metrics"
+	"golang.org/x/sync/singleflight"
 )
 
-func GetOrSet[T any](ctx context.Context, rdb *redis.Client, key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+const (
+	// lockTTL bounds how long one instance may hold a key's load lock.
+	lockTTL = 10 * time.Second
+	// lockWait is how long a miss waits for another instance's load before
+	// running the loader itself.
+	lockWait = 2 * time.Second
+	// loadTimeout bounds a shared load, which outlives the caller that
+	// started it.
+	loadTimeout = 30 * time.Second
+)
+
+// Options tunes GetOrSetWith.
+type Options struct {
+	// TTL is how long a value is served as fresh.
+	TTL time.Duration
+	// Stale is how long after TTL a value is still served while one caller
+	// refreshes it in the background. Zero disables stale serving.
+	Stale time.Duration
+	// ErrorTTL remembers a loader error for this long so a failing upstream
+	// isn't retried by every request. Zero disables negative caching.
+	ErrorTTL time.Duration
+	// Tags are added to the key's tag sets; see Store.InvalidateTags.
+	Tags []string
+}
+
+// CachedError stands in for a loader error served from the negative cache.
+type CachedError struct {
+	Message string
+}
+
+func (e *CachedError) Error() string { return e.Message }
+
+// entry is the stored form of a cached value.
+type entry struct {
+	Value      json.RawMessage `json:"v,omitempty"`
+	Err        string          `json:"e,omitempty"`
+	FreshUntil int64           `json:"f"`
+}
+
+func (e *entry) fresh(now time.Time) bool {
+	return now.UnixMilli() < e.FreshUntil
+}
+
+// decodeEntry reports ok=false for values that aren't entries, such as those
+// written before entries existed, so they are reloaded.
+func decodeEntry(raw []byte) (*entry, bool) {
+	var e entry
+	if err := json.Unmarshal(raw, &e); err != nil || e.FreshUntil == 0 {
+		return nil, false
+	}
+	return &e, true
+}
+
+func decodeValue[T any](e *entry) (T, error) {
+	var result T
+	if e.Err != "" {
+		return result, &CachedError{Message: e.Err}
+	}
+	err := json.Unmarshal(e.Value, &result)
+	return result, err
+}
+
+var group singleflight.Group
+
+func GetOrSet[T any](ctx context.Context, store Store, key string, ttl time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	return GetOrSetWith(ctx, store, key, Options{TTL: ttl}, fn)
+}
+
+// GetOrSetWith returns the cached value for key, calling fn on a miss.
+// Concurrent misses share one call to fn within the process, and the store's
+// lock keeps other instances waiting on it rather than calling fn too. fn
+// receives a context that is not cancelled with the caller's.
+func GetOrSetWith[T any](ctx context.Context, store Store, key string, opts Options, fn func(ctx context.Context) (T, error)) (T, error) {
 	var zero T
 
-	// Try get
-	val, err := rdb.Get(ctx, key).Result()
-	if err == nil {
-		var result T
-		if err := json.Unmarshal([]byte(val), &result); err == nil {
-			return result, nil
+	raw, err := store.Get(ctx, key)
+	switch {
+	case err == nil:
+		if e, ok := decodeEntry(raw); ok {
+			if e.fresh(time.Now()) {
+				metrics.CacheLookup(key, "hit")
+				return decodeValue[T](e)
+			}
+			if e.Err == "" {
+				metrics.CacheLookup(key, "stale")
+				refresh(ctx, store, key, opts, fn)
+				return decodeValue[T](e)
+			}
+		}
+		metrics.CacheLookup(key, "miss")
+	case errors.Is(err, ErrMiss):
+		metrics.CacheLookup(key, "miss")
+	default:
+		metrics.CacheLookup(key, "error")
+	}
+
+	ch := group.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		return load(loadCtx, store, key, opts, fn, true)
+	})
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
 		}
-		// If unmarshal fails, we proceed to compute and overwrite
-	} else if !errors.Is(err, redis.Nil) {
-		// Log error? For now just proceed
+		return res.Val.(T), nil
 	}
+}
 
-	// Compute
-	result, err := fn()
+// refresh reloads a stale key in the background unless this process or
+// another instance is already doing so. Refreshes use their own flight key
+// so a miss never receives a skipped refresh's empty result.
+func refresh[T any](ctx context.Context, store Store, key string, opts Options, fn func(ctx context.Context) (T, error)) {
+	group.DoChan("refresh:"+key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		return load(loadCtx, store, key, opts, fn, false)
+	})
+}
+
+// load calls fn under the key's Redis lock and stores the result. When
+// another instance holds the lock, wait decides between waiting for its
+// result and giving up.
+func load[T any](ctx context.Context, store Store, key string, opts Options, fn func(ctx context.Context) (T, error), wait bool) (T, error) {
+	var zero T
+
+	unlock, locked, err := store.Lock(ctx, "lock:"+key, lockTTL)
+	if err == nil && !locked {
+		if !wait {
+			return zero, nil
+		}
+		if e, ok := awaitEntry(ctx, store, key); ok {
+			return decodeValue[T](e)
+		}
+	}
+	if locked {
+		defer unlock()
+	}
+
+	tags := &tagSet{tags: append([]string(nil), opts.Tags...)}
+	result, err := fn(context.WithValue(ctx, tagsKey{}, tags))
 	if err != nil {
+		if opts.ErrorTTL > 0 {
+			put(ctx, store, key, &entry{Err: err.Error()}, opts.ErrorTTL, opts.ErrorTTL, nil)
+		}
 		return zero, err
 	}
 
-	// Set
-	bytes, err := json.Marshal(result)
+	value, err := json.Marshal(result)
 	if err == nil {
-		rdb.Set(ctx, key, bytes, ttl)
+		put(ctx, store, key, &entry{Value: value}, opts.TTL, opts.TTL+opts.Stale, tags.list())
 	}
-
 	return result, nil
 }
 
-func Invalidate(ctx context.Context, rdb *redis.Client, pattern string) error {
-	iter := rdb.Scan(ctx, 0, pattern, 0).Iterator()
-	for iter.Next(ctx) {
-		if err := rdb.Del(ctx, iter.Val()).Err(); err != nil {
-			return err
+// awaitEntry polls for a value another instance is loading.
+func awaitEntry(ctx context.Context, store Store, key string) (*entry, bool) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(lockWait)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-deadline:
+			return nil, false
+		case <-ticker.C:
+		}
+		raw, err := store.Get(ctx, key)
+		if err != nil {
+			continue
 		}
+		if e, ok := decodeEntry(raw); ok {
+			return e, true
+		}
+	}
+}
+
+func put(ctx context.Context, store Store, key string, e *entry, fresh, expiry time.Duration, tags []string) {
+	e.FreshUntil = time.Now().Add(fresh).UnixMilli()
+	if raw, err := json.Marshal(e); err == nil {
+		store.Set(ctx, key, raw, expiry, tags...)
+	}
+}
+
+type tagsKey struct{}
+
+type tagSet struct {
+	mu   sync.Mutex
+	tags []string
+}
+
+func (s *tagSet) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tags
+}
+
+// AddTags tags the value being loaded. Call it from inside a GetOrSet
+// loader when the tags depend on what was loaded; elsewhere it does nothing.
+func AddTags(ctx context.Context, tags ...string) {
+	s, ok := ctx.Value(tagsKey{}).(*tagSet)
+	if !ok {
+		return
 	}
-	return iter.Err()
+	s.mu.Lock()
+	s.tags = append(s.tags, tags...)
+	s.mu.Unlock()
 }
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDecodeEntry(t *testing.T) {
+	// Values written before entries existed are treated as misses.
+	for _, legacy := range []string{`[{"id":1}]`, `{"title":"Severance"}`, `42`, `not json`} {
+		if _, ok := decodeEntry([]byte(legacy)); ok {
+			t.Fatalf("decodeEntry(%s) accepted a legacy value", legacy)
+		}
+	}
+
+	now := time.Now()
+	e, ok := decodeEntry([]byte(`{"v":{"title":"Severance"},"f":` + strconv.FormatInt(now.Add(time.Minute).UnixMilli(), 10) + `}`))
+	if !ok || !e.fresh(now) {
+		t.Fatalf("expected a fresh entry, got %+v ok=%v", e, ok)
+	}
+	if e.fresh(now.Add(2 * time.Minute)) {
+		t.Fatal("entry still fresh after its soft TTL")
+	}
+	got, err := decodeValue[map[string]string](e)
+	if err != nil || got["title"] != "Severance" {
+		t.Fatalf("decodeValue = %v, %v", got, err)
+	}
+
+	negative := &entry{Err: "tmdb: 503", FreshUntil: now.Add(time.Minute).UnixMilli()}
+	_, err = decodeValue[[]string](negative)
+	var cached *CachedError
+	if !errors.As(err, &cached) || cached.Message != "tmdb: 503" {
+		t.Fatalf("negative entry returned %v", err)
+	}
+}
+
+func TestAddTagsOnlyInsideLoader(t *testing.T) {
+	AddTags(context.Background(), "show:ignored")
+
+	tags := &tagSet{tags: []string{"profile:1"}}
+	ctx := context.WithValue(context.Background(), tagsKey{}, tags)
+	AddTags(ctx, "show:a", "show:b")
+
+	got := tags.list()
+	if len(got) != 3 || got[0] != "profile:1" || got[2] != "show:b" {
+		t.Fatalf("tags = %v", got)
+	}
+}
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// lru is the in-process tier: a byte-bounded least-recently-used map with
+// per-entry expiry and tag sets.
+type lru struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List
+	items    map[string]*list.Element
+	tags     map[string]map[string]struct{}
+}
+
+type lruItem struct {
+	key     string
+	value   []byte
+	expires time.Time
+	tags    []string
+}
+
+func (it *lruItem) size() int64 {
+	return int64(len(it.key) + len(it.value))
+}
+
+func newLRU(maxBytes int64) *lru {
+	return &lru{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		tags:     make(map[string]map[string]struct{}),
+	}
+}
+
+func (c *lru) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	it := c.lookup(key, time.Now())
+	if it == nil {
+		return nil, false
+	}
+	return it.value, true
+}
+
+// lookup returns a live item and marks it recently used, dropping it if it
+// has expired. Callers hold mu.
+func (c *lru) lookup(key string, now time.Time) *lruItem {
+	el, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	it := el.Value.(*lruItem)
+	if !now.Before(it.expires) {
+		c.remove(el)
+		return nil
+	}
+	c.order.MoveToFront(el)
+	return it
+}
+
+func (c *lru) set(key string, value []byte, ttl time.Duration, tags []string) {
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	c.insert(&lruItem{key: key, value: value, expires: time.Now().Add(ttl), tags: tags})
+}
+
+// insert adds an item, evicting the least recently used ones to make room.
+// Callers hold mu and have removed any item under the same key.
+func (c *lru) insert(it *lruItem) {
+	if it.size() > c.maxBytes {
+		return
+	}
+	c.items[it.key] = c.order.PushFront(it)
+	c.size += it.size()
+	for _, tag := range it.tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[it.key] = struct{}{}
+	}
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lru) delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+func (c *lru) invalidateTags(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if el, ok := c.items[key]; ok {
+				c.remove(el)
+			}
+		}
+		delete(c.tags, tag)
+	}
+}
+
+// remove unlinks an item and its tag memberships. Callers hold mu.
+func (c *lru) remove(el *list.Element) {
+	it := el.Value.(*lruItem)
+	c.order.Remove(el)
+	delete(c.items, it.key)
+	c.size -= it.size()
+	for _, tag := range it.tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, it.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
+
+// incr adds one to a counter, starting its window on the first hit.
+func (c *lru) incr(key string, window time.Duration) (int64, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	var count int64
+	expires := now.Add(window)
+	if it := c.lookup(key, now); it != nil {
+		count, _ = strconv.ParseInt(string(it.value), 10, 64)
+		expires = it.expires
+		c.remove(c.items[key])
+	}
+	count++
+	c.insert(&lruItem{key: key, value: []byte(strconv.FormatInt(count, 10)), expires: expires})
+	return count, expires.Sub(now)
+}
+
+func (c *lru) expire(key string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if it := c.lookup(key, time.Now()); it != nil {
+		it.expires = time.Now().Add(ttl)
+	}
+}
+
+func (c *lru) ttl(key string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if it := c.lookup(key, now); it != nil {
+		return it.expires.Sub(now)
+	}
+	return 0
+}
+
+func (c *lru) take(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	it := c.lookup(key, time.Now())
+	if it == nil {
+		return nil, false
+	}
+	c.remove(c.items[key])
+	return it.value, true
+}
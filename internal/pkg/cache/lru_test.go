@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRU(30)
+	c.set("a", []byte("0123456789"), time.Minute, nil)
+	c.set("b", []byte("0123456789"), time.Minute, nil)
+	c.get("a")
+	c.set("c", []byte("0123456789"), time.Minute, nil)
+
+	if _, ok := c.get("b"); ok {
+		t.Fatal("b should have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.get(key); !ok {
+			t.Fatalf("%s should still be cached", key)
+		}
+	}
+	if c.size > c.maxBytes {
+		t.Fatalf("size %d over limit %d", c.size, c.maxBytes)
+	}
+}
+
+func TestLRUTagsAndExpiry(t *testing.T) {
+	c := newLRU(1 << 20)
+	c.set("show:1", []byte("{}"), time.Minute, []string{"show:1"})
+	c.set("season:1:1", []byte("{}"), time.Minute, []string{"show:1"})
+	c.set("show:2", []byte("{}"), time.Minute, []string{"show:2"})
+	c.set("gone", []byte("{}"), time.Nanosecond, nil)
+	time.Sleep(time.Millisecond)
+
+	c.invalidateTags("show:1")
+	for key, want := range map[string]bool{"show:1": false, "season:1:1": false, "show:2": true, "gone": false} {
+		if _, ok := c.get(key); ok != want {
+			t.Fatalf("get(%s) ok=%v, want %v", key, ok, want)
+		}
+	}
+	if _, ok := c.tags["show:1"]; ok {
+		t.Fatal("invalidated tag set left behind")
+	}
+
+	if count, _ := c.incr("hits", time.Minute); count != 1 {
+		t.Fatalf("first incr = %d", count)
+	}
+	if count, ttl := c.incr("hits", time.Hour); count != 2 || ttl > time.Minute {
+		t.Fatalf("second incr = %d, ttl %v; the window should not restart", count, ttl)
+	}
+	if value, ok := c.take("hits"); !ok || string(value) != "2" {
+		t.Fatalf("take = %q, %v", value, ok)
+	}
+	if _, ok := c.take("hits"); ok {
+		t.Fatal("take returned a value twice")
+	}
+}
@@ -1,14 +1,15 @@
 package cache
 
 import (
-	"context"
 	"fmt"
-	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"github.com/tashifkhan/bingebeacon/internal/config"
 )
 
+// NewRedisClient does not wait for Redis to answer; the client connects on
+// first use and reconnects after outages, so the API can start without it.
 func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     cfg.Addr,
@@ -16,11 +17,8 @@ func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
 		DB:       cfg.DB,
 	})
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		return nil, fmt.Errorf("failed to enable redis tracing: %w", err)
 	}
 
 	return client, nil
@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get and Take when a key is absent.
+var ErrMiss = errors.New("cache miss")
+
+// Store is the cache services read and write through. Cached values may be
+// served from an in-process tier; the shared-state methods (Incr, Expire,
+// TTL, Take) always go to Redis when it is configured and reachable, so
+// counters and one-time values hold across replicas.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value and adds key to each tag's set; see InvalidateTags.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	Delete(ctx context.Context, keys ...string) error
+	// InvalidateTags deletes every key carrying any of the tags.
+	InvalidateTags(ctx context.Context, tags ...string) error
+	// Lock takes a short-lived lock shared by all replicas. ok is false when
+	// another holder has it.
+	Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error)
+
+	// Incr counts a hit against key, starting its expiry on the first one,
+	// and returns the count and the time left.
+	Incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	// TTL returns zero for a missing key.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Take returns a value and deletes it, so it can be redeemed only once.
+	Take(ctx context.Context, key string) ([]byte, error)
+}
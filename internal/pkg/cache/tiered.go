@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/tashifkhan/bingebeacon/internal/config"
+)
+
+const (
+	invalidationChannel = "cache:invalidate"
+	// redisBackoff is how long Redis is skipped after a failed call, so an
+	// outage costs one timeout rather than one per request.
+	redisBackoff = 5 * time.Second
+)
+
+// Tiered is a Store with an in-process LRU in front of Redis. Writes and
+// deletes reach both tiers, and deletes are broadcast over pub/sub so other
+// replicas drop their copies. Without Redis, or while it is unreachable,
+// everything is served from the LRU alone.
+type Tiered struct {
+	l1     *lru
+	l1TTL  time.Duration
+	rdb    *redis.Client
+	id     string
+	logger *slog.Logger
+
+	downUntil atomic.Int64
+}
+
+// NewTiered builds the cache. rdb may be nil to run without Redis, in which
+// case entries keep their full TTL in memory.
+func NewTiered(rdb *redis.Client, cfg config.CacheConfig, logger *slog.Logger) *Tiered {
+	return &Tiered{
+		l1:     newLRU(cfg.L1MaxBytes),
+		l1TTL:  cfg.L1TTL,
+		rdb:    rdb,
+		id:     uuid.NewString(),
+		logger: logger,
+	}
+}
+
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// Listen applies other replicas' deletes to the LRU until ctx is cancelled.
+// Messages missed while disconnected are covered by the L1 TTL.
+func (t *Tiered) Listen(ctx context.Context) {
+	if t.rdb == nil {
+		return
+	}
+	sub := t.rdb.Subscribe(ctx, invalidationChannel)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil || inv.Origin == t.id {
+				continue
+			}
+			t.l1.delete(inv.Keys...)
+			t.l1.invalidateTags(inv.Tags...)
+		}
+	}
+}
+
+// redisUp reports whether Redis is configured and not in backoff.
+func (t *Tiered) redisUp() bool {
+	return t.rdb != nil && time.Now().UnixNano() >= t.downUntil.Load()
+}
+
+// check puts Redis into backoff after a connection-level failure.
+func (t *Tiered) check(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, redis.Nil) || ctx.Err() != nil {
+		return err
+	}
+	if t.downUntil.Swap(time.Now().Add(redisBackoff).UnixNano()) < time.Now().UnixNano() {
+		t.logger.WarnContext(ctx, "Redis unavailable; using the in-process cache", "error", err)
+	}
+	return err
+}
+
+func (t *Tiered) localTTL(ttl time.Duration) time.Duration {
+	if t.rdb != nil && t.l1TTL > 0 && ttl > t.l1TTL {
+		return t.l1TTL
+	}
+	return ttl
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, ok := t.l1.get(key); ok {
+		return value, nil
+	}
+	if !t.redisUp() {
+		return nil, ErrMiss
+	}
+
+	pipe := t.rdb.Pipeline()
+	get := pipe.Get(ctx, key)
+	pttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrMiss
+		}
+		return nil, t.check(ctx, err)
+	}
+	value, _ := get.Bytes()
+	if ttl := pttl.Val(); ttl > 0 {
+		t.l1.set(key, value, t.localTTL(ttl), nil)
+	}
+	return value, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	t.l1.set(key, value, t.localTTL(ttl), tags)
+	if !t.redisUp() {
+		return nil
+	}
+
+	pipe := t.rdb.TxPipeline()
+	pipe.Set(ctx, key, value, ttl)
+	for _, tag := range tags {
+		// A tag set lives as long as its longest-lived key.
+		pipe.SAdd(ctx, tagKey(tag), key)
+		pipe.ExpireNX(ctx, tagKey(tag), ttl)
+		pipe.ExpireGT(ctx, tagKey(tag), ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return t.check(ctx, err)
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	t.l1.delete(keys...)
+	if !t.redisUp() {
+		return nil
+	}
+	if err := t.check(ctx, t.rdb.Del(ctx, keys...).Err()); err != nil {
+		return err
+	}
+	return t.publish(ctx, invalidation{Keys: keys})
+}
+
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
+
+// invalidateScript deletes every key in the given tag sets, then the sets,
+// and returns the deleted keys. Running it as a script keeps a key tagged
+// mid-invalidation from slipping through.
+var invalidateScript = redis.NewScript(`
+local deleted = {}
+for _, tag in ipairs(KEYS) do
+	local members = redis.call("SMEMBERS", tag)
+	for i = 1, #members, 500 do
+		redis.call("DEL", unpack(members, i, math.min(i + 499, #members)))
+	end
+	for _, key in ipairs(members) do
+		deleted[#deleted + 1] = key
+	end
+	redis.call("DEL", tag)
+end
+return deleted
+`)
+
+func (t *Tiered) InvalidateTags(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	t.l1.invalidateTags(tags...)
+	if !t.redisUp() {
+		return nil
+	}
+
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = tagKey(tag)
+	}
+	// Other replicas may hold keys they read from Redis without their tags,
+	// so the message names the deleted keys as well.
+	deleted, err := invalidateScript.Run(ctx, t.rdb, keys).StringSlice()
+	if err := t.check(ctx, err); err != nil {
+		return err
+	}
+	return t.publish(ctx, invalidation{Keys: deleted, Tags: tags})
+}
+
+func (t *Tiered) publish(ctx context.Context, inv invalidation) error {
+	inv.Origin = t.id
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	return t.check(ctx, t.rdb.Publish(ctx, invalidationChannel, payload).Err())
+}
+
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Lock always succeeds without Redis; callers already coalesce within the
+// process.
+func (t *Tiered) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	if !t.redisUp() {
+		return func() {}, true, nil
+	}
+	token := uuid.NewString()
+	ok, err := t.rdb.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, false, t.check(ctx, err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	return func() {
+		unlockScript.Run(context.WithoutCancel(ctx), t.rdb, []string{key}, token)
+	}, true, nil
+}
+
+// fixedWindow counts a hit and starts the window on the first one, so the
+// count and its expiry are set atomically.
+var fixedWindow = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {count, redis.call("PTTL", KEYS[1])}
+`)
+
+func (t *Tiered) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	if !t.redisUp() {
+		count, ttl := t.l1.incr(key, window)
+		return count, ttl, nil
+	}
+	values, err := fixedWindow.Run(ctx, t.rdb, []string{key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, t.check(ctx, err)
+	}
+	ttl := time.Duration(values[1]) * time.Millisecond
+	if ttl < 0 {
+		ttl = window
+	}
+	return values[0], ttl, nil
+}
+
+func (t *Tiered) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if !t.redisUp() {
+		t.l1.expire(key, ttl)
+		return nil
+	}
+	return t.check(ctx, t.rdb.Expire(ctx, key, ttl).Err())
+}
+
+func (t *Tiered) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if !t.redisUp() {
+		return t.l1.ttl(key), nil
+	}
+	ttl, err := t.rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, t.check(ctx, err)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (t *Tiered) Take(ctx context.Context, key string) ([]byte, error) {
+	if !t.redisUp() {
+		if value, ok := t.l1.take(key); ok {
+			return value, nil
+		}
+		return nil, ErrMiss
+	}
+	t.l1.delete(key)
+	value, err := t.rdb.GetDel(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, t.check(ctx, err)
+}
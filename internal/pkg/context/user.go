@@ -8,7 +8,12 @@ import (
 
 type key string
 
-const UserIDKey key = "user_id"
+const (
+	UserIDKey    key = "user_id"
+	ProfileIDKey key = "profile_id"
+	SessionIDKey key = "session_id"
+	RoleKey      key = "role"
+)
 
 func WithUserID(ctx context.Context, userID uuid.UUID) context.Context {
 	return context.WithValue(ctx, UserIDKey, userID)
@@ -18,3 +23,38 @@ func UserID(ctx context.Context) (uuid.UUID, bool) {
 	userID, ok := ctx.Value(UserIDKey).(uuid.UUID)
 	return userID, ok
 }
+
+func WithProfileID(ctx context.Context, profileID uuid.UUID) context.Context {
+	return context.WithValue(ctx, ProfileIDKey, profileID)
+}
+
+// ProfileID returns the household profile selected in the access token,
+// falling back to the account's primary profile, which shares the user ID.
+func ProfileID(ctx context.Context) (uuid.UUID, bool) {
+	if profileID, ok := ctx.Value(ProfileIDKey).(uuid.UUID); ok {
+		return profileID, true
+	}
+	return UserID(ctx)
+}
+
+func WithSessionID(ctx context.Context, sessionID uuid.UUID) context.Context {
+	return context.WithValue(ctx, SessionIDKey, sessionID)
+}
+
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, RoleKey, role)
+}
+
+// Role returns the account role from the access token. Personal access
+// tokens carry none.
+func Role(ctx context.Context) string {
+	role, _ := ctx.Value(RoleKey).(string)
+	return role
+}
+
+// SessionID returns the sign-in session the access token belongs to. Tokens
+// issued before sessions existed carry none.
+func SessionID(ctx context.Context) (uuid.UUID, bool) {
+	sessionID, ok := ctx.Value(SessionIDKey).(uuid.UUID)
+	return sessionID, ok
+}
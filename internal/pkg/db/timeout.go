@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const cancelKey = "query_timeout:cancel"
+
+// queryTimeout gives every statement a deadline of its own, so a query run
+// under a long-lived context (a scheduler job, a request whose client went
+// quiet) cannot hold a connection indefinitely. An earlier deadline on the
+// caller's context still wins.
+type queryTimeout time.Duration
+
+func (queryTimeout) Name() string { return "query_timeout" }
+
+func (t queryTimeout) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	// Row and Rows return a cursor that is read after the callbacks finish,
+	// so the row processor is left on the caller's context.
+	for _, err := range []error{
+		cb.Create().Before("*").Register("query_timeout:start", t.start),
+		cb.Create().After("*").Register("query_timeout:end", end),
+		cb.Query().Before("*").Register("query_timeout:start", t.start),
+		cb.Query().After("*").Register("query_timeout:end", end),
+		cb.Update().Before("*").Register("query_timeout:start", t.start),
+		cb.Update().After("*").Register("query_timeout:end", end),
+		cb.Delete().Before("*").Register("query_timeout:start", t.start),
+		cb.Delete().After("*").Register("query_timeout:end", end),
+		cb.Raw().Before("*").Register("query_timeout:start", t.start),
+		cb.Raw().After("*").Register("query_timeout:end", end),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t queryTimeout) start(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(t))
+	db.Statement.Context = ctx
+	db.InstanceSet(cancelKey, cancel)
+}
+
+func end(db *gorm.DB) {
+	if cancel, ok := db.InstanceGet(cancelKey); ok {
+		cancel.(context.CancelFunc)()
+	}
+}
@@ -0,0 +1,92 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tashifkhan/bingebeacon/internal/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers transactional email.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender returns an SMTP sender when a host is configured, otherwise a
+// LogSender so local development works without a mail server.
+func NewSender(cfg config.EmailConfig, logger *slog.Logger) Sender {
+	if cfg.SMTPHost == "" {
+		return &LogSender{logger: logger}
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+type SMTPSender struct {
+	cfg config.EmailConfig
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(s.cfg.SMTPHost, strconv.Itoa(s.cfg.SMTPPort))
+	var auth smtp.Auth
+	if s.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword, s.cfg.SMTPHost)
+	}
+
+	envelopeFrom := s.cfg.From
+	if parsed, err := mail.ParseAddress(s.cfg.From); err == nil {
+		envelopeFrom = parsed.Address
+	}
+
+	// net/smtp has no context support, so run it under the caller's deadline.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, envelopeFrom, []string{msg.To}, formatMessage(s.cfg.From, msg))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func formatMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", sanitizeHeader(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func sanitizeHeader(v string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+}
+
+// LogSender writes messages to the log instead of sending them. It stands
+// in for SMTP in development, where reset and verification links can be
+// copied straight from the API output.
+type LogSender struct {
+	logger *slog.Logger
+}
+
+func (s *LogSender) Send(_ context.Context, msg Message) error {
+	s.logger.Info("Email (not sent: SMTP not configured)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
@@ -18,6 +18,6 @@ func Init(env string) {
 			Level: slog.LevelDebug,
 		})
 	}
-	Log = slog.New(handler)
+	Log = slog.New(traceHandler{handler})
 	slog.SetDefault(Log)
 }
@@ -0,0 +1,218 @@
+// Package metrics holds the Prometheus collectors the API exports on
+// /metrics. Collectors are registered on the default registry alongside the
+// Go runtime and process metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bingebeacon"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time spent serving HTTP requests, by route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	jobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Scheduler job runs, by outcome (success, failure or cancelled).",
+	}, []string{"job", "outcome"})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Scheduler job run time.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"job"})
+
+	jobLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "job_last_success_timestamp_seconds",
+		Help:      "Unix time of each job's last successful run.",
+	}, []string{"job"})
+
+	providerRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_requests_total",
+		Help:      "HTTP calls to external metadata providers, by status class.",
+	}, []string{"provider", "outcome"})
+
+	providerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "provider_request_duration_seconds",
+		Help:      "Latency of calls to external metadata providers.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"provider"})
+
+	providerRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_retries_total",
+		Help:      "Provider calls retried after a transport error, 429 or 5xx.",
+	}, []string{"provider"})
+
+	providerCircuitOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "provider_circuit_open",
+		Help:      "1 while a provider's circuit breaker is rejecting calls.",
+	}, []string{"provider"})
+
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Redis cache lookups, by key prefix and result (hit, stale, miss or error).",
+	}, []string{"cache", "result"})
+
+	notificationQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "notification_queue_depth",
+		Help:      "Pending notifications that are due, as of the last dispatch run.",
+	})
+
+	notificationDeadLetters = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "notification_dead_letters",
+		Help:      "Notifications in the dead-letter queue, by error class, as of the last dispatch run.",
+	}, []string{"error_class"})
+
+	notificationDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notification_deliveries_total",
+		Help:      "Notification deliveries, by channel and result.",
+	}, []string{"channel", "result"})
+)
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records request counts and latency per route template, so
+// /shows/{id} is one series rather than one per show. It must be installed
+// with Router.Use so the matched route is known.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(recorder.status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// ObserveJob records one scheduler run. Outcome is success, failure or
+// cancelled.
+func ObserveJob(job, outcome string, elapsed time.Duration) {
+	jobRuns.WithLabelValues(job, outcome).Inc()
+	jobDuration.WithLabelValues(job).Observe(elapsed.Seconds())
+	if outcome == "success" {
+		jobLastSuccess.WithLabelValues(job).SetToCurrentTime()
+	}
+}
+
+// InstrumentTransport counts and times every request a provider client
+// sends, including each retry attempt and any login calls.
+func InstrumentTransport(provider string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := base.RoundTrip(req)
+		providerDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+		outcome := "error"
+		if err == nil {
+			outcome = strconv.Itoa(resp.StatusCode/100) + "xx"
+		}
+		providerRequests.WithLabelValues(provider, outcome).Inc()
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func ProviderRetry(provider string) {
+	providerRetries.WithLabelValues(provider).Inc()
+}
+
+// ProviderRejected counts a call refused locally because the circuit is open.
+func ProviderRejected(provider string) {
+	providerRequests.WithLabelValues(provider, "circuit_open").Inc()
+}
+
+func SetCircuitOpen(provider string, open bool) {
+	value := 0.0
+	if open {
+		value = 1
+	}
+	providerCircuitOpen.WithLabelValues(provider).Set(value)
+}
+
+// CacheLookup records a cache read. Keys are labelled by their prefix, the
+// part before the first colon, to keep the series count bounded.
+func CacheLookup(key, result string) {
+	prefix, _, _ := strings.Cut(key, ":")
+	cacheRequests.WithLabelValues(prefix, result).Inc()
+}
+
+func SetNotificationQueueDepth(depth int64) {
+	notificationQueueDepth.Set(float64(depth))
+}
+
+// SetNotificationDeadLetters replaces the dead-letter sizes, so classes that
+// have emptied drop to zero.
+func SetNotificationDeadLetters(classes []string, counts map[string]int64) {
+	for _, class := range classes {
+		notificationDeadLetters.WithLabelValues(class).Set(float64(counts[class]))
+	}
+}
+
+// NotificationDelivery records one delivery attempt. Channel is push, email
+// or in_app.
+func NotificationDelivery(channel, result string) {
+	notificationDeliveries.WithLabelValues(channel, result).Inc()
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	appctx "github.com/tashifkhan/bingebeacon/internal/pkg/context"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/httputil"
+)
+
+// KeyFunc picks the bucket a request is counted against.
+type KeyFunc func(r *http.Request) string
+
+// ByIP counts requests per client address.
+func ByIP(r *http.Request) string {
+	return "ip:" + httputil.ClientIP(r)
+}
+
+// ByUser counts signed-in requests per account and anonymous ones per
+// address. It must run after the auth middleware.
+func ByUser(r *http.Request) string {
+	if userID, ok := appctx.UserID(r.Context()); ok {
+		return "user:" + userID.String()
+	}
+	return ByIP(r)
+}
+
+// Rule allows Requests per Window for each key.
+type Rule struct {
+	Name     string
+	Requests int
+	Window   time.Duration
+	Key      KeyFunc
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+// Limiter counts requests in fixed windows through the cache store, so limits
+// hold across API replicas when Redis is configured.
+type Limiter struct {
+	store   cache.Store
+	enabled bool
+	logger  *slog.Logger
+}
+
+func NewLimiter(store cache.Store, enabled bool, logger *slog.Logger) *Limiter {
+	return &Limiter{store: store, enabled: enabled, logger: logger}
+}
+
+// Allow records one request against key and reports whether it fits the
+// rule's budget.
+func (l *Limiter) Allow(ctx context.Context, rule Rule, key string) (Result, error) {
+	count, ttl, err := l.store.Incr(ctx, fmt.Sprintf("ratelimit:%s:%s", rule.Name, key), rule.Window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	remaining := rule.Requests - int(count)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{
+		Allowed:   count <= int64(rule.Requests),
+		Limit:     rule.Requests,
+		Remaining: remaining,
+		Reset:     ttl,
+	}, nil
+}
+
+// Middleware enforces rule, answering 429 once a bucket is spent. Requests
+// are let through if Redis is unavailable rather than failing the API.
+func (l *Limiter) Middleware(rule Rule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !l.enabled || rule.Requests <= 0 || rule.Window <= 0 {
+			return next
+		}
+		keyFunc := rule.Key
+		if keyFunc == nil {
+			keyFunc = ByIP
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := l.Allow(r.Context(), rule, keyFunc(r))
+			if err != nil {
+				l.logger.WarnContext(r.Context(), "Rate limiter unavailable", "rule", rule.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			WriteHeaders(w, result, rule.Window)
+			if !result.Allowed {
+				RetryAfter(w, result.Reset)
+				httputil.Error(w, http.StatusTooManyRequests, "Too many requests, please slow down")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WriteHeaders sets the RateLimit-* fields from the IETF httpapi draft.
+func WriteHeaders(w http.ResponseWriter, result Result, window time.Duration) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit, seconds(window)))
+}
+
+// RetryAfter sets Retry-After in whole seconds, rounding up so clients never
+// retry early.
+func RetryAfter(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(seconds(wait)))
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tashifkhan/bingebeacon/internal/config"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+)
+
+func newTestLimiter(enabled bool) *Limiter {
+	store := cache.NewTiered(nil, config.CacheConfig{L1MaxBytes: 1 << 20, L1TTL: time.Minute}, slog.New(slog.DiscardHandler))
+	return NewLimiter(store, enabled, slog.New(slog.DiscardHandler))
+}
+
+func TestAllowCountsDownToZero(t *testing.T) {
+	limiter := newTestLimiter(true)
+	rule := Rule{Name: "test", Requests: 2, Window: time.Minute}
+
+	for i, want := range []Result{
+		{Allowed: true, Limit: 2, Remaining: 1},
+		{Allowed: true, Limit: 2, Remaining: 0},
+		{Allowed: false, Limit: 2, Remaining: 0},
+		{Allowed: false, Limit: 2, Remaining: 0},
+	} {
+		got, err := limiter.Allow(context.Background(), rule, "ip:192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Allowed != want.Allowed || got.Limit != want.Limit || got.Remaining != want.Remaining {
+			t.Fatalf("request %d: got %+v, want %+v", i+1, got, want)
+		}
+		if got.Reset <= 0 || got.Reset > rule.Window {
+			t.Fatalf("request %d: reset %s outside the window", i+1, got.Reset)
+		}
+	}
+
+	// Buckets are per key.
+	if got, _ := limiter.Allow(context.Background(), rule, "ip:192.0.2.2"); !got.Allowed || got.Remaining != 1 {
+		t.Fatalf("other key: got %+v", got)
+	}
+}
+
+func TestMiddlewareRejectsWithHeaders(t *testing.T) {
+	limiter := newTestLimiter(true)
+	handler := limiter.Middleware(Rule{Name: "auth", Requests: 1, Window: 90 * time.Second})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+		req.RemoteAddr = "192.0.2.1:5000"
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	first := serve()
+	if first.Code != http.StatusNoContent {
+		t.Fatalf("first request: status %d", first.Code)
+	}
+	if first.Header().Get("RateLimit-Limit") != "1" || first.Header().Get("RateLimit-Remaining") != "0" ||
+		first.Header().Get("RateLimit-Policy") != "1;w=90" || first.Header().Get("Retry-After") != "" {
+		t.Fatalf("first request headers: %v", first.Header())
+	}
+
+	second := serve()
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status %d, want 429", second.Code)
+	}
+	if second.Header().Get("RateLimit-Remaining") != "0" || second.Header().Get("Retry-After") == "" ||
+		second.Header().Get("Retry-After") != second.Header().Get("RateLimit-Reset") {
+		t.Fatalf("second request headers: %v", second.Header())
+	}
+	if reset := second.Header().Get("Retry-After"); reset == "0" {
+		t.Fatalf("Retry-After %q must be at least one second", reset)
+	}
+}
+
+func TestMiddlewareBypass(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for name, tc := range map[string]struct {
+		enabled bool
+		rule    Rule
+	}{
+		"disabled":      {false, Rule{Name: "a", Requests: 1, Window: time.Minute}},
+		"zero requests": {true, Rule{Name: "b", Requests: 0, Window: time.Minute}},
+		"zero window":   {true, Rule{Name: "c", Requests: 1, Window: 0}},
+	} {
+		handler := newTestLimiter(tc.enabled).Middleware(tc.rule)(next)
+		for i := 0; i < 3; i++ {
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+			if res.Code != http.StatusOK || res.Header().Get("RateLimit-Limit") != "" {
+				t.Fatalf("%s: request %d limited: %d %v", name, i+1, res.Code, res.Header())
+			}
+		}
+	}
+}
+
+// failingStore stands in for an unreachable Redis.
+type failingStore struct{ cache.Store }
+
+func (failingStore) Incr(context.Context, string, time.Duration) (int64, time.Duration, error) {
+	return 0, 0, errors.New("connection refused")
+}
+
+func TestMiddlewareFailsOpen(t *testing.T) {
+	limiter := NewLimiter(failingStore{}, true, slog.New(slog.DiscardHandler))
+	called := 0
+	handler := limiter.Middleware(Rule{Name: "api", Requests: 1, Window: time.Minute})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called++ }))
+
+	for i := 0; i < 3; i++ {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+		if res.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i+1, res.Code)
+		}
+	}
+	if called != 3 {
+		t.Fatalf("handler ran %d times, want 3", called)
+	}
+}
@@ -0,0 +1,92 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is returned when a push endpoint resolves to a
+// loopback, private, link-local or otherwise internal address.
+var ErrNonPublicAddress = errors.New("push endpoint is not a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), internal
+// although netip does not call it private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// ValidateSubscription checks a browser PushSubscription before it is
+// stored: an https endpoint that is not obviously internal, a P-256 public
+// key and a 16-byte auth secret. Hostnames are checked again when the
+// delivery client dials them, after DNS resolution.
+func ValidateSubscription(endpoint, p256dh, auth string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("subscription endpoint must be an https URL")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("subscription endpoint must be a public host")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublic(addr) {
+		return errors.New("subscription endpoint must be a public host")
+	}
+
+	key, err := DecodeBase64URL(p256dh)
+	if err != nil {
+		return errors.New("subscription keys.p256dh must be base64url")
+	}
+	if _, err := ecdh.P256().NewPublicKey(key); err != nil {
+		return errors.New("subscription keys.p256dh must be a P-256 public key")
+	}
+	secret, err := DecodeBase64URL(auth)
+	if err != nil || len(secret) != 16 {
+		return errors.New("subscription keys.auth must be a 16-byte base64url secret")
+	}
+	return nil
+}
+
+// IsPublic reports whether addr is a globally routable unicast address.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// NewHTTPClient returns a client that refuses to connect to non-public
+// addresses. The check runs on the resolved address of every dial, so DNS
+// tricks and redirects cannot reach internal hosts. Proxies from the
+// environment are ignored for the same reason.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !IsPublic(addr) {
+				return ErrNonPublicAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// DecodeBase64URL accepts base64url with or without padding, and the
+// standard alphabet some clients still send.
+func DecodeBase64URL(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,30 @@
+package webpush
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	cases := map[string]bool{
+		"142.250.74.10":   true,
+		"2607:f8b0::200e": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.20":    false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"0.0.0.0":         false,
+		"::ffff:10.0.0.1": false,
+		"224.0.0.1":       false,
+	}
+	for raw, want := range cases {
+		if got := IsPublic(netip.MustParseAddr(raw)); got != want {
+			t.Errorf("%s: got %v, want %v", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package recommendation
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/tashifkhan/bingebeacon/internal/pkg/context"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/httputil"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	profileID, ok := context.ProfileID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	mediaType := r.URL.Query().Get("type")
+
+	recommendations, err := h.svc.GetRecommendations(r.Context(), profileID, mediaType, limit)
+	if errors.Is(err, ErrInvalidType) {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	httputil.JSON(w, http.StatusOK, recommendations)
+}
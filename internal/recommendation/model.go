@@ -0,0 +1,42 @@
+package recommendation
+
+import (
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tashifkhan/bingebeacon/internal/show"
+)
+
+type Recommendation struct {
+	show.ShowResult
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+	// Rated is the title's content rating, kept so cached picks can be
+	// checked against the profile's current limit.
+	Rated string `json:"rated,omitempty"`
+}
+
+// seed is a show the profile has engaged with, used to look for more like it.
+type seed struct {
+	ShowID     uuid.UUID
+	TMDBID     *int
+	MediaType  string
+	Title      string
+	Genres     pq.StringArray `gorm:"type:text[]"`
+	Network    *string
+	IsFavorite bool
+	Tracked    bool
+	Rating     *int
+}
+
+// coTracked is a show other accounts track alongside one of the profile's.
+type coTracked struct {
+	SeedID    uuid.UUID
+	TMDBID    int
+	MediaType string
+	Overlap   int
+}
+
+type seenShow struct {
+	TMDBID    int
+	MediaType string
+}
@@ -0,0 +1,93 @@
+package recommendation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetSeeds merges the profile's tracked shows with its average rating per
+// show from watch history.
+func (r *Repository) GetSeeds(ctx context.Context, profileID uuid.UUID) ([]seed, error) {
+	var tracked []seed
+	err := r.db.WithContext(ctx).Table("user_tracked_shows uts").
+		Select("s.id AS show_id, s.tmdb_id, s.media_type, s.title, s.genres, s.network, uts.is_favorite, TRUE AS tracked").
+		Joins("INNER JOIN shows s ON s.id = uts.show_id").
+		Where("uts.profile_id = ?", profileID).
+		Scan(&tracked).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var rated []seed
+	err = r.db.WithContext(ctx).Table("watch_history_entries h").
+		Select("s.id AS show_id, s.tmdb_id, s.media_type, s.title, s.genres, s.network, ROUND(AVG(h.rating))::int AS rating").
+		Joins("INNER JOIN shows s ON s.id = h.show_id").
+		Where("h.profile_id = ? AND h.rating IS NOT NULL", profileID).
+		Group("s.id").
+		Scan(&rated).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byShow := make(map[uuid.UUID]int, len(tracked))
+	for i, t := range tracked {
+		byShow[t.ShowID] = i
+	}
+	for _, r := range rated {
+		if i, ok := byShow[r.ShowID]; ok {
+			tracked[i].Rating = r.Rating
+			continue
+		}
+		tracked = append(tracked, r)
+	}
+	return tracked, nil
+}
+
+// GetSeen lists titles the profile already tracks, has logged, or has
+// queued, so they are never recommended back.
+func (r *Repository) GetSeen(ctx context.Context, profileID uuid.UUID) ([]seenShow, error) {
+	var seen []seenShow
+	err := r.db.WithContext(ctx).Table("shows").
+		Select("tmdb_id, media_type").
+		Where("tmdb_id IS NOT NULL").
+		Where(`id IN (
+			SELECT show_id FROM user_tracked_shows WHERE profile_id = ?
+			UNION SELECT show_id FROM watch_history_entries WHERE profile_id = ?
+			UNION SELECT show_id FROM watchlist_items WHERE profile_id = ?
+		)`, profileID, profileID, profileID).
+		Scan(&seen).Error
+	return seen, err
+}
+
+// GetCoTracked finds shows that other accounts track alongside the profile's
+// tracked shows. Pairs backed by a single account are dropped so one person's
+// list is never exposed through recommendations.
+func (r *Repository) GetCoTracked(ctx context.Context, profileID uuid.UUID, limit int) ([]coTracked, error) {
+	var rows []coTracked
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT mine.show_id AS seed_id, s.tmdb_id, s.media_type,
+			COUNT(DISTINCT candidate.user_id) AS overlap
+		FROM user_tracked_shows mine
+		INNER JOIN user_tracked_shows peer
+			ON peer.show_id = mine.show_id AND peer.user_id <> mine.user_id
+		INNER JOIN user_tracked_shows candidate
+			ON candidate.profile_id = peer.profile_id AND candidate.show_id <> mine.show_id
+		INNER JOIN shows s ON s.id = candidate.show_id AND s.tmdb_id IS NOT NULL
+		WHERE mine.profile_id = ?
+		GROUP BY mine.show_id, s.tmdb_id, s.media_type
+		HAVING COUNT(DISTINCT candidate.user_id) >= 2
+		ORDER BY overlap DESC
+		LIMIT ?
+	`, profileID, limit).Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,447 @@
+package recommendation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/metadata/tmdb"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
+	"github.com/tashifkhan/bingebeacon/internal/show"
+	"github.com/tashifkhan/bingebeacon/internal/user"
+)
+
+const (
+	maxSeeds      = 8
+	maxCoTracked  = 100
+	maxCached     = 50
+	defaultLimit  = 20
+	cacheDuration = time.Hour
+
+	recommendedWeight = 1.0
+	similarWeight     = 0.6
+	coTrackedWeight   = 0.5
+	genreWeight       = 0.3
+	networkWeight     = 0.2
+)
+
+// ErrInvalidType is returned for a type filter other than tv or movie.
+var ErrInvalidType = errors.New("type must be tv or movie")
+
+type Service struct {
+	repo       *Repository
+	showSvc    *show.Service
+	showRepo   *show.Repository
+	tmdbClient *tmdb.Client
+	userSvc    *user.Service
+	cache      cache.Store
+}
+
+func NewService(repo *Repository, showSvc *show.Service, showRepo *show.Repository, tmdbClient *tmdb.Client, userSvc *user.Service, store cache.Store) *Service {
+	return &Service{
+		repo:       repo,
+		showSvc:    showSvc,
+		showRepo:   showRepo,
+		tmdbClient: tmdbClient,
+		userSvc:    userSvc,
+		cache:      store,
+	}
+}
+
+// GetRecommendations returns personalised picks for a profile. The scored
+// list is cached for an hour; titles the profile has tracked, logged or
+// queued since then are filtered out on every read.
+func (s *Service) GetRecommendations(ctx context.Context, profileID uuid.UUID, mediaType string, limit int) ([]Recommendation, error) {
+	ctx, span := tracing.Start(ctx, "recommendation.GetRecommendations")
+	defer span.End()
+
+	if mediaType != "" && mediaType != "tv" && mediaType != "movie" {
+		return nil, ErrInvalidType
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxCached {
+		limit = maxCached
+	}
+
+	key := fmt.Sprintf("recommendations:%s", profileID)
+	all, err := cache.GetOrSet(ctx, s.cache, key, cacheDuration, func(ctx context.Context) ([]Recommendation, error) {
+		return s.build(ctx, profileID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	seen, err := s.seenKeys(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	// The list was rated when it was built; a limit lowered since then
+	// applies straight away.
+	ratingLimit := s.userSvc.ContentRatingLimit(ctx, profileID)
+
+	results := make([]Recommendation, 0, limit)
+	for _, rec := range all {
+		if mediaType != "" && rec.MediaType != mediaType {
+			continue
+		}
+		if seen[titleKey(rec.MediaType, rec.TMDBID)] {
+			continue
+		}
+		if !user.RatingAllowed(ratingLimit, rec.Rated) {
+			continue
+		}
+		results = append(results, rec)
+		if len(results) == limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+type candidate struct {
+	mediaType string
+	tmdbID    int
+	item      *tmdb.SearchResult
+	score     float64
+	reasons   map[string]float64
+}
+
+func (c *candidate) add(weight float64, reason string) {
+	c.score += weight
+	c.reasons[reason] += weight
+}
+
+func (s *Service) build(ctx context.Context, profileID uuid.UUID) ([]Recommendation, error) {
+	seeds, err := s.repo.GetSeeds(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	weighted := weighSeeds(seeds)
+	if len(weighted) == 0 {
+		return s.coldStart(ctx, profileID)
+	}
+	seen, err := s.seenKeys(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]*candidate)
+	upsert := func(mediaType string, tmdbID int) *candidate {
+		key := titleKey(mediaType, tmdbID)
+		if seen[key] {
+			return nil
+		}
+		c, ok := candidates[key]
+		if !ok {
+			c = &candidate{mediaType: mediaType, tmdbID: tmdbID, reasons: make(map[string]float64)}
+			candidates[key] = c
+		}
+		return c
+	}
+
+	// TMDB recommended and similar lists for the strongest seeds. A failed
+	// lookup only costs that seed's contribution.
+	for i, ws := range weighted {
+		if i == maxSeeds {
+			break
+		}
+		for _, source := range []struct {
+			fetch  func(context.Context, string, int) (*tmdb.SearchResponse, error)
+			weight float64
+		}{
+			{s.tmdbClient.GetRecommendations, recommendedWeight},
+			{s.tmdbClient.GetSimilar, similarWeight},
+		} {
+			resp, err := source.fetch(ctx, ws.MediaType, *ws.TMDBID)
+			if err != nil {
+				continue
+			}
+			for rank := range resp.Results {
+				item := resp.Results[rank]
+				c := upsert(item.MediaType, item.ID)
+				if c == nil {
+					continue
+				}
+				if c.item == nil {
+					c.item = &item
+				}
+				decay := 1 - float64(rank)/float64(len(resp.Results)+1)
+				c.add(ws.weight*source.weight*decay, ws.reason)
+			}
+		}
+	}
+
+	// Local co-tracking: accounts that track a seed also track these.
+	bySeed := make(map[uuid.UUID]weightedSeed, len(weighted))
+	for _, ws := range weighted {
+		bySeed[ws.ShowID] = ws
+	}
+	coTracked, err := s.repo.GetCoTracked(ctx, profileID, maxCoTracked)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range coTracked {
+		ws, ok := bySeed[row.SeedID]
+		if !ok {
+			continue
+		}
+		if c := upsert(row.MediaType, row.TMDBID); c != nil {
+			c.add(ws.weight*coTrackedWeight*math.Log2(float64(row.Overlap)),
+				fmt.Sprintf("people who track %s also track this", ws.Title))
+		}
+	}
+
+	ranked := make([]*candidate, 0, len(candidates))
+	for _, c := range candidates {
+		ranked = append(ranked, c)
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	if len(ranked) > 2*maxCached {
+		ranked = ranked[:2*maxCached]
+	}
+
+	local, err := s.resolveShows(ctx, ranked)
+	if err != nil {
+		return nil, err
+	}
+	genres, networks := tasteProfile(weighted)
+
+	results := make([]Recommendation, 0, len(ranked))
+	for _, c := range ranked {
+		record, ok := local[titleKey(c.mediaType, c.tmdbID)]
+		if !ok {
+			continue
+		}
+		if s.userSvc.CheckContentRating(ctx, profileID, record.Rated()) != nil {
+			continue
+		}
+		applyTaste(c, record, genres, networks)
+		results = append(results, Recommendation{
+			ShowResult: showResult(record),
+			Score:      math.Round(c.score*100) / 100,
+			Reasons:    topReasons(c.reasons, 2),
+			Rated:      record.Rated(),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > maxCached {
+		results = results[:maxCached]
+	}
+	return results, nil
+}
+
+// resolveShows stores TMDB-only candidates locally and loads every
+// candidate's show record, keyed by titleKey.
+func (s *Service) resolveShows(ctx context.Context, ranked []*candidate) (map[string]*show.Show, error) {
+	var items []tmdb.SearchResult
+	ids := map[string][]int{}
+	for _, c := range ranked {
+		if c.item != nil {
+			items = append(items, *c.item)
+		}
+		ids[c.mediaType] = append(ids[c.mediaType], c.tmdbID)
+	}
+	if _, err := s.showSvc.SaveDiscoveryResults(ctx, items); err != nil {
+		return nil, err
+	}
+
+	local := make(map[string]*show.Show)
+	for mediaType, tmdbIDs := range ids {
+		shows, err := s.showRepo.FindByTMDBIDs(ctx, mediaType, tmdbIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range shows {
+			if shows[i].TMDBID != nil {
+				local[titleKey(mediaType, *shows[i].TMDBID)] = &shows[i]
+			}
+		}
+	}
+	return local, nil
+}
+
+// coldStart falls back to this week's trending titles for profiles with no
+// history or tracking yet, under the same content rating limit as build.
+func (s *Service) coldStart(ctx context.Context, profileID uuid.UUID) ([]Recommendation, error) {
+	trending, err := s.showSvc.GetTrending(ctx, "all", "week")
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string][]int{}
+	for _, item := range trending {
+		ids[item.MediaType] = append(ids[item.MediaType], item.TMDBID)
+	}
+	local := make(map[string]*show.Show)
+	for mediaType, tmdbIDs := range ids {
+		shows, err := s.showRepo.FindByTMDBIDs(ctx, mediaType, tmdbIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range shows {
+			if shows[i].TMDBID != nil {
+				local[titleKey(mediaType, *shows[i].TMDBID)] = &shows[i]
+			}
+		}
+	}
+	return trendingPicks(trending, local, func(rated string) bool {
+		return s.userSvc.CheckContentRating(ctx, profileID, rated) == nil
+	}), nil
+}
+
+// trendingPicks keeps the trending titles whose stored rating the profile
+// may see. Titles without a local record cannot be checked and are dropped,
+// as in build.
+func trendingPicks(trending []show.ShowResult, local map[string]*show.Show, allowed func(rated string) bool) []Recommendation {
+	results := make([]Recommendation, 0, len(trending))
+	for _, item := range trending {
+		record, ok := local[titleKey(item.MediaType, item.TMDBID)]
+		if !ok || !allowed(record.Rated()) {
+			continue
+		}
+		results = append(results, Recommendation{
+			ShowResult: item,
+			Reasons:    []string{"trending this week"},
+			Rated:      record.Rated(),
+		})
+	}
+	return results
+}
+
+func (s *Service) seenKeys(ctx context.Context, profileID uuid.UUID) (map[string]bool, error) {
+	seen, err := s.repo.GetSeen(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(seen))
+	for _, item := range seen {
+		keys[titleKey(item.MediaType, item.TMDBID)] = true
+	}
+	return keys, nil
+}
+
+type weightedSeed struct {
+	seed
+	weight float64
+	reason string
+}
+
+// weighSeeds scores each seed by how strongly it signals taste: high ratings
+// count most, then favorites, then plain tracking. Middling and low ratings
+// are not used as seeds.
+func weighSeeds(seeds []seed) []weightedSeed {
+	weighted := make([]weightedSeed, 0, len(seeds))
+	for _, sd := range seeds {
+		if sd.TMDBID == nil {
+			continue
+		}
+		ws := weightedSeed{seed: sd}
+		if sd.Tracked {
+			ws.weight, ws.reason = 1.0, fmt.Sprintf("because you track %s", sd.Title)
+		}
+		if sd.IsFavorite {
+			ws.weight, ws.reason = 1.5, fmt.Sprintf("because %s is one of your favorites", sd.Title)
+		}
+		if sd.Rating != nil {
+			switch {
+			case *sd.Rating >= 7 && float64(*sd.Rating)/5 > ws.weight:
+				ws.weight = float64(*sd.Rating) / 5
+				ws.reason = fmt.Sprintf("because you rated %s %d/10", sd.Title, *sd.Rating)
+			case *sd.Rating <= 4:
+				ws.weight = 0
+			}
+		}
+		if ws.weight > 0 {
+			weighted = append(weighted, ws)
+		}
+	}
+	sort.SliceStable(weighted, func(i, j int) bool { return weighted[i].weight > weighted[j].weight })
+	return weighted
+}
+
+// tasteProfile sums seed weights per genre and network, normalised so the
+// strongest genre scores 1.
+func tasteProfile(seeds []weightedSeed) (map[string]float64, map[string]float64) {
+	genres := make(map[string]float64)
+	networks := make(map[string]float64)
+	for _, ws := range seeds {
+		for _, genre := range ws.Genres {
+			genres[genre] += ws.weight
+		}
+		if ws.Network != nil && *ws.Network != "" {
+			networks[*ws.Network] += ws.weight
+		}
+	}
+	normalise(genres)
+	normalise(networks)
+	return genres, networks
+}
+
+func normalise(weights map[string]float64) {
+	var top float64
+	for _, w := range weights {
+		top = math.Max(top, w)
+	}
+	if top == 0 {
+		return
+	}
+	for k, w := range weights {
+		weights[k] = w / top
+	}
+}
+
+func applyTaste(c *candidate, record *show.Show, genres, networks map[string]float64) {
+	var bestGenre string
+	var bestWeight float64
+	for _, genre := range record.Genres {
+		if w := genres[genre]; w > bestWeight {
+			bestGenre, bestWeight = genre, w
+		}
+	}
+	if bestWeight > 0 {
+		c.add(genreWeight*bestWeight, fmt.Sprintf("matches your taste for %s", bestGenre))
+	}
+	if record.Network != nil {
+		if w := networks[*record.Network]; w > 0 {
+			c.add(networkWeight*w, fmt.Sprintf("on %s, which you watch often", *record.Network))
+		}
+	}
+}
+
+func topReasons(reasons map[string]float64, n int) []string {
+	ordered := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		ordered = append(ordered, reason)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if reasons[ordered[i]] != reasons[ordered[j]] {
+			return reasons[ordered[i]] > reasons[ordered[j]]
+		}
+		return ordered[i] < ordered[j]
+	})
+	if len(ordered) > n {
+		ordered = ordered[:n]
+	}
+	return ordered
+}
+
+func showResult(record *show.Show) show.ShowResult {
+	return show.ShowResult{
+		ID:        &record.ID,
+		TMDBID:    *record.TMDBID,
+		Title:     record.Title,
+		Overview:  show.SafeString(record.Overview),
+		PosterURL: show.SafeString(record.PosterURL),
+		MediaType: record.MediaType,
+		Year:      show.ExtractYear(record.PremiereDate),
+	}
+}
+
+func titleKey(mediaType string, tmdbID int) string {
+	return fmt.Sprintf("%s:%d", mediaType, tmdbID)
+}
@@ -0,0 +1,61 @@
+package recommendation
+
+import (
+	"testing"
+
+	"github.com/tashifkhan/bingebeacon/internal/show"
+	"github.com/tashifkhan/bingebeacon/internal/user"
+	"gorm.io/datatypes"
+)
+
+func TestWeighSeedsPrefersHighRatings(t *testing.T) {
+	id := func(n int) *int { return &n }
+	rating := func(n int) *int { return &n }
+
+	weighted := weighSeeds([]seed{
+		{TMDBID: id(1), Title: "Tracked", Tracked: true},
+		{TMDBID: id(2), Title: "Severance", Tracked: true, Rating: rating(9)},
+		{TMDBID: id(3), Title: "Disliked", Tracked: true, Rating: rating(3)},
+		{TMDBID: id(4), Title: "Favorite", Tracked: true, IsFavorite: true},
+		{Title: "No TMDB ID", Tracked: true},
+	})
+
+	if len(weighted) != 3 {
+		t.Fatalf("expected 3 seeds, got %d", len(weighted))
+	}
+	if weighted[0].reason != "because you rated Severance 9/10" {
+		t.Fatalf("unexpected top reason %q", weighted[0].reason)
+	}
+	if weighted[1].Title != "Favorite" || weighted[2].Title != "Tracked" {
+		t.Fatalf("unexpected order: %s, %s", weighted[1].Title, weighted[2].Title)
+	}
+}
+
+func TestTrendingPicksHonorsContentRating(t *testing.T) {
+	record := func(tmdbID int, rated string) *show.Show {
+		return &show.Show{TMDBID: &tmdbID, Ratings: datatypes.JSON(`{"rated":"` + rated + `"}`)}
+	}
+	trending := []show.ShowResult{
+		{TMDBID: 1, Title: "Bluey", MediaType: "tv"},
+		{TMDBID: 2, Title: "The Boys", MediaType: "tv"},
+		{TMDBID: 3, Title: "Oppenheimer", MediaType: "movie"},
+		{TMDBID: 4, Title: "Not stored", MediaType: "movie"},
+	}
+	local := map[string]*show.Show{
+		titleKey("tv", 1):    record(1, "TV-Y"),
+		titleKey("tv", 2):    record(2, "TV-MA"),
+		titleKey("movie", 3): record(3, "R"),
+	}
+
+	// A kids profile capped at TV-Y7 with no history yet.
+	picks := trendingPicks(trending, local, func(rated string) bool {
+		return user.RatingAllowed("TV-Y7", rated)
+	})
+	if len(picks) != 1 || picks[0].Title != "Bluey" {
+		t.Fatalf("unexpected picks %+v", picks)
+	}
+	// The rating travels with the cached pick so reads can recheck it.
+	if picks[0].Rated != "TV-Y" {
+		t.Fatalf("pick rated %q, want TV-Y", picks[0].Rated)
+	}
+}
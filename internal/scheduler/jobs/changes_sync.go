@@ -15,7 +15,7 @@ func NewChangesSyncJob(syncer *metadata.Syncer, logger *slog.Logger) scheduler.J
 		Interval: time.Hour,
 		Run: func(ctx context.Context) error {
 			now := time.Now().UTC()
-			logger.Info("Polling TMDB changes", "from", now.Add(-time.Hour), "to", now)
+			logger.InfoContext(ctx, "Polling TMDB changes", "from", now.Add(-time.Hour), "to", now)
 			return syncer.SyncChanged(ctx, now.Add(-time.Hour), now)
 		},
 	}
@@ -22,12 +22,12 @@ func NewEpisodeSyncJob(
 			// 1. Get tracked show IDs
 			// Ideally we prioritize "returning" shows.
 			// For Phase 1/2, just sync all tracked shows.
-			shows, err := showRepo.GetTrackedForSync(100)
+			shows, err := showRepo.GetTrackedForSync(ctx, 100)
 			if err != nil {
 				return err
 			}
 
-			logger.Info("Starting prioritized sync", "shows_count", len(shows))
+			logger.InfoContext(ctx, "Starting prioritized sync", "shows_count", len(shows))
 
 			for _, trackedShow := range shows {
 				// Check context cancellation
@@ -36,12 +36,16 @@ func NewEpisodeSyncJob(
 				}
 
 				if err := syncer.SyncShow(ctx, trackedShow.ID); err != nil {
-					logger.Error("Failed to sync show", "show_id", trackedShow.ID, "error", err)
+					logger.ErrorContext(ctx, "Failed to sync show", "show_id", trackedShow.ID, "error", err)
 					// Continue to next show
 				}
 
 				// Rate limit kindness
-				time.Sleep(200 * time.Millisecond)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(200 * time.Millisecond):
+				}
 			}
 			return nil
 		},
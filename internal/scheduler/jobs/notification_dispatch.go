@@ -6,7 +6,10 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/tashifkhan/bingebeacon/internal/notification"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/email"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/metrics"
 	"github.com/tashifkhan/bingebeacon/internal/scheduler"
 	"github.com/tashifkhan/bingebeacon/internal/user"
 )
@@ -15,14 +18,27 @@ func NewNotificationDispatchJob(
 	notifRepo *notification.Repository,
 	userRepo *user.Repository,
 	fcm *notification.FCMClient,
+	webPush *notification.WebPushClient,
+	mailer email.Sender,
+	renderer *notification.Renderer,
+	requireVerifiedEmail bool,
+	coalesceWindow time.Duration,
 	logger *slog.Logger,
 ) scheduler.Job {
 	return scheduler.Job{
 		Name:     "notification_dispatch",
 		Interval: 1 * time.Minute,
 		Run: func(ctx context.Context) error {
-			// 1. Get pending notifications due
-			notifs, err := notifRepo.ClaimPendingDue(500)
+			if depth, err := notifRepo.CountPendingDue(ctx); err == nil {
+				metrics.SetNotificationQueueDepth(depth)
+			}
+			if counts, err := notifRepo.CountDeadLetters(ctx); err == nil {
+				metrics.SetNotificationDeadLetters(notification.ErrorClasses, counts)
+			}
+
+			// 1. Get pending notifications due, along with releases of the
+			// same shows due shortly after, and coalesce each show's batch
+			notifs, err := notifRepo.ClaimPendingDue(ctx, 500, coalesceWindow)
 			if err != nil {
 				return err
 			}
@@ -30,39 +46,66 @@ func NewNotificationDispatchJob(
 			if len(notifs) == 0 {
 				return nil
 			}
+			notifs = coalesce(ctx, notifRepo, notifs, logger)
 
-			logger.Info("Dispatching notifications", "count", len(notifs))
+			logger.InfoContext(ctx, "Dispatching notifications", "count", len(notifs))
 
+			accounts := make(map[uuid.UUID]*user.User)
 			for _, n := range notifs {
 				if ctx.Err() != nil {
 					return ctx.Err()
 				}
 
-				// In-app notifications remain fully functional without Firebase.
-				if fcm == nil {
-					if err := notifRepo.MarkSent(n.ID); err != nil {
-						logger.Error("Failed to mark in-app notification ready", "notification_id", n.ID, "error", err)
+				// Each channel is rendered in the account's language and time
+				// zone; the inbox keeps the in-app rendering.
+				account := loadAccount(ctx, userRepo, accounts, n.UserID, logger)
+				lang, loc := recipientLocale(account)
+				inApp := renderer.Render(&n, notification.ChannelInApp, lang, loc)
+				if inApp.Title != n.Title || inApp.Body != n.Body {
+					if err := notifRepo.SetContent(ctx, n.ID, inApp.Title, inApp.Body); err != nil {
+						logger.ErrorContext(ctx, "Failed to save rendered notification", "notification_id", n.ID, "error", err)
 					}
+				}
+
+				// Email is a best-effort copy sent on the first attempt only:
+				// failures are logged, never retried, and push retries,
+				// snoozed reminders and replayed dead letters do not resend it.
+				details := notification.ParseDetails(&n)
+				if mailer != nil && n.RetryCount == 0 && !details.Reminder && !details.Replayed && account != nil && account.CanReceiveEmail(requireVerifiedEmail) {
+					sendNotificationEmail(ctx, mailer, account, n.ID, renderer.Render(&n, notification.ChannelEmail, lang, loc), logger)
+				}
+
+				// In-app notifications remain fully functional without Firebase
+				// or Web Push.
+				if fcm == nil && webPush == nil {
+					if err := notifRepo.MarkSent(ctx, n.ID); err != nil {
+						logger.ErrorContext(ctx, "Failed to mark in-app notification ready", "notification_id", n.ID, "error", err)
+					}
+					metrics.NotificationDelivery("in_app", "sent")
 					continue
 				}
 
-				// 2. Get user devices
-				devices, err := userRepo.GetDevices(n.UserID)
+				// 2. Get the devices routed to this notification's profile
+				devices, err := userRepo.GetDevicesForProfile(ctx, n.UserID, n.ProfileID)
 				if err != nil {
-					logger.Error("Failed to get user devices", "user_id", n.UserID, "error", err)
-					_ = notifRepo.RetryOrFail(n.ID, err, 3)
+					logger.ErrorContext(ctx, "Failed to get user devices", "user_id", n.UserID, "error", err)
+					metrics.NotificationDelivery("push", "error")
+					retryOrFail(ctx, notifRepo, n.ID, &notification.DeliveryError{Class: notification.ErrorClassInternal, Err: err}, logger)
 					continue
 				}
 
 				if len(devices) == 0 {
-					logger.Info("No devices for user", "user_id", n.UserID)
+					metrics.NotificationDelivery("push", "no_devices")
+					logger.InfoContext(ctx, "No devices for user", "user_id", n.UserID)
 					// Mark as sent (or failed?) so we don't retry forever.
 					// Let's mark as sent effectively (or skipped)
-					notifRepo.MarkSent(n.ID) // Treat as delivered to inbox
+					notifRepo.MarkSent(ctx, n.ID) // Treat as delivered to inbox
 					continue
 				}
 
-				// 3. Send via FCM
+				// 3. Send via FCM or Web Push; devices whose transport isn't
+				// configured are skipped
+				push := renderer.Render(&n, notification.ChannelPush, lang, loc)
 				sentCount := 0
 				activeCount := 0
 				var lastSendErr error
@@ -70,31 +113,138 @@ func NewNotificationDispatchJob(
 					if !d.IsActive {
 						continue
 					}
+					channel, sent, err := sendToDevice(ctx, fcm, webPush, d, push)
+					if !sent && err == nil {
+						continue
+					}
 					activeCount++
 
-					// Should verify token format etc.
-					err := fcm.SendToDevice(ctx, d.DeviceToken, n.Title, n.Body, nil)
 					if err != nil {
-						lastSendErr = err
-						logger.Error("FCM send failed", "device_id", d.ID, "error", err)
+						// A transient failure on any device outranks dead
+						// tokens, which are deactivated and won't be retried.
+						if lastSendErr == nil || !notification.IsUnregisteredToken(err) {
+							lastSendErr = err
+						}
+						logger.ErrorContext(ctx, "Push send failed", "device_id", d.ID, "platform", d.Platform, "error", err)
 						if notification.IsUnregisteredToken(err) {
-							_ = userRepo.DeactivateDevice(d.ID)
+							_ = userRepo.DeactivateDevice(ctx, d.ID)
+							metrics.NotificationDelivery(channel, "unregistered")
+						} else {
+							metrics.NotificationDelivery(channel, "failed")
 						}
 					} else {
 						sentCount++
+						metrics.NotificationDelivery(channel, "sent")
 					}
 				}
 
 				if activeCount == 0 || sentCount > 0 {
-					notifRepo.MarkSent(n.ID)
+					notifRepo.MarkSent(ctx, n.ID)
 				} else {
 					if lastSendErr == nil {
 						lastSendErr = fmt.Errorf("all push deliveries failed")
 					}
-					_ = notifRepo.RetryOrFail(n.ID, lastSendErr, 3)
+					retryOrFail(ctx, notifRepo, n.ID, lastSendErr, logger)
 				}
 			}
 			return nil
 		},
 	}
 }
+
+// sendToDevice pushes to one device over its platform's transport and
+// returns the metrics channel. sent is false, with no error, when that
+// transport isn't configured.
+func sendToDevice(
+	ctx context.Context,
+	fcm *notification.FCMClient,
+	webPush *notification.WebPushClient,
+	d user.UserDevice,
+	content notification.Content,
+) (channel string, sent bool, err error) {
+	if d.Platform == user.PlatformWebPush {
+		if webPush == nil || d.PushP256DH == nil || d.PushAuth == nil {
+			return "web_push", false, nil
+		}
+		sub := notification.WebPushSubscription{Endpoint: d.DeviceToken, P256DH: *d.PushP256DH, Auth: *d.PushAuth}
+		err := webPush.Send(ctx, sub, content)
+		return "web_push", err == nil, err
+	}
+	if fcm == nil {
+		return "push", false, nil
+	}
+	err = fcm.SendToDevice(ctx, d.DeviceToken, content)
+	return "push", err == nil, err
+}
+
+// coalesce replaces each batch of one show's releases for a profile with a
+// single grouped notification. A batch that can't be grouped is sent as is.
+func coalesce(ctx context.Context, notifRepo *notification.Repository, notifs []notification.Notification, logger *slog.Logger) []notification.Notification {
+	singles, groups := notification.Coalesce(notifs)
+	for _, members := range groups {
+		group, err := notifRepo.CreateGroup(ctx, members)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to coalesce notifications", "profile_id", members[0].ProfileID, "count", len(members), "error", err)
+			singles = append(singles, members...)
+			continue
+		}
+		singles = append(singles, *group)
+	}
+	return singles
+}
+
+func retryOrFail(ctx context.Context, notifRepo *notification.Repository, id uuid.UUID, err error, logger *slog.Logger) {
+	dead, updateErr := notifRepo.RetryOrFail(ctx, id, err)
+	if updateErr != nil {
+		logger.ErrorContext(ctx, "Failed to record notification failure", "notification_id", id, "error", updateErr)
+		return
+	}
+	if dead {
+		metrics.NotificationDelivery("push", "dead_lettered")
+		logger.WarnContext(ctx, "Notification dead-lettered", "notification_id", id, "error_class", notification.Classify(err))
+	}
+}
+
+// loadAccount returns the recipient's account, looking each one up once per
+// run. It returns nil when the lookup fails.
+func loadAccount(ctx context.Context, userRepo *user.Repository, accounts map[uuid.UUID]*user.User, userID uuid.UUID, logger *slog.Logger) *user.User {
+	account, ok := accounts[userID]
+	if !ok {
+		found, err := userRepo.FindByID(ctx, userID)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to load notification recipient", "user_id", userID, "error", err)
+		}
+		account = found
+		accounts[userID] = account
+	}
+	return account
+}
+
+// recipientLocale falls back to English and UTC when the account is
+// unknown or its time zone no longer loads.
+func recipientLocale(account *user.User) (string, *time.Location) {
+	if account == nil {
+		return "", time.UTC
+	}
+	loc, err := time.LoadLocation(account.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return account.Language, loc
+}
+
+func sendNotificationEmail(
+	ctx context.Context,
+	mailer email.Sender,
+	account *user.User,
+	id uuid.UUID,
+	content notification.Content,
+	logger *slog.Logger,
+) {
+	if err := mailer.Send(ctx, email.Message{To: account.Email, Subject: content.Title, Body: content.Body}); err != nil {
+		logger.ErrorContext(ctx, "Notification email failed", "notification_id", id, "error", err)
+		metrics.NotificationDelivery("email", "failed")
+		return
+	}
+	metrics.NotificationDelivery("email", "sent")
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/tashifkhan/bingebeacon/internal/auth"
 	"github.com/tashifkhan/bingebeacon/internal/metadata"
 	"github.com/tashifkhan/bingebeacon/internal/notification"
 	"github.com/tashifkhan/bingebeacon/internal/scheduler"
@@ -15,6 +16,8 @@ func NewStaleCleanupJob(
 	notifRepo *notification.Repository,
 	showRepo *show.Repository,
 	syncRepo *metadata.Repository,
+	authRepo *auth.Repository,
+	jobRepo *scheduler.Repository,
 	logger *slog.Logger,
 ) scheduler.Job {
 	return scheduler.Job{
@@ -25,23 +28,34 @@ func NewStaleCleanupJob(
 			// Cleanup old read notifications > 90 days
 			cutoff := time.Now().Add(-90 * 24 * time.Hour)
 
-			notificationsDeleted, err := notifRepo.DeleteOldRead(cutoff)
+			notificationsDeleted, err := notifRepo.DeleteOldRead(ctx, cutoff)
 			if err != nil {
 				return err
 			}
-			prioritiesReset, err := showRepo.ResetUntrackedSyncPriorities(time.Now().Add(-30 * 24 * time.Hour))
+			prioritiesReset, err := showRepo.ResetUntrackedSyncPriorities(ctx, time.Now().Add(-30*24*time.Hour))
 			if err != nil {
 				return err
 			}
-			logsDeleted, err := syncRepo.DeleteOlderThan(time.Now().Add(-30 * 24 * time.Hour))
+			logsDeleted, err := syncRepo.DeleteOlderThan(ctx, time.Now().Add(-30*24*time.Hour))
 			if err != nil {
 				return err
 			}
 
-			logger.Info("Cleaned up stale data",
+			sessionsDeleted, err := authRepo.DeleteExpiredSessions(ctx, time.Now().Add(-7*24*time.Hour))
+			if err != nil {
+				return err
+			}
+			jobRunsDeleted, err := jobRepo.DeleteRunsOlderThan(ctx, time.Now().Add(-30*24*time.Hour))
+			if err != nil {
+				return err
+			}
+
+			logger.InfoContext(ctx, "Cleaned up stale data",
 				"notifications_deleted", notificationsDeleted,
 				"show_priorities_reset", prioritiesReset,
 				"sync_logs_deleted", logsDeleted,
+				"sessions_deleted", sessionsDeleted,
+				"job_runs_deleted", jobRunsDeleted,
 			)
 			return nil
 		},
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+
+	RunRunning   = "running"
+	RunSuccess   = "success"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+)
+
+// JobRun is one persisted execution of a job.
+type JobRun struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	JobName      string     `json:"job_name"`
+	Trigger      string     `json:"trigger"`
+	TriggeredBy  *uuid.UUID `gorm:"type:uuid" json:"triggered_by,omitempty"`
+	Status       string     `json:"status"`
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+func (JobRun) TableName() string { return "scheduler_job_runs" }
+
+// JobState holds the settings an admin can change at runtime.
+type JobState struct {
+	JobName   string     `gorm:"primaryKey"`
+	Paused    bool       `gorm:"not null"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid"`
+	UpdatedAt time.Time
+}
+
+func (JobState) TableName() string { return "scheduler_jobs" }
+
+// JobStatus describes a registered job for the admin API. Running and
+// RunningSince reflect this replica only.
+type JobStatus struct {
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"`
+	Paused       bool       `json:"paused"`
+	Running      bool       `json:"running"`
+	RunningSince *time.Time `json:"running_since,omitempty"`
+	NextRunAt    *time.Time `json:"next_run_at,omitempty"`
+	LastRun      *JobRun    `json:"last_run,omitempty"`
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository struct{ db *gorm.DB }
+
+func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+
+func (r *Repository) StartRun(ctx context.Context, run *JobRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *Repository) FinishRun(ctx context.Context, id uuid.UUID, status string, runErr error) error {
+	updates := map[string]interface{}{"status": status, "finished_at": time.Now()}
+	if runErr != nil {
+		updates["error_message"] = runErr.Error()
+	}
+	return r.db.WithContext(ctx).Model(&JobRun{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Repository) ListRuns(ctx context.Context, jobName string, limit int) ([]JobRun, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	var runs []JobRun
+	err := r.db.WithContext(ctx).Where("job_name = ?", jobName).
+		Order("started_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// LastRuns returns the most recent run of each job.
+func (r *Repository) LastRuns(ctx context.Context) (map[string]JobRun, error) {
+	var runs []JobRun
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (job_name) * FROM scheduler_job_runs
+		ORDER BY job_name, started_at DESC
+	`).Scan(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+	byJob := make(map[string]JobRun, len(runs))
+	for _, run := range runs {
+		byJob[run.JobName] = run
+	}
+	return byJob, nil
+}
+
+func (r *Repository) DeleteRunsOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("started_at < ?", cutoff).Delete(&JobRun{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *Repository) IsPaused(ctx context.Context, jobName string) (bool, error) {
+	var state JobState
+	err := r.db.WithContext(ctx).First(&state, "job_name = ?", jobName).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return state.Paused, err
+}
+
+func (r *Repository) PausedJobs(ctx context.Context) (map[string]bool, error) {
+	var states []JobState
+	if err := r.db.WithContext(ctx).Where("paused").Find(&states).Error; err != nil {
+		return nil, err
+	}
+	paused := make(map[string]bool, len(states))
+	for _, state := range states {
+		paused[state.JobName] = true
+	}
+	return paused, nil
+}
+
+func (r *Repository) SetPaused(ctx context.Context, jobName string, paused bool, by *uuid.UUID) error {
+	state := &JobState{JobName: jobName, Paused: paused, UpdatedBy: by, UpdatedAt: time.Now()}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"paused", "updated_by", "updated_at"}),
+	}).Create(state).Error
+}
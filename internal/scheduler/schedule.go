@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule decides when a job runs next.
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// ParseSchedule accepts a five-field cron expression ("0 */6 * * *") or a
+// descriptor such as "@hourly" or "@every 30m". Times use the server's time
+// zone unless the spec starts with CRON_TZ=<zone>.
+func ParseSchedule(spec string) (Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return schedule, nil
+}
+
+// every runs a job at a fixed interval, measured from the end of the
+// previous run.
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2025, 3, 10, 4, 15, 0, 0, time.Local)
+
+	schedule, err := ParseSchedule("0 */6 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := schedule.Next(from), time.Date(2025, 3, 10, 6, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Fatalf("cron next = %v, want %v", got, want)
+	}
+
+	schedule, err = ParseSchedule("@every 30m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := schedule.Next(from), from.Add(30*time.Minute); !got.Equal(want) {
+		t.Fatalf("@every next = %v, want %v", got, want)
+	}
+
+	if _, err := ParseSchedule("every six hours"); err == nil {
+		t.Fatal("expected an invalid spec to be rejected")
+	}
+	if _, err := NewScheduler(nil, map[string]string{"episode_sync": "61 * * * *"}, slog.Default()); err == nil {
+		t.Fatal("expected NewScheduler to reject an invalid schedule")
+	}
+}
+
+func TestInvokeReportsPanicsAndCancellation(t *testing.T) {
+	s := &Scheduler{}
+
+	err := s.invoke(context.Background(), Job{Run: func(context.Context) error { panic("boom") }})
+	if err == nil || err.Error() != "panic: boom" {
+		t.Fatalf("panic error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = s.invoke(ctx, Job{Run: func(context.Context) error { return nil }})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled run error = %v, want context.Canceled", err)
+	}
+}
@@ -2,11 +2,31 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/metrics"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// jobTimeout bounds a single run. Stop and Cancel end runs sooner.
+const jobTimeout = 10 * time.Minute
+
+var (
+	ErrJobNotFound   = errors.New("job not found")
+	ErrJobRunning    = errors.New("job is already running")
+	ErrJobNotRunning = errors.New("job is not running")
 )
 
+// Job is a unit of background work. Interval and InitialDelay are its
+// default schedule; a cron schedule configured for the job replaces both.
 type Job struct {
 	Name         string
 	Interval     time.Duration
@@ -14,87 +34,303 @@ type Job struct {
 	Run          func(ctx context.Context) error
 }
 
+type entry struct {
+	job      Job
+	schedule Schedule
+	spec     string
+	next     time.Time
+	running  *activeRun
+}
+
+type activeRun struct {
+	id        uuid.UUID
+	startedAt time.Time
+	cancel    context.CancelFunc
+}
+
 type Scheduler struct {
-	jobs   []Job
-	logger *slog.Logger
+	repo      *Repository
+	schedules map[string]Schedule
+	specs     map[string]string
+	logger    *slog.Logger
+
+	mu    sync.Mutex
+	jobs  map[string]*entry
+	order []string
+
 	wg     sync.WaitGroup
-	stop   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func NewScheduler(logger *slog.Logger) *Scheduler {
-	return &Scheduler{
-		jobs:   make([]Job, 0),
-		logger: logger,
-		stop:   make(chan struct{}),
+// NewScheduler parses the configured cron schedules, keyed by job name.
+// Empty specs keep the job's default interval.
+func NewScheduler(repo *Repository, schedules map[string]string, logger *slog.Logger) (*Scheduler, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		repo:      repo,
+		schedules: make(map[string]Schedule),
+		specs:     make(map[string]string),
+		logger:    logger,
+		jobs:      make(map[string]*entry),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	for name, spec := range schedules {
+		if spec == "" {
+			continue
+		}
+		schedule, err := ParseSchedule(spec)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("job %s: %w", name, err)
+		}
+		s.schedules[name] = schedule
+		s.specs[name] = spec
 	}
+	return s, nil
 }
 
 func (s *Scheduler) Register(job Job) {
-	s.jobs = append(s.jobs, job)
+	e := &entry{job: job, schedule: every(job.Interval), spec: "@every " + job.Interval.String()}
+	if schedule, ok := s.schedules[job.Name]; ok {
+		e.schedule, e.spec = schedule, s.specs[job.Name]
+	}
+	s.mu.Lock()
+	s.jobs[job.Name] = e
+	s.order = append(s.order, job.Name)
+	s.mu.Unlock()
 }
 
 func (s *Scheduler) Start() {
-	s.logger.Info("Starting scheduler", "job_count", len(s.jobs))
-	for _, job := range s.jobs {
+	s.logger.Info("Starting scheduler", "job_count", len(s.order))
+	for _, name := range s.order {
 		s.wg.Add(1)
-		go s.runJob(job)
+		go s.runJob(s.jobs[name])
 	}
 }
 
+// Stop cancels running jobs and waits for them to return.
 func (s *Scheduler) Stop() {
 	s.logger.Info("Stopping scheduler...")
-	close(s.stop)
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
 	s.wg.Wait()
 	s.logger.Info("Scheduler stopped")
 }
 
-func (s *Scheduler) runJob(job Job) {
+func (s *Scheduler) runJob(e *entry) {
 	defer s.wg.Done()
 
-	s.logger.Info("Job started", "name", job.Name, "interval", job.Interval)
+	s.logger.Info("Job started", "name", e.job.Name, "schedule", e.spec)
+
+	next := e.schedule.Next(time.Now())
+	if _, custom := s.schedules[e.job.Name]; !custom && e.job.InitialDelay > 0 {
+		next = time.Now().Add(e.job.InitialDelay)
+	}
+	for {
+		s.mu.Lock()
+		e.next = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			s.logger.Info("Job stopped", "name", e.job.Name)
+			return
+		case <-timer.C:
+		}
+
+		s.runScheduled(e)
+		next = e.schedule.Next(time.Now())
+	}
+}
 
-	initialDelay := job.Interval
-	if job.InitialDelay > 0 {
-		initialDelay = job.InitialDelay
+// runScheduled skips the run when the job is paused or a manual run is
+// still going.
+func (s *Scheduler) runScheduled(e *entry) {
+	paused, err := s.repo.IsPaused(s.ctx, e.job.Name)
+	if err != nil {
+		s.logger.Warn("Failed to read job pause state", "name", e.job.Name, "error", err)
 	}
-	initialTimer := time.NewTimer(initialDelay)
-	defer initialTimer.Stop()
-	select {
-	case <-s.stop:
-		s.logger.Info("Job stopped", "name", job.Name)
+	if paused {
+		s.logger.Info("Skipping paused job", "name", e.job.Name)
 		return
-	case <-initialTimer.C:
-		s.executeJob(job)
 	}
+	ctx, run, ok := s.claim(e)
+	if !ok {
+		s.logger.Info("Skipping job still running", "name", e.job.Name)
+		return
+	}
+	s.executeJob(ctx, e, run, TriggerSchedule, nil)
+}
 
-	ticker := time.NewTicker(job.Interval)
-	defer ticker.Stop()
+// RunNow starts a job outside its schedule, even if it is paused, and
+// returns without waiting for it.
+func (s *Scheduler) RunNow(name string, triggeredBy *uuid.UUID) (uuid.UUID, error) {
+	s.mu.Lock()
+	e, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return uuid.Nil, ErrJobNotFound
+	}
+	ctx, run, ok := s.claim(e)
+	if !ok {
+		return uuid.Nil, ErrJobRunning
+	}
+	go s.executeJob(ctx, e, run, TriggerManual, triggeredBy)
+	return run.id, nil
+}
 
-	for {
-		select {
-		case <-s.stop:
-			s.logger.Info("Job stopped", "name", job.Name)
-			return
-		case <-ticker.C:
-			s.executeJob(job)
+// Cancel stops a job's current run on this replica.
+func (s *Scheduler) Cancel(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if e.running == nil {
+		return ErrJobNotRunning
+	}
+	e.running.cancel()
+	return nil
+}
+
+// SetPaused pauses or resumes a job's schedule on every replica. A run in
+// progress is left to finish.
+func (s *Scheduler) SetPaused(ctx context.Context, name string, paused bool, by *uuid.UUID) error {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return ErrJobNotFound
+	}
+	return s.repo.SetPaused(ctx, name, paused, by)
+}
+
+func (s *Scheduler) Status(ctx context.Context) ([]JobStatus, error) {
+	lastRuns, err := s.repo.LastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paused, err := s.repo.PausedJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]JobStatus, 0, len(s.order))
+	for _, name := range s.order {
+		e := s.jobs[name]
+		status := JobStatus{Name: name, Schedule: e.spec, Paused: paused[name]}
+		if !e.next.IsZero() {
+			next := e.next
+			status.NextRunAt = &next
+		}
+		if e.running != nil {
+			startedAt := e.running.startedAt
+			status.Running, status.RunningSince = true, &startedAt
 		}
+		if last, ok := lastRuns[name]; ok {
+			status.LastRun = &last
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses, nil
+}
+
+func (s *Scheduler) Runs(ctx context.Context, name string, limit int) ([]JobRun, error) {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrJobNotFound
 	}
+	return s.repo.ListRuns(ctx, name, limit)
 }
 
-func (s *Scheduler) executeJob(job Job) {
-	s.logger.Info("Executing job", "name", job.Name)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute) // Long timeout for sync
+// claim marks a job as running so runs never overlap on one replica. Stop
+// waits for every claimed run to be released.
+func (s *Scheduler) claim(e *entry) (context.Context, *activeRun, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.running != nil || s.ctx.Err() != nil {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, jobTimeout)
+	e.running = &activeRun{id: uuid.New(), startedAt: time.Now(), cancel: cancel}
+	s.wg.Add(1)
+	return ctx, e.running, true
+}
+
+func (s *Scheduler) release(e *entry) {
+	s.mu.Lock()
+	e.running.cancel()
+	e.running = nil
+	s.mu.Unlock()
+	s.wg.Done()
+}
+
+func (s *Scheduler) executeJob(ctx context.Context, e *entry, run *activeRun, trigger string, triggeredBy *uuid.UUID) {
+	job := e.job
+	defer s.release(e)
+
+	// Each run is its own trace, so a job's queries and API calls group
+	// under one root span.
+	ctx, span := tracing.Start(ctx, "job."+job.Name, trace.WithNewRoot())
+	defer span.End()
+
+	s.logger.InfoContext(ctx, "Executing job", "name", job.Name, "trigger", trigger)
+	record := &JobRun{
+		ID: run.id, JobName: job.Name, Trigger: trigger, TriggeredBy: triggeredBy,
+		Status: RunRunning, StartedAt: run.startedAt,
+	}
+	if err := s.repo.StartRun(ctx, record); err != nil {
+		s.logger.WarnContext(ctx, "Failed to record job start", "name", job.Name, "error", err)
+	}
+
+	err := s.invoke(ctx, job)
+	status, outcome := RunSuccess, "success"
+	switch {
+	case err == nil:
+		s.logger.InfoContext(ctx, "Job completed successfully", "name", job.Name)
+	case errors.Is(err, context.Canceled):
+		status, outcome = RunCancelled, "cancelled"
+		s.logger.WarnContext(ctx, "Job cancelled", "name", job.Name)
+	default:
+		status, outcome = RunFailed, "failure"
+		s.logger.ErrorContext(ctx, "Job failed", "name", job.Name, "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	metrics.ObserveJob(job.Name, outcome, time.Since(run.startedAt))
+
+	// The run's context may already be cancelled; the record must still land.
+	finishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
 	defer cancel()
+	if err := s.repo.FinishRun(finishCtx, run.id, status, err); err != nil {
+		s.logger.WarnContext(ctx, "Failed to record job result", "name", job.Name, "error", err)
+	}
+}
 
+// invoke runs the job, turning a panic into an error.
+func (s *Scheduler) invoke(ctx context.Context, job Job) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			s.logger.Error("Job panicked", "name", job.Name, "error", r)
+			err = fmt.Errorf("panic: %v", r)
 		}
 	}()
-
 	if err := job.Run(ctx); err != nil {
-		s.logger.Error("Job failed", "name", job.Name, "error", err)
-	} else {
-		s.logger.Info("Job completed successfully", "name", job.Name)
+		return err
+	}
+	// A job that swallowed its cancellation still ended early.
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return ctx.Err()
 	}
+	return nil
 }
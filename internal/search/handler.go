@@ -0,0 +1,71 @@
+package search
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/tashifkhan/bingebeacon/internal/pkg/httputil"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filters := Filters{
+		Query:     q.Get("q"),
+		MediaType: q.Get("type"),
+		Genres:    q["genre"],
+		Network:   q.Get("network"),
+		Status:    q.Get("status"),
+	}
+
+	var err error
+	if filters.YearFrom, err = optionalInt(q.Get("year_from")); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid year_from")
+		return
+	}
+	if filters.YearTo, err = optionalInt(q.Get("year_to")); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid year_to")
+		return
+	}
+	if raw := q.Get("min_rating"); raw != "" {
+		if filters.MinRating, err = strconv.ParseFloat(raw, 64); err != nil {
+			httputil.Error(w, http.StatusBadRequest, "Invalid min_rating")
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	results, err := h.svc.Search(r.Context(), filters, q.Get("cursor"), limit)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSONWithCache(w, r, http.StatusOK, results, 60, 300)
+}
+
+func (h *Handler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	suggestions, err := h.svc.Autocomplete(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSONWithCache(w, r, http.StatusOK, suggestions, 300, 600)
+}
+
+func optionalInt(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(raw)
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Filters narrows a catalogue search. Every field is optional; an empty
+// Query browses the filtered catalogue by rating instead of relevance.
+type Filters struct {
+	Query     string
+	MediaType string
+	Genres    []string
+	Network   string
+	Status    string
+	YearFrom  int
+	YearTo    int
+	MinRating float64
+}
+
+type Hit struct {
+	ID           uuid.UUID      `json:"id"`
+	TMDBID       *int           `json:"tmdb_id,omitempty"`
+	Title        string         `json:"title"`
+	MediaType    string         `json:"media_type"`
+	Status       *string        `json:"status,omitempty"`
+	Overview     *string        `json:"overview,omitempty"`
+	PosterURL    *string        `json:"poster_url,omitempty"`
+	Genres       pq.StringArray `gorm:"type:text[]" json:"genres"`
+	Network      *string        `json:"network,omitempty"`
+	PremiereDate *time.Time     `json:"premiere_date,omitempty"`
+	IMDBRating   *float64       `json:"imdb_rating,omitempty"`
+	Score        float64        `json:"score"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type Facets struct {
+	MediaType []FacetCount `json:"media_type"`
+	Genre     []FacetCount `json:"genre"`
+	Network   []FacetCount `json:"network"`
+	Status    []FacetCount `json:"status"`
+	Year      []FacetCount `json:"year"`
+}
+
+type Results struct {
+	Results    []Hit   `json:"results"`
+	Facets     *Facets `json:"facets,omitempty"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type Suggestion struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	MediaType string    `json:"media_type"`
+	PosterURL *string   `json:"poster_url,omitempty"`
+	Year      *int      `json:"year,omitempty"`
+}
+
+// cursor is the keyset position of the last hit on a page. Scores are
+// rounded in SQL so they survive the round trip through JSON exactly.
+type cursor struct {
+	Score float64   `json:"s"`
+	ID    uuid.UUID `json:"i"`
+}
@@ -0,0 +1,127 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const maxFacetValues = 20
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// filtered applies the query and filters to the shows table. Titles are
+// indexed with the 'simple' config and overviews with 'english', so the
+// query is matched under both, plus trigram similarity for typos.
+func (r *Repository) filtered(ctx context.Context, f Filters) *gorm.DB {
+	db := r.db.WithContext(ctx).Table("shows")
+	if f.Query != "" {
+		db = db.Where("(shows.search_vector @@ websearch_to_tsquery('simple', ?) OR shows.search_vector @@ websearch_to_tsquery('english', ?) OR shows.search_titles % ?)",
+			f.Query, f.Query, f.Query)
+	}
+	if f.MediaType != "" {
+		db = db.Where("shows.media_type = ?", f.MediaType)
+	}
+	if len(f.Genres) > 0 {
+		db = db.Where("shows.genres @> ?", pq.StringArray(f.Genres))
+	}
+	if f.Network != "" {
+		db = db.Where("shows.network = ?", f.Network)
+	}
+	if f.Status != "" {
+		db = db.Where("shows.status = ?", f.Status)
+	}
+	if f.YearFrom > 0 {
+		db = db.Where("shows.premiere_date >= make_date(?, 1, 1)", f.YearFrom)
+	}
+	if f.YearTo > 0 {
+		db = db.Where("shows.premiere_date < make_date(?, 1, 1)", f.YearTo+1)
+	}
+	if f.MinRating > 0 {
+		db = db.Where("shows.imdb_rating >= ?", f.MinRating)
+	}
+	return db
+}
+
+// Search returns up to limit hits after the given keyset position, ordered
+// by relevance (or rating when there is no query) and then id.
+func (r *Repository) Search(ctx context.Context, f Filters, after *cursor, limit int) ([]Hit, error) {
+	score, args := "ROUND(COALESCE(shows.imdb_rating, 0)::numeric, 6)", []interface{}{}
+	if f.Query != "" {
+		score = `ROUND((ts_rank_cd(shows.search_vector, websearch_to_tsquery('simple', ?)) +
+			ts_rank_cd(shows.search_vector, websearch_to_tsquery('english', ?)) +
+			similarity(shows.search_titles, ?))::numeric, 6)`
+		args = []interface{}{f.Query, f.Query, f.Query}
+	}
+
+	ranked := r.filtered(ctx, f).Select(`shows.id, shows.tmdb_id, shows.title, shows.media_type, shows.status,
+		shows.overview, shows.poster_url, shows.genres, shows.network, shows.premiere_date,
+		shows.imdb_rating, `+score+` AS score`, args...)
+
+	db := r.db.WithContext(ctx).Table("(?) AS ranked", ranked)
+	if after != nil {
+		db = db.Where("ranked.score < ? OR (ranked.score = ? AND ranked.id > ?)", after.Score, after.Score, after.ID)
+	}
+
+	var hits []Hit
+	err := db.Order("ranked.score DESC, ranked.id ASC").Limit(limit).Find(&hits).Error
+	return hits, err
+}
+
+func (r *Repository) Facets(ctx context.Context, f Filters) (*Facets, error) {
+	base := r.filtered(ctx, f).Select("shows.media_type, shows.genres, shows.network, shows.status, shows.premiere_date")
+
+	var facets Facets
+	specs := []struct {
+		from  string
+		value string
+		dest  *[]FacetCount
+	}{
+		{"(?) AS f", "f.media_type", &facets.MediaType},
+		{"(?) AS f CROSS JOIN LATERAL unnest(f.genres) AS g(value)", "g.value", &facets.Genre},
+		{"(?) AS f", "f.network", &facets.Network},
+		{"(?) AS f", "f.status", &facets.Status},
+		{"(?) AS f", "EXTRACT(YEAR FROM f.premiere_date)::int::text", &facets.Year},
+	}
+	for _, spec := range specs {
+		err := r.db.WithContext(ctx).Table(spec.from, base).
+			Select(spec.value + " AS value, COUNT(*) AS count").
+			Where(spec.value + " IS NOT NULL").
+			Group("1").
+			Order("count DESC, value ASC").
+			Limit(maxFacetValues).
+			Scan(spec.dest).Error
+		if err != nil {
+			return nil, err
+		}
+		if *spec.dest == nil {
+			*spec.dest = []FacetCount{}
+		}
+	}
+	return &facets, nil
+}
+
+// Autocomplete matches title prefixes first, then falls back to trigram
+// word similarity so small typos still find the right title.
+func (r *Repository) Autocomplete(ctx context.Context, query string, limit int) ([]Suggestion, error) {
+	prefix := likeEscaper.Replace(query) + "%"
+
+	var suggestions []Suggestion
+	err := r.db.WithContext(ctx).Table("shows").
+		Select("id, title, media_type, poster_url, EXTRACT(YEAR FROM premiere_date)::int AS year").
+		Where("title ILIKE ? OR ? <% search_titles", prefix, query).
+		Order(gorm.Expr("(title ILIKE ?) DESC, word_similarity(?, search_titles) DESC, imdb_rating DESC NULLS LAST, id", prefix, query)).
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
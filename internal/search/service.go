@@ -0,0 +1,151 @@
+package search
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
+)
+
+const (
+	defaultLimit        = 20
+	maxLimit            = 50
+	defaultSuggestions  = 8
+	maxSuggestions      = 20
+	minSuggestionLength = 2
+	maxQueryLength      = 200
+	suggestionCacheTTL  = 10 * time.Minute
+)
+
+type Service struct {
+	repo  *Repository
+	cache cache.Store
+}
+
+func NewService(repo *Repository, store cache.Store) *Service {
+	return &Service{repo: repo, cache: store}
+}
+
+// Search runs a filtered catalogue search. Facets describe the whole
+// filtered result set, so they are only computed for the first page.
+func (s *Service) Search(ctx context.Context, f Filters, cursorToken string, limit int) (*Results, error) {
+	ctx, span := tracing.Start(ctx, "search.Search")
+	defer span.End()
+
+	f.Query = strings.TrimSpace(f.Query)
+	if err := validate(&f); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	var after *cursor
+	if cursorToken != "" {
+		decoded, err := decodeCursor(cursorToken)
+		if err != nil {
+			return nil, err
+		}
+		after = decoded
+	}
+
+	hits, err := s.repo.Search(ctx, f, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &Results{Results: hits}
+	if len(hits) > limit {
+		results.Results = hits[:limit]
+		last := hits[limit-1]
+		results.NextCursor = encodeCursor(cursor{Score: last.Score, ID: last.ID})
+	}
+	if results.Results == nil {
+		results.Results = []Hit{}
+	}
+
+	if after == nil {
+		facets, err := s.repo.Facets(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		results.Facets = facets
+	}
+	return results, nil
+}
+
+func (s *Service) Autocomplete(ctx context.Context, query string, limit int) ([]Suggestion, error) {
+	ctx, span := tracing.Start(ctx, "search.Autocomplete")
+	defer span.End()
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	if len([]rune(query)) < minSuggestionLength {
+		return []Suggestion{}, nil
+	}
+	if len(query) > maxQueryLength {
+		return nil, errors.New("query is too long")
+	}
+	if limit <= 0 {
+		limit = defaultSuggestions
+	}
+	if limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+
+	key := fmt.Sprintf("autocomplete:%d:%s", limit, query)
+	return cache.GetOrSet(ctx, s.cache, key, suggestionCacheTTL, func(ctx context.Context) ([]Suggestion, error) {
+		suggestions, err := s.repo.Autocomplete(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		if suggestions == nil {
+			suggestions = []Suggestion{}
+		}
+		return suggestions, nil
+	})
+}
+
+func validate(f *Filters) error {
+	if len(f.Query) > maxQueryLength {
+		return errors.New("query is too long")
+	}
+	if f.MediaType != "" && f.MediaType != "tv" && f.MediaType != "movie" {
+		return errors.New("type must be tv or movie")
+	}
+	if f.YearFrom < 0 || f.YearTo < 0 {
+		return errors.New("years must be positive")
+	}
+	if f.YearFrom > 0 && f.YearTo > 0 && f.YearFrom > f.YearTo {
+		return errors.New("year_from must not be after year_to")
+	}
+	if f.MinRating < 0 || f.MinRating > 10 {
+		return errors.New("min_rating must be between 0 and 10")
+	}
+	return nil
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(token string) (*cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
@@ -0,0 +1,23 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := cursor{Score: 0.912345, ID: uuid.New()}
+
+	got, err := decodeCursor(encodeCursor(want))
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if *got != want {
+		t.Fatalf("got %+v, want %+v", *got, want)
+	}
+
+	if _, err := decodeCursor("not a cursor"); err == nil {
+		t.Fatal("expected error for malformed cursor")
+	}
+}
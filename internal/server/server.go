@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -17,6 +18,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/cors"
+	"github.com/tashifkhan/bingebeacon/internal/admin"
 	"github.com/tashifkhan/bingebeacon/internal/alert"
 	"github.com/tashifkhan/bingebeacon/internal/auth"
 	"github.com/tashifkhan/bingebeacon/internal/config"
@@ -29,16 +31,24 @@ import (
 	"github.com/tashifkhan/bingebeacon/internal/notification"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/db"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/email"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/httputil"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/logger"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/metrics"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/ratelimit"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
+	"github.com/tashifkhan/bingebeacon/internal/recommendation"
 	"github.com/tashifkhan/bingebeacon/internal/scheduler"
 	"github.com/tashifkhan/bingebeacon/internal/scheduler/jobs"
+	"github.com/tashifkhan/bingebeacon/internal/search"
 	"github.com/tashifkhan/bingebeacon/internal/show"
 	"github.com/tashifkhan/bingebeacon/internal/showtimes"
+	"github.com/tashifkhan/bingebeacon/internal/social"
 	"github.com/tashifkhan/bingebeacon/internal/streaming"
 	"github.com/tashifkhan/bingebeacon/internal/timeline"
 	"github.com/tashifkhan/bingebeacon/internal/user"
 	"github.com/tashifkhan/bingebeacon/internal/watchlist"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 	"gorm.io/gorm"
 )
 
@@ -47,10 +57,13 @@ type Server struct {
 	config     *config.Config
 	db         *gorm.DB
 	redis      *redis.Client
+	cache      *cache.Tiered
 	logger     *slog.Logger
 	httpServer *http.Server
 	syncer     *metadata.Syncer
 	scheduler  *scheduler.Scheduler
+	// shutdownTracing flushes spans still buffered for export.
+	shutdownTracing func(context.Context) error
 }
 
 func NewServer(cfg *config.Config) (*Server, error) {
@@ -58,20 +71,38 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	logger.Init(cfg.Server.Environment)
 	log := logger.Log
 
-	// 2. Init DB
-	database, err := db.NewPostgresDB(cfg.Database)
+	// 1b. Init Tracing (before clients, which record spans from the start)
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing, cfg.Server.Environment)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Init Redis
-	rdb, err := cache.NewRedisClient(cfg.Redis)
+	// 2. Init DB
+	database, err := db.NewPostgresDB(cfg.Database)
 	if err != nil {
 		return nil, err
 	}
 
+	// 3. Init Cache (Redis is optional; without it the in-process tier serves alone)
+	var rdb *redis.Client
+	if cfg.Redis.Enabled {
+		rdb, err = cache.NewRedisClient(cfg.Redis)
+		if err != nil {
+			return nil, err
+		}
+		pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := rdb.Ping(pingCtx).Err(); err != nil {
+			log.Warn("Redis unavailable; starting with the in-process cache", "error", err)
+		}
+		cancel()
+	} else {
+		log.Info("Redis disabled; using the in-process cache only")
+	}
+	store := cache.NewTiered(rdb, cfg.Cache, log)
+
 	// 4. Init Router
 	r := mux.NewRouter()
+	r.Use(otelmux.Middleware(cfg.Tracing.ServiceName), metrics.Middleware)
 
 	// 5. Init Modules & Dependencies
 
@@ -84,7 +115,20 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	notifRepo := notification.NewRepository(database)
 	watchlistRepo := watchlist.NewRepository(database)
 	historyRepo := history.NewRepository(database)
+	socialRepo := social.NewRepository(database)
+	recommendationRepo := recommendation.NewRepository(database)
+	searchRepo := search.NewRepository(database)
 	syncRepo := metadata.NewRepository(database)
+	adminRepo := admin.NewRepository(database)
+	jobRepo := scheduler.NewRepository(database)
+
+	// Accounts listed in SERVER_ADMIN_EMAILS are promoted on every start, so
+	// a fresh install can bootstrap its first admin.
+	if promoted, err := userRepo.PromoteAdmins(context.Background(), lowerCSV(cfg.Server.AdminEmails)); err != nil {
+		log.Warn("Failed to promote configured admins", "error", err)
+	} else if promoted > 0 {
+		log.Info("Promoted configured admin accounts", "count", promoted)
+	}
 
 	// External Clients
 	tmdbClient := tmdb.NewClient(cfg.TMDB, log)
@@ -99,81 +143,153 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		// Assuming we can run without FCM for dev/test
 	}
 
+	// Web Push (Optional - browsers subscribe with the VAPID public key)
+	var webPushClient *notification.WebPushClient
+	if cfg.WebPush.VAPIDPrivateKey != "" {
+		webPushClient, err = notification.NewWebPushClient(cfg.WebPush.VAPIDPrivateKey, cfg.WebPush.Subject, log)
+		if err != nil {
+			log.Warn("Web Push initialization failed", "error", err)
+		}
+	}
+
 	// Services
 	userSvc := user.NewService(userRepo)
-	authSvc := auth.NewService(authRepo, userRepo, cfg.JWT)
+	identityProviders, err := auth.NewIdentityProviders(cfg.OIDC, &http.Client{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("invalid OIDC configuration: %w", err)
+	}
+	mailer := email.NewSender(cfg.Email, log)
+	authSvc := auth.NewService(authRepo, userRepo, cfg.JWT, identityProviders, store, mailer, cfg.Email.AppURL)
 	if err := authSvc.ValidateConfig(); err != nil {
 		return nil, fmt.Errorf("invalid auth configuration: %w", err)
 	}
 	if cfg.Server.Environment == "production" && cfg.JWT.Secret == "development-only-secret-change-me" {
 		return nil, fmt.Errorf("invalid auth configuration: set JWT_SECRET in production")
 	}
-	showSvc := show.NewService(showRepo, tmdbClient, rdb)
-	notifSvc := notification.NewService(notifRepo, rdb)
-	historySvc := history.NewService(historyRepo, showRepo, alertRepo)
-	showtimesSvc := showtimes.NewService(movieGluClient, showRepo, rdb)
-	streamingSvc := streaming.NewService(showRepo, tmdbClient, rdb)
+	showSvc := show.NewService(showRepo, tmdbClient, store)
+	socialSvc := social.NewService(socialRepo)
+	historySvc := history.NewService(historyRepo, showRepo, alertRepo, socialSvc)
+	actionSigner := notification.NewActionSigner(cfg.JWT.Secret, cfg.Notification.ActionTTL)
+	notifSvc := notification.NewService(notifRepo, store, historySvc, alertRepo, actionSigner, cfg.Notification.Snooze)
+	showtimesSvc := showtimes.NewService(movieGluClient, showRepo, store)
+	streamingSvc := streaming.NewService(showRepo, tmdbClient, store)
 
 	// Syncer
-	syncer := metadata.NewSyncer(tmdbClient, omdbClient, thetvdbClient, showRepo, alertRepo, timelineRepo, notifRepo, syncRepo, rdb, log)
-
-	alertSvc := alert.NewService(alertRepo, showSvc, showRepo, syncer, rdb)
-	watchlistSvc := watchlist.NewService(watchlistRepo, showRepo, alertSvc)
-	timelineSvc := timeline.NewService(timelineRepo, rdb)
+	syncer := metadata.NewSyncer(tmdbClient, omdbClient, thetvdbClient, showRepo, alertRepo, timelineRepo, notifRepo, syncRepo, store, log)
+
+	alertSvc := alert.NewService(alertRepo, showSvc, showRepo, syncer, socialSvc, userSvc)
+	watchlistSvc := watchlist.NewService(watchlistRepo, showRepo, alertSvc, socialSvc, userSvc)
+	timelineSvc := timeline.NewService(timelineRepo)
+	recommendationSvc := recommendation.NewService(recommendationRepo, showSvc, showRepo, tmdbClient, userSvc, store)
+	searchSvc := search.NewService(searchRepo, store)
+	// The scheduler is built here so the admin API can control it; jobs are
+	// registered below.
+	sched, err := scheduler.NewScheduler(jobRepo, cfg.Scheduler.Schedules(), log)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheduler configuration: %w", err)
+	}
+	adminSvc := admin.NewService(adminRepo, userRepo, notifRepo, authRepo, showRepo, showSvc, syncer, sched, store, log)
 
 	// Handlers
 	userHandler := user.NewHandler(userSvc)
-	authHandler := auth.NewHandler(authSvc, cfg.JWT, cfg.Server.Environment)
-	showHandler := show.NewHandler(showSvc)
+	authHandler := auth.NewHandler(authSvc, cfg.JWT, cfg.Server.Environment, cfg.OIDC.SuccessURL)
+	showHandler := show.NewHandler(showSvc, socialSvc, userSvc)
 	alertHandler := alert.NewHandler(alertSvc)
 	timelineHandler := timeline.NewHandler(timelineSvc)
-	notifHandler := notification.NewHandler(notifSvc)
+	notifHandler := notification.NewHandler(notifSvc, webPushClient)
 	watchlistHandler := watchlist.NewHandler(watchlistSvc)
 	historyHandler := history.NewHandler(historySvc)
+	socialHandler := social.NewHandler(socialSvc)
+	recommendationHandler := recommendation.NewHandler(recommendationSvc)
+	searchHandler := search.NewHandler(searchSvc)
 	showtimesHandler := showtimes.NewHandler(showtimesSvc)
 	streamingHandler := streaming.NewHandler(streamingSvc)
+	adminHandler := admin.NewHandler(adminSvc)
 
 	// Scheduler
-	sched := scheduler.NewScheduler(log)
 	sched.Register(jobs.NewEpisodeSyncJob(syncer, showRepo, log))
 	sched.Register(jobs.NewChangesSyncJob(syncer, log))
-	sched.Register(jobs.NewNotificationDispatchJob(notifRepo, userRepo, fcmClient, log))
-	sched.Register(jobs.NewStaleCleanupJob(notifRepo, showRepo, syncRepo, log))
+	sched.Register(jobs.NewNotificationDispatchJob(notifRepo, userRepo, fcmClient, webPushClient, mailer, notification.NewRenderer(cfg.Email.AppURL, actionSigner), cfg.Email.RequireVerified, cfg.Notification.CoalesceWindow, log))
+	sched.Register(jobs.NewStaleCleanupJob(notifRepo, showRepo, syncRepo, authRepo, jobRepo, log))
 
 	// Middleware
-	authMiddleware := auth.NewMiddleware(cfg.JWT)
+	authMiddleware := auth.NewMiddleware(cfg.JWT, authSvc)
+	limiter := ratelimit.NewLimiter(store, cfg.RateLimit.Enabled, log)
+	authLimit := limiter.Middleware(rateLimitRule("auth", cfg.RateLimit.Auth, ratelimit.ByIP))
+	searchLimit := limiter.Middleware(rateLimitRule("search", cfg.RateLimit.Search, ratelimit.ByUser))
+	importLimit := limiter.Middleware(rateLimitRule("import", cfg.RateLimit.Import, ratelimit.ByUser))
+	apiLimit := limiter.Middleware(rateLimitRule("api", cfg.RateLimit.API, ratelimit.ByUser))
 
 	// 6. Register Routes
 	api := r.PathPrefix("/api/v1").Subrouter()
 
 	// Auth Routes
-	api.HandleFunc("/auth/register", authHandler.Register).Methods("POST")
-	api.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
+	api.Handle("/auth/register", authLimit(http.HandlerFunc(authHandler.Register))).Methods("POST")
+	api.Handle("/auth/login", authLimit(http.HandlerFunc(authHandler.Login))).Methods("POST")
 	api.HandleFunc("/auth/refresh", authHandler.Refresh).Methods("POST")
 	api.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST")
+	api.Handle("/auth/profile", authMiddleware.Authenticate(http.HandlerFunc(authHandler.SelectProfile))).Methods("POST")
+	api.Handle("/auth/mfa/verify", authLimit(http.HandlerFunc(authHandler.VerifyMFA))).Methods("POST")
+	api.Handle("/auth/mfa/enroll", authLimit(http.HandlerFunc(authHandler.EnrollMFA))).Methods("POST")
+	api.Handle("/auth/mfa/enroll/confirm", authLimit(http.HandlerFunc(authHandler.ConfirmMFAEnrollment))).Methods("POST")
+	api.Handle("/auth/password/forgot", authLimit(http.HandlerFunc(authHandler.ForgotPassword))).Methods("POST")
+	api.Handle("/auth/password/reset", authLimit(http.HandlerFunc(authHandler.ResetPassword))).Methods("POST")
+	api.Handle("/auth/password/change", authMiddleware.Authenticate(http.HandlerFunc(authHandler.ChangePassword))).Methods("POST")
+	api.Handle("/auth/email/verify", authLimit(http.HandlerFunc(authHandler.VerifyEmail))).Methods("POST")
+	api.Handle("/auth/email/verification", authMiddleware.Authenticate(http.HandlerFunc(authHandler.ResendVerification))).Methods("POST")
+	api.Handle("/auth/email/change", authMiddleware.Authenticate(http.HandlerFunc(authHandler.ChangeEmail))).Methods("POST")
+	api.HandleFunc("/auth/oidc/providers", authHandler.OIDCProviders).Methods("GET")
+	api.Handle("/auth/oidc/exchange", authLimit(http.HandlerFunc(authHandler.OIDCExchange))).Methods("POST")
+	api.HandleFunc("/auth/oidc/{provider}/login", authHandler.OIDCLogin).Methods("GET")
+	api.HandleFunc("/auth/oidc/{provider}/callback", authHandler.OIDCCallback).Methods("GET")
+	api.Handle("/auth/oidc/{provider}/link", authMiddleware.Authenticate(http.HandlerFunc(authHandler.OIDCLink))).Methods("POST")
+	api.Handle("/auth/identities", authMiddleware.Authenticate(http.HandlerFunc(authHandler.ListIdentities))).Methods("GET")
+	api.Handle("/auth/identities/{id}", authMiddleware.Authenticate(http.HandlerFunc(authHandler.UnlinkIdentity))).Methods("DELETE")
 
 	// User Routes (Protected)
 	userRouter := api.PathPrefix("/me").Subrouter()
-	userRouter.Use(authMiddleware.Authenticate)
+	userRouter.Use(authMiddleware.Authenticate, apiLimit)
 	userRouter.HandleFunc("", userHandler.GetProfile).Methods("GET")
 	userRouter.HandleFunc("", userHandler.UpdateProfile).Methods("PATCH")
 	userRouter.HandleFunc("/devices", userHandler.RegisterDevice).Methods("POST")
 	userRouter.HandleFunc("/devices/{id}", userHandler.UnregisterDevice).Methods("DELETE")
-
-	// Show Routes (Public)
+	userRouter.HandleFunc("/profiles", userHandler.ListProfiles).Methods("GET")
+	userRouter.HandleFunc("/profiles", userHandler.CreateProfile).Methods("POST")
+	userRouter.HandleFunc("/profiles/{id}", userHandler.EditProfile).Methods("PATCH")
+	userRouter.HandleFunc("/profiles/{id}", userHandler.DeleteProfile).Methods("DELETE")
+	userRouter.HandleFunc("/sessions", authHandler.ListSessions).Methods("GET")
+	userRouter.HandleFunc("/sessions", authHandler.RevokeAllSessions).Methods("DELETE")
+	userRouter.HandleFunc("/sessions/{id}", authHandler.RevokeSession).Methods("DELETE")
+	userRouter.HandleFunc("/mfa", authHandler.MFAStatus).Methods("GET")
+	userRouter.HandleFunc("/mfa/totp", authHandler.BeginTOTP).Methods("POST")
+	userRouter.HandleFunc("/mfa/totp/confirm", authHandler.ConfirmTOTP).Methods("POST")
+	userRouter.HandleFunc("/mfa/totp", authHandler.DisableTOTP).Methods("DELETE")
+	userRouter.HandleFunc("/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes).Methods("POST")
+	userRouter.HandleFunc("/tokens", authHandler.ListPersonalTokens).Methods("GET")
+	userRouter.HandleFunc("/tokens", authHandler.CreatePersonalToken).Methods("POST")
+	userRouter.HandleFunc("/tokens/{id}", authHandler.RevokePersonalToken).Methods("DELETE")
+
+	// Show Routes (Public; signed-in callers also see friends watching)
 	showRouter := api.PathPrefix("/shows").Subrouter()
-	showRouter.HandleFunc("/search", showHandler.Search).Methods("GET")
+	showRouter.Use(authMiddleware.OptionalAuthenticate)
+	showRouter.Handle("/search", searchLimit(http.HandlerFunc(showHandler.Search))).Methods("GET")
 	showRouter.HandleFunc("/trending", showHandler.Trending).Methods("GET")
 	showRouter.HandleFunc("/popular", showHandler.Popular).Methods("GET")
-	showRouter.HandleFunc("/import", showHandler.Import).Methods("POST")
+	showRouter.Handle("/import", importLimit(http.HandlerFunc(showHandler.Import))).Methods("POST")
 	showRouter.HandleFunc("/{id}", showHandler.GetShow).Methods("GET")
 	showRouter.HandleFunc("/{id}/seasons/{num}", showHandler.GetSeason).Methods("GET")
 	showRouter.HandleFunc("/{id}/episodes", showHandler.GetEpisodes).Methods("GET")
 	showRouter.HandleFunc("/{id}/sync-status", showHandler.GetSyncStatus).Methods("GET")
 
+	// Search Routes (Public)
+	searchRouter := api.PathPrefix("/search").Subrouter()
+	searchRouter.Use(searchLimit)
+	searchRouter.HandleFunc("", searchHandler.Search).Methods("GET")
+	searchRouter.HandleFunc("/autocomplete", searchHandler.Autocomplete).Methods("GET")
+
 	// Tracking Routes (Protected)
 	trackingRouter := api.PathPrefix("/tracking").Subrouter()
-	trackingRouter.Use(authMiddleware.Authenticate)
+	trackingRouter.Use(authMiddleware.Scoped("tracking"), apiLimit)
 	trackingRouter.HandleFunc("", alertHandler.GetTrackedShows).Methods("GET")
 	trackingRouter.HandleFunc("", alertHandler.TrackShow).Methods("POST")
 	trackingRouter.HandleFunc("/{show_id}", alertHandler.UpdateTracking).Methods("PATCH")
@@ -183,32 +299,76 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 	// Timeline Routes (Protected)
 	timelineRouter := api.PathPrefix("/timeline").Subrouter()
-	timelineRouter.Use(authMiddleware.Authenticate)
+	timelineRouter.Use(authMiddleware.Scoped("timeline"), apiLimit)
 	timelineRouter.HandleFunc("", timelineHandler.GetTimeline).Methods("GET")
+	timelineRouter.HandleFunc("/events", timelineHandler.ListEvents).Methods("GET")
 	timelineRouter.HandleFunc("/today", timelineHandler.GetToday).Methods("GET")
 	timelineRouter.HandleFunc("/week", timelineHandler.GetThisWeek).Methods("GET")
 	timelineRouter.HandleFunc("/upcoming", timelineHandler.GetUpcoming).Methods("GET")
 
+	// Notification action buttons carry their own signed token
+	api.Handle("/notifications/actions", authLimit(http.HandlerFunc(notifHandler.PerformAction))).Methods("POST")
+
 	// Notification Routes (Protected)
 	notifRouter := api.PathPrefix("/notifications").Subrouter()
-	notifRouter.Use(authMiddleware.Authenticate)
+	notifRouter.Use(authMiddleware.Scoped("notifications"), apiLimit)
 	notifRouter.HandleFunc("", notifHandler.GetNotifications).Methods("GET")
 	notifRouter.HandleFunc("/unread-count", notifHandler.GetUnreadCount).Methods("GET")
+	notifRouter.HandleFunc("/web-push/key", notifHandler.WebPushKey).Methods("GET")
 	notifRouter.HandleFunc("/read-all", notifHandler.MarkAllRead).Methods("POST")
 	notifRouter.HandleFunc("/{id}/read", notifHandler.MarkRead).Methods("PATCH")
 
 	// Watchlist Routes (Protected)
 	watchlistRouter := api.PathPrefix("/watchlist").Subrouter()
-	watchlistRouter.Use(authMiddleware.Authenticate)
+	watchlistRouter.Use(authMiddleware.Scoped("watchlist"), apiLimit)
 	watchlistRouter.HandleFunc("", watchlistHandler.List).Methods("GET")
 	watchlistRouter.HandleFunc("", watchlistHandler.Add).Methods("POST")
 	watchlistRouter.HandleFunc("/{show_id}", watchlistHandler.Update).Methods("PATCH")
 	watchlistRouter.HandleFunc("/{show_id}", watchlistHandler.Remove).Methods("DELETE")
 	watchlistRouter.HandleFunc("/{show_id}/start-tracking", watchlistHandler.StartTracking).Methods("POST")
 
+	// Custom List Routes (Protected)
+	listRouter := api.PathPrefix("/lists").Subrouter()
+	listRouter.Use(authMiddleware.Scoped("lists"), apiLimit)
+	listRouter.HandleFunc("", watchlistHandler.GetLists).Methods("GET")
+	listRouter.HandleFunc("", watchlistHandler.CreateList).Methods("POST")
+	listRouter.HandleFunc("/shared/{slug}/clone", watchlistHandler.CloneList).Methods("POST")
+	listRouter.HandleFunc("/{id}", watchlistHandler.GetList).Methods("GET")
+	listRouter.HandleFunc("/{id}", watchlistHandler.UpdateList).Methods("PATCH")
+	listRouter.HandleFunc("/{id}", watchlistHandler.DeleteList).Methods("DELETE")
+	listRouter.HandleFunc("/{id}/share", watchlistHandler.RotateShareLink).Methods("POST")
+	listRouter.HandleFunc("/{id}/order", watchlistHandler.ReorderList).Methods("PUT")
+	listRouter.HandleFunc("/{id}/items", watchlistHandler.AddListItem).Methods("POST")
+	listRouter.HandleFunc("/{id}/items/{show_id}", watchlistHandler.UpdateListItem).Methods("PATCH")
+	listRouter.HandleFunc("/{id}/items/{show_id}", watchlistHandler.RemoveListItem).Methods("DELETE")
+
+	// Shared List Routes (Public, read-only)
+	publicRouter := api.PathPrefix("/public").Subrouter()
+	publicRouter.HandleFunc("/lists/{slug}", watchlistHandler.GetSharedList).Methods("GET")
+	publicRouter.HandleFunc("/users/{username}/lists", watchlistHandler.GetPublicLists).Methods("GET")
+
+	// Recommendation Routes (Protected)
+	recommendationRouter := api.PathPrefix("/recommendations").Subrouter()
+	recommendationRouter.Use(authMiddleware.Scoped("recommendations"), apiLimit)
+	recommendationRouter.HandleFunc("", recommendationHandler.GetRecommendations).Methods("GET")
+
+	// Social Routes (Protected)
+	socialRouter := api.PathPrefix("/social").Subrouter()
+	socialRouter.Use(authMiddleware.Scoped("social"), apiLimit)
+	socialRouter.HandleFunc("/feed", socialHandler.GetFeed).Methods("GET")
+	socialRouter.HandleFunc("/settings", socialHandler.GetSettings).Methods("GET")
+	socialRouter.HandleFunc("/settings", socialHandler.UpdateSettings).Methods("PATCH")
+	socialRouter.HandleFunc("/follow/{username}", socialHandler.Follow).Methods("POST")
+	socialRouter.HandleFunc("/follow/{username}", socialHandler.Unfollow).Methods("DELETE")
+	socialRouter.HandleFunc("/followers", socialHandler.GetFollowers).Methods("GET")
+	socialRouter.HandleFunc("/followers/{user_id}", socialHandler.RemoveFollower).Methods("DELETE")
+	socialRouter.HandleFunc("/following", socialHandler.GetFollowing).Methods("GET")
+	socialRouter.HandleFunc("/requests", socialHandler.GetFollowRequests).Methods("GET")
+	socialRouter.HandleFunc("/requests/{user_id}/approve", socialHandler.ApproveRequest).Methods("POST")
+
 	// Watch History Routes (Protected)
 	historyRouter := api.PathPrefix("/history").Subrouter()
-	historyRouter.Use(authMiddleware.Authenticate)
+	historyRouter.Use(authMiddleware.Scoped("history"), apiLimit)
 	historyRouter.HandleFunc("", historyHandler.List).Methods("GET")
 	historyRouter.HandleFunc("", historyHandler.Create).Methods("POST")
 	historyRouter.HandleFunc("/batch", historyHandler.CreateBatch).Methods("POST")
@@ -219,29 +379,28 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 	// Showtimes Routes (Protected)
 	showtimesRouter := api.PathPrefix("/showtimes").Subrouter()
-	showtimesRouter.Use(authMiddleware.Authenticate)
+	showtimesRouter.Use(authMiddleware.Scoped("showtimes"), apiLimit)
 	showtimesRouter.HandleFunc("/cinemas/nearby", showtimesHandler.GetCinemasNearby).Methods("GET")
 	showtimesRouter.HandleFunc("/{show_id}", showtimesHandler.GetShowtimes).Methods("GET")
 
 	// Streaming Providers (Protected)
 	streamingRouter := api.PathPrefix("/streaming").Subrouter()
-	streamingRouter.Use(authMiddleware.Authenticate)
+	streamingRouter.Use(authMiddleware.Scoped("streaming"), apiLimit)
 	streamingRouter.HandleFunc("/{show_id}", streamingHandler.GetStreaming).Methods("GET")
 
-	// Internal/Admin Routes
-	internalRouter := r.PathPrefix("/api/internal").Subrouter()
-	// Add simple auth or ip restriction middleware here if needed
-	internalRouter.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		// Simple health check
+	healthReport := func(ctx context.Context) map[string]string {
 		dbStatus := "up"
-		if sqlDB, err := database.DB(); err != nil || sqlDB.Ping() != nil {
+		if sqlDB, err := database.DB(); err != nil || sqlDB.PingContext(ctx) != nil {
 			dbStatus = "down"
 		}
-		redisStatus := "up"
-		if rdb.Ping(context.Background()).Err() != nil {
-			redisStatus = "down"
+		redisStatus := "disabled"
+		if rdb != nil {
+			redisStatus = "up"
+			if rdb.Ping(ctx).Err() != nil {
+				redisStatus = "down"
+			}
 		}
-		httputil.JSON(w, http.StatusOK, map[string]string{
+		return map[string]string{
 			"status":  "ok",
 			"db":      dbStatus,
 			"redis":   redisStatus,
@@ -249,7 +408,50 @@ func NewServer(cfg *config.Config) (*Server, error) {
 			"omdb":    configuredStatus(cfg.OMDB.APIKey),
 			"thetvdb": configuredStatus(cfg.TheTVDB.APIKey),
 			"fcm":     configuredStatus(cfg.FCM.CredentialsFile),
-		})
+			"webpush": configuredStatus(cfg.WebPush.VAPIDPrivateKey),
+		}
+	}
+
+	// Admin Routes (moderators and admins; admins need a 2FA session)
+	adminRouter := api.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(authMiddleware.Authenticate, authMiddleware.RequireRole(user.RoleModerator, user.RoleAdmin), apiLimit)
+	adminOnly := authMiddleware.RequireRole(user.RoleAdmin)
+	adminRouter.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		httputil.JSON(w, http.StatusOK, healthReport(r.Context()))
+	}).Methods("GET")
+	adminRouter.HandleFunc("/users", adminHandler.ListUsers).Methods("GET")
+	adminRouter.HandleFunc("/users/{id}", adminHandler.GetUser).Methods("GET")
+	adminRouter.Handle("/users/{id}", adminOnly(http.HandlerFunc(adminHandler.DeleteUser))).Methods("DELETE")
+	adminRouter.Handle("/users/{id}/role", adminOnly(http.HandlerFunc(adminHandler.SetRole))).Methods("PUT")
+	adminRouter.Handle("/users/{id}/disable", adminOnly(http.HandlerFunc(adminHandler.DisableUser))).Methods("POST")
+	adminRouter.Handle("/users/{id}/enable", adminOnly(http.HandlerFunc(adminHandler.EnableUser))).Methods("POST")
+	adminRouter.Handle("/users/{id}/sessions", adminOnly(http.HandlerFunc(adminHandler.ResetSessions))).Methods("DELETE")
+	adminRouter.HandleFunc("/shows", adminHandler.ListShows).Methods("GET")
+	adminRouter.HandleFunc("/shows", adminHandler.ImportShow).Methods("POST")
+	adminRouter.HandleFunc("/shows/{id}/sync", adminHandler.SyncShow).Methods("POST")
+	adminRouter.Handle("/shows/{id}", adminOnly(http.HandlerFunc(adminHandler.DeleteShow))).Methods("DELETE")
+	adminRouter.HandleFunc("/sync/logs", adminHandler.SyncLogs).Methods("GET")
+	adminRouter.HandleFunc("/jobs", adminHandler.ListJobs).Methods("GET")
+	adminRouter.HandleFunc("/jobs/{name}/runs", adminHandler.JobRuns).Methods("GET")
+	adminRouter.Handle("/jobs/{name}/run", adminOnly(http.HandlerFunc(adminHandler.RunJob))).Methods("POST")
+	adminRouter.Handle("/jobs/{name}/pause", adminOnly(http.HandlerFunc(adminHandler.PauseJob))).Methods("POST")
+	adminRouter.Handle("/jobs/{name}/resume", adminOnly(http.HandlerFunc(adminHandler.ResumeJob))).Methods("POST")
+	adminRouter.Handle("/jobs/{name}/cancel", adminOnly(http.HandlerFunc(adminHandler.CancelJob))).Methods("POST")
+	adminRouter.HandleFunc("/notifications/dead-letters", adminHandler.ListDeadLetters).Methods("GET")
+	adminRouter.Handle("/notifications/dead-letters/replay", adminOnly(http.HandlerFunc(adminHandler.ReplayDeadLetters))).Methods("POST")
+	adminRouter.Handle("/notifications/dead-letters/discard", adminOnly(http.HandlerFunc(adminHandler.DiscardDeadLetters))).Methods("POST")
+	adminRouter.Handle("/audit", adminOnly(http.HandlerFunc(adminHandler.ListAudit))).Methods("GET")
+
+	// Internal Routes (automation; key-protected except the liveness probe)
+	internalRouter := r.PathPrefix("/api/internal").Subrouter()
+	internalRouter.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		// Container health checks only need a 200; dependency details are
+		// for holders of the internal key.
+		if !authorizeInternal(r, cfg) {
+			httputil.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+			return
+		}
+		httputil.JSON(w, http.StatusOK, healthReport(r.Context()))
 	}).Methods("GET")
 
 	internalRouter.HandleFunc("/sync/trigger", func(w http.ResponseWriter, r *http.Request) {
@@ -271,7 +473,12 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		}
 
 		// Run sync in background or foreground? Foreground for feedback
-		if err := syncer.SyncShow(r.Context(), showID); err != nil {
+		actor := admin.Actor{Role: "internal_api_key", IPAddress: httputil.ClientIP(r)}
+		if err := adminSvc.SyncShow(r.Context(), actor, showID); err != nil {
+			if errors.Is(err, admin.ErrShowNotFound) {
+				httputil.Error(w, http.StatusNotFound, err.Error())
+				return
+			}
 			httputil.Error(w, http.StatusInternalServerError, err.Error())
 			return
 		}
@@ -285,7 +492,7 @@ func NewServer(cfg *config.Config) (*Server, error) {
 			return
 		}
 		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		entries, err := syncer.RecentLogs(limit)
+		entries, err := syncer.RecentLogs(r.Context(), limit)
 		if err != nil {
 			httputil.Error(w, http.StatusInternalServerError, err.Error())
 			return
@@ -293,11 +500,24 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		httputil.JSON(w, http.StatusOK, entries)
 	}).Methods("GET")
 
+	// Metrics (Prometheus scrape endpoint)
+	if cfg.Metrics.Enabled {
+		metricsHandler := metrics.Handler()
+		r.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			if !authorizeMetrics(r, cfg) {
+				httputil.Error(w, http.StatusUnauthorized, "metrics token required")
+				return
+			}
+			metricsHandler.ServeHTTP(w, r)
+		}).Methods("GET")
+	}
+
 	// 7. CORS
 	c := cors.New(cors.Options{
 		AllowedOrigins:   splitCSV(cfg.Server.CORSOrigins),
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type", "X-Client-Platform", "X-Internal-API-Key"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "X-Client-Platform", "X-Device-Name", "X-Internal-API-Key"},
+		ExposedHeaders:   []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
 		AllowCredentials: true,
 	})
 
@@ -306,8 +526,11 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		config:    cfg,
 		db:        database,
 		redis:     rdb,
+		cache:     store,
 		logger:    log,
 		scheduler: sched,
+
+		shutdownTracing: shutdownTracing,
 	}
 
 	srv.httpServer = &http.Server{
@@ -328,6 +551,10 @@ func (s *Server) Start() error {
 	// Start Scheduler
 	s.scheduler.Start()
 
+	listenCtx, stopListening := context.WithCancel(context.Background())
+	defer stopListening()
+	go s.cache.Listen(listenCtx)
+
 	serverErrors := make(chan error, 1)
 	go func() {
 		serverErrors <- s.httpServer.ListenAndServe()
@@ -355,6 +582,9 @@ func (s *Server) Start() error {
 	if err := s.httpServer.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server forced to shutdown: %w", err)
 	}
+	if err := s.shutdownTracing(ctx); err != nil {
+		s.logger.Warn("Failed to flush traces", "error", err)
+	}
 
 	return nil
 }
@@ -370,6 +600,14 @@ func splitCSV(value string) []string {
 	return result
 }
 
+func lowerCSV(value string) []string {
+	values := splitCSV(value)
+	for i := range values {
+		values[i] = strings.ToLower(values[i])
+	}
+	return values
+}
+
 func authorizeInternal(r *http.Request, cfg *config.Config) bool {
 	if cfg.Server.InternalAPIKey == "" {
 		return cfg.Server.Environment != "production"
@@ -379,6 +617,21 @@ func authorizeInternal(r *http.Request, cfg *config.Config) bool {
 		subtle.ConstantTimeCompare([]byte(provided), []byte(cfg.Server.InternalAPIKey)) == 1
 }
 
+// authorizeMetrics accepts the metrics bearer token. Without one configured
+// the endpoint is open outside production, like the internal routes.
+func authorizeMetrics(r *http.Request, cfg *config.Config) bool {
+	if cfg.Metrics.Token == "" {
+		return cfg.Server.Environment != "production"
+	}
+	provided, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return len(provided) == len(cfg.Metrics.Token) &&
+		subtle.ConstantTimeCompare([]byte(provided), []byte(cfg.Metrics.Token)) == 1
+}
+
+func rateLimitRule(name string, rule config.RateLimitRule, key ratelimit.KeyFunc) ratelimit.Rule {
+	return ratelimit.Rule{Name: name, Requests: rule.Requests, Window: rule.Window, Key: key}
+}
+
 func configuredStatus(value string) string {
 	if strings.TrimSpace(value) == "" {
 		return "not_configured"
@@ -1,12 +1,14 @@
 package show
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	appctx "github.com/tashifkhan/bingebeacon/internal/pkg/context"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/httputil"
 )
 
@@ -15,12 +17,24 @@ type ImportRequest struct {
 	MediaType string `json:"media_type"`
 }
 
+// FriendsFinder lists the accounts a viewer follows that are tracking a show.
+type FriendsFinder interface {
+	FriendsWatching(ctx context.Context, viewerID, showID uuid.UUID) ([]FriendWatching, error)
+}
+
+// ContentGate enforces a household profile's content rating limit.
+type ContentGate interface {
+	CheckContentRating(ctx context.Context, profileID uuid.UUID, rated string) error
+}
+
 type Handler struct {
-	svc *Service
+	svc     *Service
+	friends FriendsFinder
+	gate    ContentGate
 }
 
-func NewHandler(svc *Service) *Handler {
-	return &Handler{svc: svc}
+func NewHandler(svc *Service, friends FriendsFinder, gate ContentGate) *Handler {
+	return &Handler{svc: svc, friends: friends, gate: gate}
 }
 
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
@@ -94,6 +108,30 @@ func (h *Handler) GetShow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if profileID, ok := appctx.ProfileID(r.Context()); ok && h.gate != nil {
+		if err := h.gate.CheckContentRating(r.Context(), profileID, show.Rated()); err != nil {
+			w.Header().Set("Cache-Control", "private, no-cache")
+			httputil.Error(w, http.StatusForbidden, err.Error())
+			return
+		}
+	}
+
+	// Signed-in viewers get a personalised response, which must not be
+	// stored by shared caches.
+	if viewerID, ok := appctx.UserID(r.Context()); ok && h.friends != nil {
+		friends, err := h.friends.FriendsWatching(r.Context(), viewerID, id)
+		if err != nil {
+			httputil.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Cache-Control", "private, no-cache")
+		httputil.JSON(w, http.StatusOK, struct {
+			*Show
+			FriendsWatching []FriendWatching `json:"friends_watching"`
+		}{Show: show, FriendsWatching: friends})
+		return
+	}
+
 	httputil.JSONWithCache(w, r, http.StatusOK, show, 300, 3600)
 }
 
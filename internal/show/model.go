@@ -1,6 +1,7 @@
 package show
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,26 +10,39 @@ import (
 )
 
 type Show struct {
-	ID           uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Title        string         `gorm:"type:text;not null" json:"title"`
-	MediaType    string         `gorm:"type:text;not null" json:"media_type"`
-	Status       *string        `gorm:"type:text" json:"status"`
-	Overview     *string        `gorm:"type:text" json:"overview"`
-	PosterURL    *string        `gorm:"type:text" json:"poster_url"`
-	BackdropURL  *string        `gorm:"type:text" json:"backdrop_url"`
-	Genres       pq.StringArray `gorm:"type:text[]" json:"genres"`
-	Network      *string        `gorm:"type:text" json:"network"`
-	PremiereDate *time.Time     `gorm:"type:date" json:"premiere_date"`
-	TMDBID       *int           `gorm:"uniqueIndex" json:"tmdb_id"`
-	IMDBID       *string        `gorm:"uniqueIndex" json:"imdb_id"`
-	TheTVDBID    *int           `gorm:"uniqueIndex" json:"thetvdb_id"`
-	OMDBID       *string        `json:"omdb_id"`
-	LastSyncedAt time.Time      `json:"last_synced_at"`
-	SyncPriority int            `gorm:"not null;default:0" json:"sync_priority"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	Seasons      []Season       `gorm:"foreignKey:ShowID" json:"seasons"`
-	Ratings      datatypes.JSON `gorm:"type:jsonb" json:"ratings"`
+	ID                uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Title             string         `gorm:"type:text;not null" json:"title"`
+	AlternativeTitles pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"alternative_titles"`
+	MediaType         string         `gorm:"type:text;not null" json:"media_type"`
+	Status            *string        `gorm:"type:text" json:"status"`
+	Overview          *string        `gorm:"type:text" json:"overview"`
+	PosterURL         *string        `gorm:"type:text" json:"poster_url"`
+	BackdropURL       *string        `gorm:"type:text" json:"backdrop_url"`
+	Genres            pq.StringArray `gorm:"type:text[]" json:"genres"`
+	Network           *string        `gorm:"type:text" json:"network"`
+	PremiereDate      *time.Time     `gorm:"type:date" json:"premiere_date"`
+	TMDBID            *int           `gorm:"uniqueIndex" json:"tmdb_id"`
+	IMDBID            *string        `gorm:"uniqueIndex" json:"imdb_id"`
+	TheTVDBID         *int           `gorm:"uniqueIndex" json:"thetvdb_id"`
+	OMDBID            *string        `json:"omdb_id"`
+	LastSyncedAt      time.Time      `json:"last_synced_at"`
+	SyncPriority      int            `gorm:"not null;default:0" json:"sync_priority"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	Seasons           []Season       `gorm:"foreignKey:ShowID" json:"seasons"`
+	Ratings           datatypes.JSON `gorm:"type:jsonb" json:"ratings"`
+}
+
+// Rated returns the OMDb content rating (e.g. "PG-13", "TV-MA") stored with
+// the show's ratings, or "" when OMDb has not rated it.
+func (s *Show) Rated() string {
+	var ratings struct {
+		Rated string `json:"rated"`
+	}
+	if len(s.Ratings) == 0 || json.Unmarshal(s.Ratings, &ratings) != nil {
+		return ""
+	}
+	return ratings.Rated
 }
 
 type Season struct {
@@ -64,3 +78,12 @@ type Episode struct {
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 }
+
+// FriendWatching is a followed account that tracks a show, as shown on the
+// show detail page.
+type FriendWatching struct {
+	UserID             uuid.UUID `json:"user_id"`
+	Username           string    `json:"username"`
+	LastWatchedSeason  *int      `json:"last_watched_season,omitempty"`
+	LastWatchedEpisode *int      `json:"last_watched_episode,omitempty"`
+}
@@ -1,6 +1,9 @@
 package show
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,12 +19,12 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Create(show *Show) error {
-	return r.db.Create(show).Error
+func (r *Repository) Create(ctx context.Context, show *Show) error {
+	return r.db.WithContext(ctx).Create(show).Error
 }
 
-func (r *Repository) UpsertSearchResult(item *Show) error {
-	return r.db.Clauses(clause.OnConflict{
+func (r *Repository) UpsertSearchResult(ctx context.Context, item *Show) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns: []clause.Column{{Name: "tmdb_id"}},
 		DoUpdates: clause.AssignmentColumns([]string{
 			"title", "media_type", "overview", "poster_url", "backdrop_url", "premiere_date", "updated_at",
@@ -29,27 +32,34 @@ func (r *Repository) UpsertSearchResult(item *Show) error {
 	}, clause.Returning{Columns: []clause.Column{{Name: "id"}}}).Create(item).Error
 }
 
-func (r *Repository) FindByID(id uuid.UUID) (*Show, error) {
+func (r *Repository) FindByID(ctx context.Context, id uuid.UUID) (*Show, error) {
 	var show Show
-	if err := r.db.Preload("Seasons.Episodes").First(&show, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Seasons.Episodes").First(&show, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &show, nil
 }
 
-func (r *Repository) FindByTMDBID(tmdbID int) (*Show, error) {
+// Delete removes a show; seasons, episodes, tracking and timeline events
+// cascade. It reports whether the show existed.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&Show{}, "id = ?", id)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *Repository) FindByTMDBID(ctx context.Context, tmdbID int) (*Show, error) {
 	var show Show
-	if err := r.db.Preload("Seasons").First(&show, "tmdb_id = ?", tmdbID).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Seasons").First(&show, "tmdb_id = ?", tmdbID).Error; err != nil {
 		return nil, err
 	}
 	return &show, nil
 }
 
-func (r *Repository) Search(query string, mediaType string, limit int) ([]Show, error) {
+func (r *Repository) Search(ctx context.Context, query string, mediaType string, limit int) ([]Show, error) {
 	var shows []Show
 	// Use pg_trgm similarity
 	// Note: You need to ensure the extension is enabled and index exists
-	db := r.db.Where("similarity(title, ?) > 0.3", query).
+	db := r.db.WithContext(ctx).Where("similarity(title, ?) > 0.3", query).
 		Order(gorm.Expr("similarity(title, ?) DESC", query))
 
 	if mediaType != "" {
@@ -62,25 +72,25 @@ func (r *Repository) Search(query string, mediaType string, limit int) ([]Show,
 	return shows, nil
 }
 
-func (r *Repository) UpsertFromTMDB(show *Show) error {
+func (r *Repository) UpsertFromTMDB(ctx context.Context, show *Show) error {
 	// Use OnConflict to upsert based on tmdb_id
 	// GORM's Save/Updates might not be enough for deep upsert of seasons/episodes
 	// For simplicity in Phase 1, we might just check existence and update
 	// But proper way is using Clauses
-	return r.db.Save(show).Error
+	return r.db.WithContext(ctx).Save(show).Error
 }
 
-func (r *Repository) GetWithSeasons(id uuid.UUID) (*Show, error) {
+func (r *Repository) GetWithSeasons(ctx context.Context, id uuid.UUID) (*Show, error) {
 	var show Show
-	if err := r.db.Preload("Seasons").First(&show, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Seasons").First(&show, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &show, nil
 }
 
-func (r *Repository) GetSeasonWithEpisodes(showID uuid.UUID, seasonNum int) (*Season, error) {
+func (r *Repository) GetSeasonWithEpisodes(ctx context.Context, showID uuid.UUID, seasonNum int) (*Season, error) {
 	var season Season
-	if err := r.db.Preload("Episodes").
+	if err := r.db.WithContext(ctx).Preload("Episodes").
 		Where("show_id = ? AND season_number = ?", showID, seasonNum).
 		First(&season).Error; err != nil {
 		return nil, err
@@ -88,9 +98,9 @@ func (r *Repository) GetSeasonWithEpisodes(showID uuid.UUID, seasonNum int) (*Se
 	return &season, nil
 }
 
-func (r *Repository) GetEpisodes(showID uuid.UUID, upcoming bool) ([]Episode, error) {
+func (r *Repository) GetEpisodes(ctx context.Context, showID uuid.UUID, upcoming bool) ([]Episode, error) {
 	var episodes []Episode
-	db := r.db.Where("show_id = ?", showID)
+	db := r.db.WithContext(ctx).Where("show_id = ?", showID)
 	if upcoming {
 		db = db.Where("air_date >= CURRENT_DATE")
 	}
@@ -102,18 +112,18 @@ func (r *Repository) GetEpisodes(showID uuid.UUID, upcoming bool) ([]Episode, er
 	return episodes, nil
 }
 
-func (r *Repository) GetEpisodeByNumber(showID uuid.UUID, seasonNum, episodeNum int) (*Episode, error) {
+func (r *Repository) GetEpisodeByNumber(ctx context.Context, showID uuid.UUID, seasonNum, episodeNum int) (*Episode, error) {
 	var episode Episode
-	if err := r.db.Where("show_id = ? AND season_number = ? AND episode_number = ?", showID, seasonNum, episodeNum).
+	if err := r.db.WithContext(ctx).Where("show_id = ? AND season_number = ? AND episode_number = ?", showID, seasonNum, episodeNum).
 		First(&episode).Error; err != nil {
 		return nil, err
 	}
 	return &episode, nil
 }
 
-func (r *Repository) GetTrackedForSync(limit int) ([]Show, error) {
+func (r *Repository) GetTrackedForSync(ctx context.Context, limit int) ([]Show, error) {
 	var shows []Show
-	err := r.db.Table("shows").
+	err := r.db.WithContext(ctx).Table("shows").
 		Select("shows.*").
 		Joins("INNER JOIN user_tracked_shows uts ON uts.show_id = shows.id").
 		Group("shows.id").
@@ -123,60 +133,167 @@ func (r *Repository) GetTrackedForSync(limit int) ([]Show, error) {
 	return shows, err
 }
 
-func (r *Repository) FindByTMDBIDs(mediaType string, ids []int) ([]Show, error) {
+func (r *Repository) FindByTMDBIDs(ctx context.Context, mediaType string, ids []int) ([]Show, error) {
 	if len(ids) == 0 {
 		return []Show{}, nil
 	}
 	var shows []Show
-	err := r.db.Where("media_type = ? AND tmdb_id IN ?", mediaType, ids).Find(&shows).Error
+	err := r.db.WithContext(ctx).Where("media_type = ? AND tmdb_id IN ?", mediaType, ids).Find(&shows).Error
 	return shows, err
 }
 
-func (r *Repository) BumpSyncPriority(showID uuid.UUID) error {
-	return r.db.Model(&Show{}).Where("id = ?", showID).
+func (r *Repository) BumpSyncPriority(ctx context.Context, showID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&Show{}).Where("id = ?", showID).
 		UpdateColumn("sync_priority", gorm.Expr("sync_priority + 1")).Error
 }
 
-func (r *Repository) ResetUntrackedSyncPriorities(cutoff time.Time) (int64, error) {
-	result := r.db.Model(&Show{}).
+func (r *Repository) ResetUntrackedSyncPriorities(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&Show{}).
 		Where("sync_priority > 0 AND last_synced_at < ?", cutoff).
 		Where("NOT EXISTS (SELECT 1 FROM user_tracked_shows uts WHERE uts.show_id = shows.id)").
 		Update("sync_priority", 0)
 	return result.RowsAffected, result.Error
 }
 
-func (r *Repository) CountEpisodes(showID uuid.UUID) (int, error) {
+func (r *Repository) CountEpisodes(ctx context.Context, showID uuid.UUID) (int, error) {
 	var count int64
-	if err := r.db.Model(&Episode{}).Where("show_id = ?", showID).Count(&count).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&Episode{}).Where("show_id = ?", showID).Count(&count).Error; err != nil {
 		return 0, err
 	}
 	return int(count), nil
 }
 
-func (r *Repository) UpsertSeason(season *Season) error {
-	// Find existing or create
-	var existing Season
-	err := r.db.Where("show_id = ? AND season_number = ?", season.ShowID, season.SeasonNumber).First(&existing).Error
-	if err == nil {
-		season.ID = existing.ID
-		return r.db.Model(&existing).Updates(season).Error
-	}
-	if err == gorm.ErrRecordNotFound {
-		return r.db.Create(season).Error
-	}
-	return err
+// SeasonSync is one season and its episodes as fetched from a provider.
+type SeasonSync struct {
+	Season   *Season
+	Episodes []*Episode
 }
 
-func (r *Repository) UpsertEpisode(episode *Episode) (bool, error) {
-	var existing Episode
-	err := r.db.Where("show_id = ? AND season_number = ? AND episode_number = ?",
-		episode.ShowID, episode.SeasonNumber, episode.EpisodeNumber).First(&existing).Error
-	if err == nil {
-		episode.ID = existing.ID
-		return false, r.db.Model(&existing).Updates(episode).Error
+// EpisodeChanges lists the episodes an upsert wrote. Episodes whose stored
+// values already matched appear in neither list.
+type EpisodeChanges struct {
+	Inserted []*Episode
+	Updated  []*Episode
+}
+
+// ApplySync saves a synced show with all its seasons and episodes in one
+// transaction, so a failure part-way leaves the previous data intact.
+func (r *Repository) ApplySync(ctx context.Context, show *Show, seasons []SeasonSync) (*EpisodeChanges, error) {
+	changes := &EpisodeChanges{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(show).Error; err != nil {
+			return err
+		}
+		for _, s := range seasons {
+			if err := upsertSeason(tx, s.Season); err != nil {
+				return fmt.Errorf("season %d: %w", s.Season.SeasonNumber, err)
+			}
+			for _, ep := range s.Episodes {
+				ep.SeasonID = s.Season.ID
+			}
+			if err := upsertEpisodes(tx, s.Episodes, changes); err != nil {
+				return fmt.Errorf("season %d episodes: %w", s.Season.SeasonNumber, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if err == gorm.ErrRecordNotFound {
-		return true, r.db.Create(episode).Error
+	return changes, nil
+}
+
+// upsertSeason inserts or updates a season by number and sets its ID.
+// Fields the provider left empty keep their stored values.
+func upsertSeason(tx *gorm.DB, season *Season) error {
+	return tx.Raw(`
+		INSERT INTO seasons (show_id, season_number, name, overview, poster_url, air_date, episode_count, tmdb_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (show_id, season_number) DO UPDATE SET
+			name = COALESCE(EXCLUDED.name, seasons.name),
+			overview = COALESCE(EXCLUDED.overview, seasons.overview),
+			poster_url = COALESCE(EXCLUDED.poster_url, seasons.poster_url),
+			air_date = COALESCE(EXCLUDED.air_date, seasons.air_date),
+			episode_count = COALESCE(EXCLUDED.episode_count, seasons.episode_count),
+			tmdb_id = COALESCE(EXCLUDED.tmdb_id, seasons.tmdb_id),
+			updated_at = NOW()
+		RETURNING id
+	`, season.ShowID, season.SeasonNumber, season.Name, season.Overview, season.PosterURL,
+		season.AirDate, season.EpisodeCount, season.TMDBID).Row().Scan(&season.ID)
+}
+
+// episodeBatch keeps each statement well under Postgres's 65535 bind
+// parameters.
+const episodeBatch = 500
+
+// upsertEpisodes writes episodes in multi-row INSERT ... ON CONFLICT
+// statements. Rows whose values are unchanged are left alone, and xmax = 0
+// tells freshly inserted rows from updated ones.
+func upsertEpisodes(tx *gorm.DB, episodes []*Episode, changes *EpisodeChanges) error {
+	for start := 0; start < len(episodes); start += episodeBatch {
+		batch := episodes[start:min(start+episodeBatch, len(episodes))]
+
+		byNumber := make(map[[2]int]*Episode, len(batch))
+		rows := make([]string, 0, len(batch))
+		args := make([]interface{}, 0, len(batch)*10)
+		for _, ep := range batch {
+			// A row may only be touched once per statement.
+			number := [2]int{ep.SeasonNumber, ep.EpisodeNumber}
+			if _, dup := byNumber[number]; dup {
+				continue
+			}
+			byNumber[number] = ep
+			rows = append(rows, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+			args = append(args, ep.ShowID, ep.SeasonID, ep.SeasonNumber, ep.EpisodeNumber, ep.Title,
+				ep.Overview, ep.AirDate, ep.RuntimeMinutes, ep.StillURL, ep.TMDBID)
+		}
+
+		var written []struct {
+			ID            uuid.UUID
+			SeasonNumber  int
+			EpisodeNumber int
+			Inserted      bool
+		}
+		err := tx.Raw(`
+			INSERT INTO episodes (show_id, season_id, season_number, episode_number, title,
+				overview, air_date, runtime_minutes, still_url, tmdb_id)
+			VALUES `+strings.Join(rows, ", ")+`
+			ON CONFLICT (show_id, season_number, episode_number) DO UPDATE SET
+				season_id = EXCLUDED.season_id,
+				title = COALESCE(EXCLUDED.title, episodes.title),
+				overview = COALESCE(EXCLUDED.overview, episodes.overview),
+				air_date = COALESCE(EXCLUDED.air_date, episodes.air_date),
+				runtime_minutes = COALESCE(EXCLUDED.runtime_minutes, episodes.runtime_minutes),
+				still_url = COALESCE(EXCLUDED.still_url, episodes.still_url),
+				tmdb_id = COALESCE(EXCLUDED.tmdb_id, episodes.tmdb_id),
+				updated_at = NOW()
+			WHERE (episodes.season_id, episodes.title, episodes.overview, episodes.air_date,
+					episodes.runtime_minutes, episodes.still_url, episodes.tmdb_id)
+				IS DISTINCT FROM (EXCLUDED.season_id,
+					COALESCE(EXCLUDED.title, episodes.title),
+					COALESCE(EXCLUDED.overview, episodes.overview),
+					COALESCE(EXCLUDED.air_date, episodes.air_date),
+					COALESCE(EXCLUDED.runtime_minutes, episodes.runtime_minutes),
+					COALESCE(EXCLUDED.still_url, episodes.still_url),
+					COALESCE(EXCLUDED.tmdb_id, episodes.tmdb_id))
+			RETURNING id, season_number, episode_number, (xmax = 0) AS inserted
+		`, args...).Scan(&written).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range written {
+			ep, ok := byNumber[[2]int{row.SeasonNumber, row.EpisodeNumber}]
+			if !ok {
+				continue
+			}
+			ep.ID = row.ID
+			if row.Inserted {
+				changes.Inserted = append(changes.Inserted, ep)
+			} else {
+				changes.Updated = append(changes.Updated, ep)
+			}
+		}
 	}
-	return false, err
+	return nil
 }
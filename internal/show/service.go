@@ -6,22 +6,35 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 	"github.com/tashifkhan/bingebeacon/internal/metadata/tmdb"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
 )
 
+// discoveryCache keeps TMDB lists warm: a stale list is served while it
+// refreshes, and a TMDB failure is remembered briefly instead of retried by
+// every request.
+var discoveryCache = cache.Options{TTL: time.Hour, Stale: time.Hour, ErrorTTL: 30 * time.Second}
+
+// showCache tags a show's cached details so a sync can drop them together.
+func showCache(id uuid.UUID) cache.Options {
+	return cache.Options{
+		TTL: 15 * time.Minute, Stale: 15 * time.Minute,
+		Tags: []string{fmt.Sprintf("show:%s", id)},
+	}
+}
+
 type Service struct {
 	repo       *Repository
 	tmdbClient *tmdb.Client
-	redis      *redis.Client
+	cache      cache.Store
 }
 
-func NewService(repo *Repository, tmdbClient *tmdb.Client, rdb *redis.Client) *Service {
+func NewService(repo *Repository, tmdbClient *tmdb.Client, store cache.Store) *Service {
 	return &Service{
 		repo:       repo,
 		tmdbClient: tmdbClient,
-		redis:      rdb,
+		cache:      store,
 	}
 }
 
@@ -36,12 +49,15 @@ type ShowResult struct {
 }
 
 func (s *Service) Search(ctx context.Context, query string, mediaType string) ([]ShowResult, error) {
+	ctx, span := tracing.Start(ctx, "show.Search")
+	defer span.End()
+
 	// Cache key
 	key := fmt.Sprintf("search:%s:%s", query, mediaType)
 
-	return cache.GetOrSet(ctx, s.redis, key, 1*time.Hour, func() ([]ShowResult, error) {
+	return cache.GetOrSet(ctx, s.cache, key, 1*time.Hour, func(ctx context.Context) ([]ShowResult, error) {
 		// 1. Search local DB
-		localShows, err := s.repo.Search(query, mediaType, 10)
+		localShows, err := s.repo.Search(ctx, query, mediaType, 10)
 		if err != nil {
 			// Log error but continue to TMDB
 		}
@@ -86,7 +102,7 @@ func (s *Service) Search(ctx context.Context, query string, mediaType string) ([
 					}
 
 					stored := showFromSearchResult(item)
-					if err := s.repo.UpsertSearchResult(stored); err != nil {
+					if err := s.repo.UpsertSearchResult(ctx, stored); err != nil {
 						continue
 					}
 					results = append(results, mapSearchResult(stored, dateStr))
@@ -98,6 +114,9 @@ func (s *Service) Search(ctx context.Context, query string, mediaType string) ([
 }
 
 func (s *Service) GetTrending(ctx context.Context, mediaType, timeWindow string) ([]ShowResult, error) {
+	ctx, span := tracing.Start(ctx, "show.GetTrending")
+	defer span.End()
+
 	if mediaType == "" {
 		mediaType = "all"
 	}
@@ -111,16 +130,19 @@ func (s *Service) GetTrending(ctx context.Context, mediaType, timeWindow string)
 		return nil, fmt.Errorf("window must be day or week")
 	}
 	key := fmt.Sprintf("trending:%s:%s", mediaType, timeWindow)
-	return cache.GetOrSet(ctx, s.redis, key, time.Hour, func() ([]ShowResult, error) {
+	return cache.GetOrSetWith(ctx, s.cache, key, discoveryCache, func(ctx context.Context) ([]ShowResult, error) {
 		response, err := s.tmdbClient.GetTrending(ctx, mediaType, timeWindow)
 		if err != nil {
 			return nil, err
 		}
-		return s.persistDiscoveryResults(response.Results)
+		return s.persistDiscoveryResults(ctx, response.Results)
 	})
 }
 
 func (s *Service) GetPopular(ctx context.Context, mediaType string, page int) ([]ShowResult, error) {
+	ctx, span := tracing.Start(ctx, "show.GetPopular")
+	defer span.End()
+
 	if mediaType == "" {
 		mediaType = "tv"
 	}
@@ -131,16 +153,25 @@ func (s *Service) GetPopular(ctx context.Context, mediaType string, page int) ([
 		page = 1
 	}
 	key := fmt.Sprintf("popular:%s:%d", mediaType, page)
-	return cache.GetOrSet(ctx, s.redis, key, time.Hour, func() ([]ShowResult, error) {
+	return cache.GetOrSetWith(ctx, s.cache, key, discoveryCache, func(ctx context.Context) ([]ShowResult, error) {
 		response, err := s.tmdbClient.GetPopular(ctx, mediaType, page)
 		if err != nil {
 			return nil, err
 		}
-		return s.persistDiscoveryResults(response.Results)
+		return s.persistDiscoveryResults(ctx, response.Results)
 	})
 }
 
-func (s *Service) persistDiscoveryResults(items []tmdb.SearchResult) ([]ShowResult, error) {
+// SaveDiscoveryResults stores TMDB list results as local shows so callers
+// outside this package can hand out show IDs.
+func (s *Service) SaveDiscoveryResults(ctx context.Context, items []tmdb.SearchResult) ([]ShowResult, error) {
+	ctx, span := tracing.Start(ctx, "show.SaveDiscoveryResults")
+	defer span.End()
+
+	return s.persistDiscoveryResults(ctx, items)
+}
+
+func (s *Service) persistDiscoveryResults(ctx context.Context, items []tmdb.SearchResult) ([]ShowResult, error) {
 	results := make([]ShowResult, 0, len(items))
 	for _, item := range items {
 		if item.MediaType == "" {
@@ -155,7 +186,7 @@ func (s *Service) persistDiscoveryResults(items []tmdb.SearchResult) ([]ShowResu
 			continue
 		}
 		stored := showFromSearchResult(item)
-		if err := s.repo.UpsertSearchResult(stored); err != nil {
+		if err := s.repo.UpsertSearchResult(ctx, stored); err != nil {
 			return nil, err
 		}
 		date := item.FirstAirDate
@@ -193,32 +224,44 @@ func mapSearchResult(item *Show, date string) ShowResult {
 }
 
 func (s *Service) GetShow(ctx context.Context, id uuid.UUID) (*Show, error) {
+	ctx, span := tracing.Start(ctx, "show.GetShow")
+	defer span.End()
+
 	// Cache key: show:{id}
 	// Note: Show struct has nested slices (Seasons) which might need careful JSON handling
 	// but standard encoding/json should work fine.
 	key := fmt.Sprintf("show:%s", id.String())
-	return cache.GetOrSet(ctx, s.redis, key, 15*time.Minute, func() (*Show, error) {
-		return s.repo.GetWithSeasons(id)
+	return cache.GetOrSetWith(ctx, s.cache, key, showCache(id), func(ctx context.Context) (*Show, error) {
+		return s.repo.GetWithSeasons(ctx, id)
 	})
 }
 
 // ... other methods unchanged ...
 
 func (s *Service) GetSeason(ctx context.Context, showID uuid.UUID, seasonNum int) (*Season, error) {
+	ctx, span := tracing.Start(ctx, "show.GetSeason")
+	defer span.End()
+
 	key := fmt.Sprintf("season:%s:%d", showID, seasonNum)
-	return cache.GetOrSet(ctx, s.redis, key, 15*time.Minute, func() (*Season, error) {
-		return s.repo.GetSeasonWithEpisodes(showID, seasonNum)
+	return cache.GetOrSetWith(ctx, s.cache, key, showCache(showID), func(ctx context.Context) (*Season, error) {
+		return s.repo.GetSeasonWithEpisodes(ctx, showID, seasonNum)
 	})
 }
 
 func (s *Service) GetEpisodes(ctx context.Context, showID uuid.UUID, upcoming bool) ([]Episode, error) {
+	ctx, span := tracing.Start(ctx, "show.GetEpisodes")
+	defer span.End()
+
 	key := fmt.Sprintf("episodes:%s:%v", showID, upcoming)
-	return cache.GetOrSet(ctx, s.redis, key, 15*time.Minute, func() ([]Episode, error) {
-		return s.repo.GetEpisodes(showID, upcoming)
+	return cache.GetOrSetWith(ctx, s.cache, key, showCache(showID), func(ctx context.Context) ([]Episode, error) {
+		return s.repo.GetEpisodes(ctx, showID, upcoming)
 	})
 }
 
 func (s *Service) GetOrCreateByTMDBID(ctx context.Context, tmdbID int, mediaType string) (*Show, error) {
+	ctx, span := tracing.Start(ctx, "show.GetOrCreateByTMDBID")
+	defer span.End()
+
 	if mediaType == "" {
 		mediaType = "tv"
 	}
@@ -227,7 +270,7 @@ func (s *Service) GetOrCreateByTMDBID(ctx context.Context, tmdbID int, mediaType
 	}
 
 	// Check if exists
-	show, err := s.repo.FindByTMDBID(tmdbID)
+	show, err := s.repo.FindByTMDBID(ctx, tmdbID)
 	if err == nil {
 		if show.MediaType != mediaType {
 			return nil, fmt.Errorf("TMDB ID %d is already stored as %s", tmdbID, show.MediaType)
@@ -268,12 +311,12 @@ func (s *Service) GetOrCreateByTMDBID(ctx context.Context, tmdbID int, mediaType
 		}
 	}
 
-	if err := s.repo.UpsertFromTMDB(newShow); err != nil {
+	if err := s.repo.UpsertFromTMDB(ctx, newShow); err != nil {
 		return nil, err
 	}
 
 	// Retrieve to get the generated UUID
-	return s.repo.FindByTMDBID(tmdbID)
+	return s.repo.FindByTMDBID(ctx, tmdbID)
 }
 
 func parseTMDBDate(value string) *time.Time {
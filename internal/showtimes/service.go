@@ -6,20 +6,24 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 	"github.com/tashifkhan/bingebeacon/internal/metadata/movieglu"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
 	"github.com/tashifkhan/bingebeacon/internal/show"
 )
 
+// showtimesCache remembers MovieGlu failures briefly so a failing lookup
+// isn't repeated by every request.
+var showtimesCache = cache.Options{TTL: 5 * time.Minute, ErrorTTL: 30 * time.Second}
+
 type Service struct {
 	movieGlu *movieglu.Client
 	showRepo *show.Repository
-	redis    *redis.Client
+	cache    cache.Store
 }
 
-func NewService(client *movieglu.Client, showRepo *show.Repository, rdb *redis.Client) *Service {
-	return &Service{movieGlu: client, showRepo: showRepo, redis: rdb}
+func NewService(client *movieglu.Client, showRepo *show.Repository, store cache.Store) *Service {
+	return &Service{movieGlu: client, showRepo: showRepo, cache: store}
 }
 
 type ShowtimesResponse struct {
@@ -30,7 +34,10 @@ type ShowtimesResponse struct {
 }
 
 func (s *Service) GetShowtimes(ctx context.Context, showID uuid.UUID, date string, geolocation string) (*ShowtimesResponse, error) {
-	showRecord, err := s.showRepo.FindByID(showID)
+	ctx, span := tracing.Start(ctx, "showtimes.GetShowtimes")
+	defer span.End()
+
+	showRecord, err := s.showRepo.FindByID(ctx, showID)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +49,7 @@ func (s *Service) GetShowtimes(ctx context.Context, showID uuid.UUID, date strin
 	}
 
 	key := fmt.Sprintf("showtimes:%s:%s:%s", *showRecord.IMDBID, geolocation, date)
-	return cache.GetOrSet(ctx, s.redis, key, 5*time.Minute, func() (*ShowtimesResponse, error) {
+	return cache.GetOrSetWith(ctx, s.cache, key, showtimesCache, func(ctx context.Context) (*ShowtimesResponse, error) {
 		// MovieGlu requires a film_id, so resolve via search
 		search, err := s.movieGlu.FilmLiveSearch(ctx, showRecord.Title, geolocation)
 		if err != nil {
@@ -76,8 +83,11 @@ func (s *Service) GetShowtimes(ctx context.Context, showID uuid.UUID, date strin
 }
 
 func (s *Service) GetCinemasNearby(ctx context.Context, geolocation string) (*movieglu.CinemasNearbyResponse, error) {
+	ctx, span := tracing.Start(ctx, "showtimes.GetCinemasNearby")
+	defer span.End()
+
 	key := fmt.Sprintf("cinemas:%s", geolocation)
-	return cache.GetOrSet(ctx, s.redis, key, 5*time.Minute, func() (*movieglu.CinemasNearbyResponse, error) {
+	return cache.GetOrSetWith(ctx, s.cache, key, showtimesCache, func(ctx context.Context) (*movieglu.CinemasNearbyResponse, error) {
 		return s.movieGlu.CinemasNearby(ctx, geolocation)
 	})
 }
@@ -0,0 +1,208 @@
+package social
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/context"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/httputil"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) Follow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	follow, err := h.svc.Follow(r.Context(), userID, mux.Vars(r)["username"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, follow)
+}
+
+func (h *Handler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	if err := h.svc.Unfollow(r.Context(), userID, mux.Vars(r)["username"]); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	followers, err := h.svc.GetFollowers(r.Context(), userID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, followers)
+}
+
+func (h *Handler) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	following, err := h.svc.GetFollowing(r.Context(), userID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, following)
+}
+
+func (h *Handler) GetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	requests, err := h.svc.GetFollowRequests(r.Context(), userID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, requests)
+}
+
+func (h *Handler) ApproveRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	followerID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.svc.ApproveRequest(r.Context(), userID, followerID); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "Follow request approved"})
+}
+
+func (h *Handler) RemoveFollower(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	followerID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.svc.RemoveFollower(r.Context(), userID, followerID); err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	settings, err := h.svc.GetSettings(r.Context(), userID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	var req UpdateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	settings, err := h.svc.UpdateSettings(r.Context(), userID, req)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	var before time.Time
+	if beforeStr := r.URL.Query().Get("before"); beforeStr != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, beforeStr)
+		if err != nil {
+			httputil.Error(w, http.StatusBadRequest, "Invalid before timestamp")
+			return
+		}
+		before = parsed
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	revealSpoilers := r.URL.Query().Get("spoilers") == "show"
+
+	feed, err := h.svc.GetFeed(r.Context(), userID, before, limit, revealSpoilers)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, feed)
+}
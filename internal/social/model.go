@@ -0,0 +1,97 @@
+package social
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type FollowStatus string
+
+const (
+	FollowPending  FollowStatus = "pending"
+	FollowAccepted FollowStatus = "accepted"
+)
+
+type ActivityType string
+
+const (
+	ActivityWatched ActivityType = "watched"
+	ActivityRated   ActivityType = "rated"
+	ActivityTracked ActivityType = "tracked"
+	ActivityListed  ActivityType = "listed"
+)
+
+type Follow struct {
+	FollowerID uuid.UUID    `gorm:"type:uuid;primaryKey" json:"follower_id"`
+	FolloweeID uuid.UUID    `gorm:"type:uuid;primaryKey" json:"followee_id"`
+	Status     FollowStatus `gorm:"type:text;not null;default:'pending'" json:"status"`
+	CreatedAt  time.Time    `json:"created_at"`
+	AcceptedAt *time.Time   `json:"accepted_at,omitempty"`
+}
+
+// Settings holds a user's social privacy choices. Users without a row get
+// the defaults: a public account sharing every activity type.
+type Settings struct {
+	UserID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	IsPrivate     bool      `gorm:"not null;default:false" json:"is_private"`
+	ShareWatched  bool      `gorm:"not null;default:true" json:"share_watched"`
+	ShareRatings  bool      `gorm:"not null;default:true" json:"share_ratings"`
+	ShareTracking bool      `gorm:"not null;default:true" json:"share_tracking"`
+	ShareLists    bool      `gorm:"not null;default:true" json:"share_lists"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (Settings) TableName() string { return "social_settings" }
+
+type Activity struct {
+	ID            uuid.UUID    `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID        uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Type          ActivityType `gorm:"type:text;not null"`
+	ShowID        uuid.UUID    `gorm:"type:uuid;not null"`
+	SourceID      *uuid.UUID   `gorm:"type:uuid"`
+	SeasonNumber  *int
+	EpisodeNumber *int
+	Rating        *int
+	Notes         *string        `gorm:"type:text"`
+	ListName      *string        `gorm:"type:text"`
+	Metadata      datatypes.JSON `gorm:"type:jsonb;default:'{}'"`
+	CreatedAt     time.Time
+}
+
+type UpdateSettingsRequest struct {
+	IsPrivate     *bool `json:"is_private"`
+	ShareWatched  *bool `json:"share_watched"`
+	ShareRatings  *bool `json:"share_ratings"`
+	ShareTracking *bool `json:"share_tracking"`
+	ShareLists    *bool `json:"share_lists"`
+}
+
+type UserSummary struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+}
+
+type FollowResponse struct {
+	User      UserSummary  `json:"user"`
+	Status    FollowStatus `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type FeedItem struct {
+	ID            uuid.UUID    `json:"id"`
+	Actor         UserSummary  `json:"actor"`
+	Type          ActivityType `json:"type"`
+	ShowID        uuid.UUID    `json:"show_id"`
+	ShowTitle     string       `json:"show_title"`
+	PosterURL     string       `json:"poster_url"`
+	SeasonNumber  *int         `json:"season_number,omitempty"`
+	EpisodeNumber *int         `json:"episode_number,omitempty"`
+	EpisodeTitle  *string      `json:"episode_title,omitempty"`
+	Rating        *int         `json:"rating,omitempty"`
+	Notes         *string      `json:"notes,omitempty"`
+	ListName      *string      `json:"list_name,omitempty"`
+	Spoiler       bool         `json:"spoiler"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
@@ -0,0 +1,216 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/show"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) FindUserByUsername(ctx context.Context, username string) (*UserSummary, error) {
+	var u UserSummary
+	result := r.db.WithContext(ctx).Table("users").Select("id, username").Where("username = ?", username).Scan(&u)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &u, nil
+}
+
+func (r *Repository) GetSettings(ctx context.Context, userID uuid.UUID) (*Settings, error) {
+	settings := Settings{
+		UserID: userID, ShareWatched: true, ShareRatings: true, ShareTracking: true, ShareLists: true,
+	}
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&settings).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *Repository) SaveSettings(ctx context.Context, settings *Settings) error {
+	return r.db.WithContext(ctx).Save(settings).Error
+}
+
+func (r *Repository) FindFollow(ctx context.Context, followerID, followeeID uuid.UUID) (*Follow, error) {
+	var follow Follow
+	if err := r.db.WithContext(ctx).Where("follower_id = ? AND followee_id = ?", followerID, followeeID).First(&follow).Error; err != nil {
+		return nil, err
+	}
+	return &follow, nil
+}
+
+func (r *Repository) CreateFollow(ctx context.Context, follow *Follow) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(follow).Error
+}
+
+func (r *Repository) AcceptFollow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&Follow{}).
+		Where("follower_id = ? AND followee_id = ? AND status = ?", followerID, followeeID, FollowPending).
+		Updates(map[string]interface{}{"status": FollowAccepted, "accepted_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+// AcceptAllPending approves every outstanding request, used when an account
+// switches from private to public.
+func (r *Repository) AcceptAllPending(ctx context.Context, followeeID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&Follow{}).
+		Where("followee_id = ? AND status = ?", followeeID, FollowPending).
+		Updates(map[string]interface{}{"status": FollowAccepted, "accepted_at": time.Now()}).Error
+}
+
+func (r *Repository) DeleteFollow(ctx context.Context, followerID, followeeID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Delete(&Follow{}).Error
+}
+
+func (r *Repository) GetFollowers(ctx context.Context, userID uuid.UUID, status FollowStatus) ([]FollowResponse, error) {
+	var rows []followRow
+	err := r.db.WithContext(ctx).Table("follows").
+		Select("users.id AS user_id, users.username, follows.status, follows.created_at").
+		Joins("INNER JOIN users ON users.id = follows.follower_id").
+		Where("follows.followee_id = ? AND follows.status = ?", userID, status).
+		Order("follows.created_at DESC").
+		Scan(&rows).Error
+	return mapFollowRows(rows), err
+}
+
+func (r *Repository) GetFollowing(ctx context.Context, userID uuid.UUID) ([]FollowResponse, error) {
+	var rows []followRow
+	err := r.db.WithContext(ctx).Table("follows").
+		Select("users.id AS user_id, users.username, follows.status, follows.created_at").
+		Joins("INNER JOIN users ON users.id = follows.followee_id").
+		Where("follows.follower_id = ?", userID).
+		Order("follows.created_at DESC").
+		Scan(&rows).Error
+	return mapFollowRows(rows), err
+}
+
+func (r *Repository) CreateActivity(ctx context.Context, activity *Activity) error {
+	return r.db.WithContext(ctx).Create(activity).Error
+}
+
+func (r *Repository) DeleteActivitiesBySource(ctx context.Context, userID, sourceID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND source_id = ?", userID, sourceID).Delete(&Activity{}).Error
+}
+
+func (r *Repository) DeleteShowActivitiesBySource(ctx context.Context, userID, sourceID, showID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND source_id = ? AND show_id = ?", userID, sourceID, showID).
+		Delete(&Activity{}).Error
+}
+
+// privacyFilter keeps only activity types the actor has chosen to share.
+// Missing settings rows fall back to sharing everything.
+const privacyFilter = `(
+	(activities.type = 'watched' AND COALESCE(ss.share_watched, TRUE)) OR
+	(activities.type = 'rated' AND COALESCE(ss.share_ratings, TRUE)) OR
+	(activities.type = 'tracked' AND COALESCE(ss.share_tracking, TRUE)) OR
+	(activities.type = 'listed' AND COALESCE(ss.share_lists, TRUE))
+)`
+
+// GetFeed returns activities of accounts the viewer follows (accepted only),
+// newest first, strictly older than before.
+func (r *Repository) GetFeed(ctx context.Context, viewerID uuid.UUID, before time.Time, limit int) ([]feedRow, error) {
+	var rows []feedRow
+	err := r.db.WithContext(ctx).Table("activities").
+		Select(`activities.*, users.username, shows.title AS show_title, shows.poster_url,
+			episodes.title AS episode_title`).
+		Joins("INNER JOIN follows f ON f.followee_id = activities.user_id AND f.follower_id = ? AND f.status = ?", viewerID, FollowAccepted).
+		Joins("INNER JOIN users ON users.id = activities.user_id").
+		Joins("INNER JOIN shows ON shows.id = activities.show_id").
+		Joins(`LEFT JOIN episodes ON episodes.show_id = activities.show_id
+			AND episodes.season_number = activities.season_number
+			AND episodes.episode_number = activities.episode_number`).
+		Joins("LEFT JOIN social_settings ss ON ss.user_id = activities.user_id").
+		Where(privacyFilter).
+		Where("activities.created_at < ?", before).
+		Order("activities.created_at DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetWatchedEpisodes returns the viewer's logged episodes for the given shows
+// keyed by show, used to decide which feed items would spoil them.
+func (r *Repository) GetWatchedEpisodes(ctx context.Context, viewerID uuid.UUID, showIDs []uuid.UUID) (map[uuid.UUID]map[[2]int]bool, error) {
+	watched := make(map[uuid.UUID]map[[2]int]bool)
+	if len(showIDs) == 0 {
+		return watched, nil
+	}
+	var rows []struct {
+		ShowID        uuid.UUID
+		SeasonNumber  int
+		EpisodeNumber int
+	}
+	err := r.db.WithContext(ctx).Table("watch_history_entries").
+		Select("show_id, season_number, episode_number").
+		Where("user_id = ? AND show_id IN ?", viewerID, showIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if watched[row.ShowID] == nil {
+			watched[row.ShowID] = make(map[[2]int]bool)
+		}
+		watched[row.ShowID][[2]int{row.SeasonNumber, row.EpisodeNumber}] = true
+	}
+	return watched, nil
+}
+
+// GetFriendsTracking lists followed accounts that track showID and share
+// their tracking activity.
+func (r *Repository) GetFriendsTracking(ctx context.Context, viewerID, showID uuid.UUID) ([]show.FriendWatching, error) {
+	var friends []show.FriendWatching
+	// Several household profiles may track the same show; report the
+	// furthest one per account.
+	err := r.db.WithContext(ctx).Table("user_tracked_shows uts").
+		Select("DISTINCT ON (users.username) users.id AS user_id, users.username, uts.last_watched_season, uts.last_watched_episode").
+		Joins("INNER JOIN follows f ON f.followee_id = uts.user_id AND f.follower_id = ? AND f.status = ?", viewerID, FollowAccepted).
+		Joins("INNER JOIN users ON users.id = uts.user_id").
+		Joins("LEFT JOIN social_settings ss ON ss.user_id = uts.user_id").
+		Where("uts.show_id = ? AND COALESCE(ss.share_tracking, TRUE)", showID).
+		Order("users.username ASC, uts.last_watched_season DESC NULLS LAST, uts.last_watched_episode DESC NULLS LAST").
+		Scan(&friends).Error
+	return friends, err
+}
+
+type followRow struct {
+	UserID    uuid.UUID
+	Username  string
+	Status    FollowStatus
+	CreatedAt time.Time
+}
+
+type feedRow struct {
+	Activity
+	Username     string
+	ShowTitle    string
+	PosterURL    *string
+	EpisodeTitle *string
+}
+
+func mapFollowRows(rows []followRow) []FollowResponse {
+	resp := make([]FollowResponse, len(rows))
+	for i, row := range rows {
+		resp[i] = FollowResponse{
+			User:      UserSummary{ID: row.UserID, Username: row.Username},
+			Status:    row.Status,
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return resp
+}
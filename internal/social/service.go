@@ -0,0 +1,255 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
+	"github.com/tashifkhan/bingebeacon/internal/show"
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) Follow(ctx context.Context, followerID uuid.UUID, username string) (*FollowResponse, error) {
+	ctx, span := tracing.Start(ctx, "social.Follow")
+	defer span.End()
+
+	target, err := s.repo.FindUserByUsername(ctx, username)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if target.ID == followerID {
+		return nil, errors.New("you cannot follow yourself")
+	}
+	if existing, err := s.repo.FindFollow(ctx, followerID, target.ID); err == nil {
+		return &FollowResponse{User: *target, Status: existing.Status, CreatedAt: existing.CreatedAt}, nil
+	}
+
+	settings, err := s.repo.GetSettings(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	follow := &Follow{FollowerID: followerID, FolloweeID: target.ID, Status: FollowPending}
+	if !settings.IsPrivate {
+		now := time.Now()
+		follow.Status = FollowAccepted
+		follow.AcceptedAt = &now
+	}
+	if err := s.repo.CreateFollow(ctx, follow); err != nil {
+		return nil, err
+	}
+	return &FollowResponse{User: *target, Status: follow.Status, CreatedAt: follow.CreatedAt}, nil
+}
+
+// Unfollow removes an accepted follow or cancels a pending request.
+func (s *Service) Unfollow(ctx context.Context, followerID uuid.UUID, username string) error {
+	ctx, span := tracing.Start(ctx, "social.Unfollow")
+	defer span.End()
+
+	target, err := s.repo.FindUserByUsername(ctx, username)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	return s.repo.DeleteFollow(ctx, followerID, target.ID)
+}
+
+func (s *Service) GetFollowers(ctx context.Context, userID uuid.UUID) ([]FollowResponse, error) {
+	ctx, span := tracing.Start(ctx, "social.GetFollowers")
+	defer span.End()
+
+	return s.repo.GetFollowers(ctx, userID, FollowAccepted)
+}
+
+func (s *Service) GetFollowing(ctx context.Context, userID uuid.UUID) ([]FollowResponse, error) {
+	ctx, span := tracing.Start(ctx, "social.GetFollowing")
+	defer span.End()
+
+	return s.repo.GetFollowing(ctx, userID)
+}
+
+func (s *Service) GetFollowRequests(ctx context.Context, userID uuid.UUID) ([]FollowResponse, error) {
+	ctx, span := tracing.Start(ctx, "social.GetFollowRequests")
+	defer span.End()
+
+	return s.repo.GetFollowers(ctx, userID, FollowPending)
+}
+
+func (s *Service) ApproveRequest(ctx context.Context, userID, followerID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "social.ApproveRequest")
+	defer span.End()
+
+	approved, err := s.repo.AcceptFollow(ctx, followerID, userID)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return errors.New("follow request not found")
+	}
+	return nil
+}
+
+// RemoveFollower rejects a pending request or drops an accepted follower.
+func (s *Service) RemoveFollower(ctx context.Context, userID, followerID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "social.RemoveFollower")
+	defer span.End()
+
+	return s.repo.DeleteFollow(ctx, followerID, userID)
+}
+
+func (s *Service) GetSettings(ctx context.Context, userID uuid.UUID) (*Settings, error) {
+	ctx, span := tracing.Start(ctx, "social.GetSettings")
+	defer span.End()
+
+	return s.repo.GetSettings(ctx, userID)
+}
+
+func (s *Service) UpdateSettings(ctx context.Context, userID uuid.UUID, req UpdateSettingsRequest) (*Settings, error) {
+	ctx, span := tracing.Start(ctx, "social.UpdateSettings")
+	defer span.End()
+
+	settings, err := s.repo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	wasPrivate := settings.IsPrivate
+	if req.IsPrivate != nil {
+		settings.IsPrivate = *req.IsPrivate
+	}
+	if req.ShareWatched != nil {
+		settings.ShareWatched = *req.ShareWatched
+	}
+	if req.ShareRatings != nil {
+		settings.ShareRatings = *req.ShareRatings
+	}
+	if req.ShareTracking != nil {
+		settings.ShareTracking = *req.ShareTracking
+	}
+	if req.ShareLists != nil {
+		settings.ShareLists = *req.ShareLists
+	}
+	settings.UpdatedAt = time.Now()
+	if err := s.repo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	// A public account has nothing to approve, so waiting requests go through.
+	if wasPrivate && !settings.IsPrivate {
+		if err := s.repo.AcceptAllPending(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// GetFeed builds the viewer's activity feed. Unless revealSpoilers is set,
+// episode titles and notes are withheld for episodes the viewer has not
+// logged in their own history.
+func (s *Service) GetFeed(ctx context.Context, viewerID uuid.UUID, before time.Time, limit int, revealSpoilers bool) ([]FeedItem, error) {
+	ctx, span := tracing.Start(ctx, "social.GetFeed")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 30
+	}
+	if before.IsZero() {
+		before = time.Now()
+	}
+	rows, err := s.repo.GetFeed(ctx, viewerID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var watched map[uuid.UUID]map[[2]int]bool
+	if !revealSpoilers {
+		showIDs := make([]uuid.UUID, 0, len(rows))
+		seen := make(map[uuid.UUID]bool)
+		for _, row := range rows {
+			if !seen[row.ShowID] {
+				seen[row.ShowID] = true
+				showIDs = append(showIDs, row.ShowID)
+			}
+		}
+		watched, err = s.repo.GetWatchedEpisodes(ctx, viewerID, showIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]FeedItem, len(rows))
+	for i, row := range rows {
+		item := FeedItem{
+			ID:            row.ID,
+			Actor:         UserSummary{ID: row.UserID, Username: row.Username},
+			Type:          row.Type,
+			ShowID:        row.ShowID,
+			ShowTitle:     row.ShowTitle,
+			PosterURL:     show.SafeString(row.PosterURL),
+			SeasonNumber:  row.SeasonNumber,
+			EpisodeNumber: row.EpisodeNumber,
+			EpisodeTitle:  row.EpisodeTitle,
+			Rating:        row.Rating,
+			Notes:         row.Notes,
+			ListName:      row.ListName,
+			CreatedAt:     row.CreatedAt,
+		}
+		if !revealSpoilers && isSpoiler(row.Activity, watched[row.ShowID]) {
+			item.Spoiler = true
+			item.EpisodeTitle = nil
+			item.Notes = nil
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// Record stores an activity for the feed. Callers treat failures as
+// non-fatal; the feed is best-effort and must never block the user action.
+func (s *Service) Record(ctx context.Context, activity *Activity) error {
+	ctx, span := tracing.Start(ctx, "social.Record")
+	defer span.End()
+
+	return s.repo.CreateActivity(ctx, activity)
+}
+
+// RemoveBySource deletes activities created from a record that no longer
+// exists, e.g. a deleted history entry.
+func (s *Service) RemoveBySource(ctx context.Context, userID, sourceID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "social.RemoveBySource")
+	defer span.End()
+
+	return s.repo.DeleteActivitiesBySource(ctx, userID, sourceID)
+}
+
+// RemoveShowBySource deletes one show's activities from a source that holds
+// several, e.g. a show taken off a list.
+func (s *Service) RemoveShowBySource(ctx context.Context, userID, sourceID, showID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "social.RemoveShowBySource")
+	defer span.End()
+
+	return s.repo.DeleteShowActivitiesBySource(ctx, userID, sourceID, showID)
+}
+
+// FriendsWatching implements show.FriendsFinder.
+func (s *Service) FriendsWatching(ctx context.Context, viewerID, showID uuid.UUID) ([]show.FriendWatching, error) {
+	ctx, span := tracing.Start(ctx, "social.FriendsWatching")
+	defer span.End()
+
+	return s.repo.GetFriendsTracking(ctx, viewerID, showID)
+}
+
+func isSpoiler(activity Activity, watched map[[2]int]bool) bool {
+	if activity.Type != ActivityWatched && activity.Type != ActivityRated {
+		return false
+	}
+	if activity.SeasonNumber == nil || activity.EpisodeNumber == nil {
+		return false
+	}
+	return !watched[[2]int{*activity.SeasonNumber, *activity.EpisodeNumber}]
+}
@@ -8,20 +8,20 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 	"github.com/tashifkhan/bingebeacon/internal/metadata/tmdb"
 	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
 	"github.com/tashifkhan/bingebeacon/internal/show"
 )
 
 type Service struct {
 	showRepo   *show.Repository
 	tmdbClient *tmdb.Client
-	redis      *redis.Client
+	cache      cache.Store
 }
 
-func NewService(showRepo *show.Repository, tmdbClient *tmdb.Client, rdb *redis.Client) *Service {
-	return &Service{showRepo: showRepo, tmdbClient: tmdbClient, redis: rdb}
+func NewService(showRepo *show.Repository, tmdbClient *tmdb.Client, store cache.Store) *Service {
+	return &Service{showRepo: showRepo, tmdbClient: tmdbClient, cache: store}
 }
 
 type StreamingResponse struct {
@@ -34,12 +34,15 @@ type StreamingResponse struct {
 }
 
 func (s *Service) GetStreaming(ctx context.Context, showID uuid.UUID, region string) (*StreamingResponse, error) {
+	ctx, span := tracing.Start(ctx, "streaming.GetStreaming")
+	defer span.End()
+
 	if region == "" {
 		region = "IN"
 	}
 	region = strings.ToUpper(region)
 
-	showRecord, err := s.showRepo.FindByID(showID)
+	showRecord, err := s.showRepo.FindByID(ctx, showID)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +55,11 @@ func (s *Service) GetStreaming(ctx context.Context, showID uuid.UUID, region str
 	}
 
 	key := fmt.Sprintf("streaming:%s:%s", showRecord.ID, region)
-	return cache.GetOrSet(ctx, s.redis, key, 12*time.Hour, func() (*StreamingResponse, error) {
+	opts := cache.Options{
+		TTL: 12 * time.Hour, Stale: 12 * time.Hour, ErrorTTL: time.Minute,
+		Tags: []string{fmt.Sprintf("show:%s", showRecord.ID)},
+	}
+	return cache.GetOrSetWith(ctx, s.cache, key, opts, func(ctx context.Context) (*StreamingResponse, error) {
 		resp, err := s.tmdbClient.GetWatchProviders(ctx, mediaType, *showRecord.TMDBID)
 		if err != nil {
 			return nil, err
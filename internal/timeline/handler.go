@@ -2,6 +2,7 @@ package timeline
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/tashifkhan/bingebeacon/internal/pkg/context"
@@ -17,7 +18,7 @@ func NewHandler(svc *Service) *Handler {
 }
 
 func (h *Handler) GetTimeline(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
@@ -50,7 +51,7 @@ func (h *Handler) GetTimeline(w http.ResponseWriter, r *http.Request) {
 		to = time.Now().Add(24 * time.Hour) // Default next 24h
 	}
 
-	events, err := h.svc.GetTimeline(r.Context(), userID, from, to, eventType)
+	events, err := h.svc.GetTimeline(r.Context(), profileID, from, to, eventType)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -59,14 +60,64 @@ func (h *Handler) GetTimeline(w http.ResponseWriter, r *http.Request) {
 	httputil.JSON(w, http.StatusOK, events)
 }
 
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+	profileID, _ := context.ProfileID(r.Context())
+
+	q := r.URL.Query()
+	query := EventQuery{
+		Filter: Filter{
+			EventType: q.Get("type"),
+			Network:   q.Get("network"),
+			Genres:    q["genre"],
+		},
+		Month:  q.Get("month"),
+		Cursor: q.Get("cursor"),
+	}
+
+	var err error
+	if raw := q.Get("from"); raw != "" {
+		if query.From, err = time.Parse(time.RFC3339, raw); err != nil {
+			httputil.Error(w, http.StatusBadRequest, "Invalid from date format")
+			return
+		}
+	}
+	if raw := q.Get("to"); raw != "" {
+		if query.To, err = time.Parse(time.RFC3339, raw); err != nil {
+			httputil.Error(w, http.StatusBadRequest, "Invalid to date format")
+			return
+		}
+	}
+	if raw := q.Get("favorites"); raw != "" {
+		if query.FavoritesOnly, err = strconv.ParseBool(raw); err != nil {
+			httputil.Error(w, http.StatusBadRequest, "Invalid favorites")
+			return
+		}
+	}
+	query.Limit, _ = strconv.Atoi(q.Get("limit"))
+
+	page, err := h.svc.ListEvents(r.Context(), userID, profileID, query)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, page)
+}
+
 func (h *Handler) GetToday(w http.ResponseWriter, r *http.Request) {
 	userID, ok := context.UserID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
-	events, err := h.svc.GetToday(r.Context(), userID)
+	events, err := h.svc.GetToday(r.Context(), userID, profileID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -81,8 +132,9 @@ func (h *Handler) GetThisWeek(w http.ResponseWriter, r *http.Request) {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
-	events, err := h.svc.GetThisWeek(r.Context(), userID)
+	events, err := h.svc.GetThisWeek(r.Context(), userID, profileID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -92,13 +144,13 @@ func (h *Handler) GetThisWeek(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetUpcoming(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
 
-	events, err := h.svc.GetUpcoming(r.Context(), userID)
+	events, err := h.svc.GetUpcoming(r.Context(), profileID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
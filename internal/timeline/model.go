@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/tashifkhan/bingebeacon/internal/show"
 	"gorm.io/datatypes"
 )
@@ -22,3 +23,50 @@ type TimelineEvent struct {
 	CreatedAt     time.Time
 	Show          show.Show `gorm:"foreignKey:ShowID"`
 }
+
+// ProfileTimelineEntry is one event on a profile's precomputed timeline,
+// with the show fields needed to filter and render it. Rows are maintained
+// by database triggers; see migration 020.
+type ProfileTimelineEntry struct {
+	ProfileID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	EventID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ShowID        uuid.UUID `gorm:"type:uuid"`
+	EventType     string    `gorm:"type:text"`
+	Title         string    `gorm:"type:text"`
+	Description   *string   `gorm:"type:text"`
+	EventDate     time.Time
+	SeasonNumber  *int
+	EpisodeNumber *int
+	Metadata      datatypes.JSON `gorm:"type:jsonb"`
+	IsFavorite    bool
+	ShowTitle     string         `gorm:"type:text"`
+	ShowPosterURL *string        `gorm:"type:text"`
+	Network       *string        `gorm:"type:text"`
+	Genres        pq.StringArray `gorm:"type:text[]"`
+}
+
+func (ProfileTimelineEntry) TableName() string {
+	return "profile_timeline"
+}
+
+// Filter narrows a profile's timeline. From is inclusive and To exclusive;
+// a zero To leaves the range open.
+type Filter struct {
+	From          time.Time
+	To            time.Time
+	EventType     string
+	FavoritesOnly bool
+	Network       string
+	Genres        []string
+}
+
+// cursor is the keyset position of the last event on a page.
+type cursor struct {
+	Date time.Time `json:"d"`
+	ID   uuid.UUID `json:"i"`
+}
+
+type EventPage struct {
+	Events     []TimelineEventResponse `json:"events"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
@@ -1,9 +1,11 @@
 package timeline
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
@@ -15,50 +17,70 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Create(event *TimelineEvent) error {
-	return r.db.Create(event).Error
+func (r *Repository) Create(ctx context.Context, event *TimelineEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
 }
 
-func (r *Repository) FindByShowAndEpisode(showID, episodeID uuid.UUID) (*TimelineEvent, error) {
+// Reschedule moves an event; triggers carry the new date to every profile
+// timeline that lists it.
+func (r *Repository) Reschedule(ctx context.Context, eventID uuid.UUID, date time.Time) error {
+	return r.db.WithContext(ctx).Model(&TimelineEvent{}).Where("id = ?", eventID).Update("event_date", date).Error
+}
+
+func (r *Repository) FindByShowAndEpisode(ctx context.Context, showID, episodeID uuid.UUID) (*TimelineEvent, error) {
 	var event TimelineEvent
-	if err := r.db.Where("show_id = ? AND episode_id = ?", showID, episodeID).First(&event).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("show_id = ? AND episode_id = ?", showID, episodeID).First(&event).Error; err != nil {
 		return nil, err
 	}
 	return &event, nil
 }
 
-func (r *Repository) FindByShowTypeAndDate(showID uuid.UUID, eventType string, eventDate time.Time) (*TimelineEvent, error) {
+func (r *Repository) FindByShowTypeAndDate(ctx context.Context, showID uuid.UUID, eventType string, eventDate time.Time) (*TimelineEvent, error) {
 	var event TimelineEvent
-	if err := r.db.Where("show_id = ? AND event_type = ? AND event_date = ?", showID, eventType, eventDate).
+	if err := r.db.WithContext(ctx).Where("show_id = ? AND event_type = ? AND event_date = ?", showID, eventType, eventDate).
 		First(&event).Error; err != nil {
 		return nil, err
 	}
 	return &event, nil
 }
 
-func (r *Repository) GetUserTimeline(userID uuid.UUID, from, to time.Time, eventType string) ([]TimelineEvent, error) {
-	var events []TimelineEvent
-
-	query := r.db.Table("timeline_events").
-		Select("timeline_events.*").
-		Joins("INNER JOIN user_tracked_shows uts ON uts.show_id = timeline_events.show_id").
-		Where("uts.user_id = ?", userID).
-		Where("timeline_events.event_date >= ? AND timeline_events.event_date < ?", from, to).
-		Preload("Show")
-
-	if eventType != "" {
-		query = query.Where("timeline_events.event_type = ?", eventType)
+// ListEntries reads a profile's precomputed timeline in date order,
+// starting after the given keyset position. limit <= 0 returns every match.
+func (r *Repository) ListEntries(ctx context.Context, profileID uuid.UUID, f Filter, after *cursor, limit int) ([]ProfileTimelineEntry, error) {
+	db := r.db.WithContext(ctx).Where("profile_id = ? AND event_date >= ?", profileID, f.From)
+	if !f.To.IsZero() {
+		db = db.Where("event_date < ?", f.To)
+	}
+	if f.EventType != "" {
+		db = db.Where("event_type = ?", f.EventType)
+	}
+	if f.FavoritesOnly {
+		db = db.Where("is_favorite")
+	}
+	if f.Network != "" {
+		db = db.Where("network = ?", f.Network)
+	}
+	if len(f.Genres) > 0 {
+		db = db.Where("genres @> ?", pq.StringArray(f.Genres))
+	}
+	if after != nil {
+		db = db.Where("(event_date, event_id) > (?, ?)", after.Date, after.ID)
+	}
+	db = db.Order("event_date ASC, event_id ASC")
+	if limit > 0 {
+		db = db.Limit(limit)
 	}
 
-	query = query.Order("timeline_events.event_date ASC")
+	var entries []ProfileTimelineEntry
+	err := db.Find(&entries).Error
+	return entries, err
+}
 
-	if err := query.Find(&events).Error; err != nil {
-		return nil, err
-	}
-	return events, nil
+func (r *Repository) GetProfileTimeline(ctx context.Context, profileID uuid.UUID, from, to time.Time, eventType string) ([]ProfileTimelineEntry, error) {
+	return r.ListEntries(ctx, profileID, Filter{From: from, To: to, EventType: eventType}, nil, 0)
 }
 
-func (r *Repository) GetTodayEvents(userID uuid.UUID, tz string) ([]TimelineEvent, error) {
+func (r *Repository) GetTodayEvents(ctx context.Context, profileID uuid.UUID, tz string) ([]ProfileTimelineEntry, error) {
 	location, err := time.LoadLocation(tz)
 	if err != nil {
 		location = time.UTC
@@ -66,10 +88,10 @@ func (r *Repository) GetTodayEvents(userID uuid.UUID, tz string) ([]TimelineEven
 	now := time.Now().In(location)
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
 	endOfDay := startOfDay.AddDate(0, 0, 1)
-	return r.GetUserTimeline(userID, startOfDay, endOfDay, "")
+	return r.GetProfileTimeline(ctx, profileID, startOfDay, endOfDay, "")
 }
 
-func (r *Repository) GetWeekEvents(userID uuid.UUID, tz string) ([]TimelineEvent, error) {
+func (r *Repository) GetWeekEvents(ctx context.Context, profileID uuid.UUID, tz string) ([]ProfileTimelineEntry, error) {
 	location, err := time.LoadLocation(tz)
 	if err != nil {
 		location = time.UTC
@@ -77,12 +99,12 @@ func (r *Repository) GetWeekEvents(userID uuid.UUID, tz string) ([]TimelineEvent
 	now := time.Now().In(location)
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
 	endOfWeek := startOfDay.AddDate(0, 0, 7)
-	return r.GetUserTimeline(userID, startOfDay, endOfWeek, "")
+	return r.GetProfileTimeline(ctx, profileID, startOfDay, endOfWeek, "")
 }
 
-func (r *Repository) GetUserTimezone(userID uuid.UUID) string {
+func (r *Repository) GetUserTimezone(ctx context.Context, userID uuid.UUID) string {
 	var timezone string
-	if err := r.db.Table("users").Select("timezone").Where("id = ?", userID).Scan(&timezone).Error; err != nil || timezone == "" {
+	if err := r.db.WithContext(ctx).Table("users").Select("timezone").Where("id = ?", userID).Scan(&timezone).Error; err != nil || timezone == "" {
 		return "UTC"
 	}
 	if _, err := time.LoadLocation(timezone); err != nil {
@@ -91,16 +113,16 @@ func (r *Repository) GetUserTimezone(userID uuid.UUID) string {
 	return timezone
 }
 
-func (r *Repository) GetUpcomingEvents(userID uuid.UUID, days int) ([]TimelineEvent, error) {
+func (r *Repository) GetUpcomingEvents(ctx context.Context, profileID uuid.UUID, days int) ([]ProfileTimelineEntry, error) {
 	now := time.Now().UTC()
 	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 	end := start.Add(time.Duration(days) * 24 * time.Hour)
-	return r.GetUserTimeline(userID, start, end, "")
+	return r.GetProfileTimeline(ctx, profileID, start, end, "")
 }
 
-func (r *Repository) GetShowUpcomingEvents(showID uuid.UUID, from time.Time) ([]TimelineEvent, error) {
+func (r *Repository) GetShowUpcomingEvents(ctx context.Context, showID uuid.UUID, from time.Time) ([]TimelineEvent, error) {
 	var events []TimelineEvent
-	err := r.db.Where("show_id = ? AND event_date >= ?", showID, from).
+	err := r.db.WithContext(ctx).Where("show_id = ? AND event_date >= ?", showID, from).
 		Order("event_date ASC").Find(&events).Error
 	return events, err
 }
@@ -2,31 +2,43 @@ package timeline
 
 import (
 	"context"
-	"fmt"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
-	"github.com/tashifkhan/bingebeacon/internal/pkg/cache"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
 	"gorm.io/datatypes"
 )
 
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+var eventTypes = map[string]bool{
+	"new_episode": true, "season_premiere": true, "season_finale": true,
+	"series_premiere": true, "series_finale": true, "movie_release": true, "status_change": true,
+}
+
+// Reads come from the precomputed profile_timeline table, which triggers
+// keep current, so they are a single index range scan and aren't cached.
 type Service struct {
-	repo  *Repository
-	redis *redis.Client
+	repo *Repository
 }
 
-func NewService(repo *Repository, rdb *redis.Client) *Service {
-	return &Service{
-		repo:  repo,
-		redis: rdb,
-	}
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
 }
 
 type TimelineEventResponse struct {
 	ID            uuid.UUID      `json:"id"`
 	ShowID        uuid.UUID      `json:"show_id"`
 	ShowTitle     string         `json:"show_title"`
+	ShowPosterURL *string        `json:"show_poster_url,omitempty"`
+	Network       *string        `json:"network,omitempty"`
+	IsFavorite    bool           `json:"is_favorite"`
 	EventType     string         `json:"event_type"`
 	Title         string         `json:"title"`
 	Description   string         `json:"description"`
@@ -36,67 +48,131 @@ type TimelineEventResponse struct {
 	Metadata      datatypes.JSON `json:"metadata,omitempty"`
 }
 
-func (s *Service) GetTimeline(ctx context.Context, userID uuid.UUID, from, to time.Time, eventType string) ([]TimelineEventResponse, error) {
-	key := fmt.Sprintf("timeline:%s:range:%s:%s:%s", userID, from.Format("2006-01-02"), to.Format("2006-01-02"), eventType)
-	return cache.GetOrSet(ctx, s.redis, key, 2*time.Minute, func() ([]TimelineEventResponse, error) {
-		events, err := s.repo.GetUserTimeline(userID, from, to, eventType)
-		if err != nil {
-			return nil, err
-		}
-		return s.mapEvents(events), nil
-	})
+// EventQuery selects a page of a profile's timeline. Month (YYYY-MM) picks
+// that calendar month in the user's time zone and overrides From and To;
+// with neither, the page starts at the beginning of today.
+type EventQuery struct {
+	Filter
+	Month  string
+	Cursor string
+	Limit  int
 }
 
-func (s *Service) GetToday(ctx context.Context, userID uuid.UUID) ([]TimelineEventResponse, error) {
-	timezone := s.repo.GetUserTimezone(userID)
-	key := fmt.Sprintf("timeline:%s:today:%s", userID, timezone)
-	return cache.GetOrSet(ctx, s.redis, key, 2*time.Minute, func() ([]TimelineEventResponse, error) {
-		events, err := s.repo.GetTodayEvents(userID, timezone)
-		if err != nil {
-			return nil, err
+func (s *Service) ListEvents(ctx context.Context, userID, profileID uuid.UUID, q EventQuery) (*EventPage, error) {
+	ctx, span := tracing.Start(ctx, "timeline.ListEvents")
+	defer span.End()
+
+	if q.EventType != "" && !eventTypes[q.EventType] {
+		return nil, errors.New("unknown event type")
+	}
+	f := q.Filter
+	if q.Month != "" || f.From.IsZero() {
+		location, _ := time.LoadLocation(s.repo.GetUserTimezone(ctx, userID))
+		if q.Month != "" {
+			start, err := time.ParseInLocation("2006-01", q.Month, location)
+			if err != nil {
+				return nil, errors.New("month must be formatted YYYY-MM")
+			}
+			f.From, f.To = start, start.AddDate(0, 1, 0)
+		} else {
+			now := time.Now().In(location)
+			f.From = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
 		}
-		return s.mapEvents(events), nil
-	})
-}
+	}
+	if !f.To.IsZero() && !f.To.After(f.From) {
+		return nil, errors.New("to must be after from")
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
 
-func (s *Service) GetThisWeek(ctx context.Context, userID uuid.UUID) ([]TimelineEventResponse, error) {
-	timezone := s.repo.GetUserTimezone(userID)
-	key := fmt.Sprintf("timeline:%s:week:%s", userID, timezone)
-	return cache.GetOrSet(ctx, s.redis, key, 2*time.Minute, func() ([]TimelineEventResponse, error) {
-		events, err := s.repo.GetWeekEvents(userID, timezone)
+	var after *cursor
+	if q.Cursor != "" {
+		decoded, err := decodeCursor(q.Cursor)
 		if err != nil {
 			return nil, err
 		}
-		return s.mapEvents(events), nil
-	})
+		after = decoded
+	}
+
+	entries, err := s.repo.ListEntries(ctx, profileID, f, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &EventPage{Events: s.mapEntries(entries)}
+	if len(entries) > limit {
+		page.Events = page.Events[:limit]
+		last := entries[limit-1]
+		page.NextCursor = encodeCursor(cursor{Date: last.EventDate, ID: last.EventID})
+	}
+	return page, nil
 }
 
-func (s *Service) GetUpcoming(ctx context.Context, userID uuid.UUID) ([]TimelineEventResponse, error) {
-	key := fmt.Sprintf("timeline:%s:upcoming", userID)
-	return cache.GetOrSet(ctx, s.redis, key, 2*time.Minute, func() ([]TimelineEventResponse, error) {
-		events, err := s.repo.GetUpcomingEvents(userID, 30)
-		if err != nil {
-			return nil, err
-		}
-		return s.mapEvents(events), nil
-	})
+func (s *Service) GetTimeline(ctx context.Context, profileID uuid.UUID, from, to time.Time, eventType string) ([]TimelineEventResponse, error) {
+	ctx, span := tracing.Start(ctx, "timeline.GetTimeline")
+	defer span.End()
+
+	entries, err := s.repo.GetProfileTimeline(ctx, profileID, from, to, eventType)
+	if err != nil {
+		return nil, err
+	}
+	return s.mapEntries(entries), nil
+}
+
+func (s *Service) GetToday(ctx context.Context, userID, profileID uuid.UUID) ([]TimelineEventResponse, error) {
+	ctx, span := tracing.Start(ctx, "timeline.GetToday")
+	defer span.End()
+
+	timezone := s.repo.GetUserTimezone(ctx, userID)
+	entries, err := s.repo.GetTodayEvents(ctx, profileID, timezone)
+	if err != nil {
+		return nil, err
+	}
+	return s.mapEntries(entries), nil
+}
+
+func (s *Service) GetThisWeek(ctx context.Context, userID, profileID uuid.UUID) ([]TimelineEventResponse, error) {
+	ctx, span := tracing.Start(ctx, "timeline.GetThisWeek")
+	defer span.End()
+
+	timezone := s.repo.GetUserTimezone(ctx, userID)
+	entries, err := s.repo.GetWeekEvents(ctx, profileID, timezone)
+	if err != nil {
+		return nil, err
+	}
+	return s.mapEntries(entries), nil
 }
 
-func (s *Service) mapEvents(events []TimelineEvent) []TimelineEventResponse {
-	resp := make([]TimelineEventResponse, len(events))
-	for i, e := range events {
+func (s *Service) GetUpcoming(ctx context.Context, profileID uuid.UUID) ([]TimelineEventResponse, error) {
+	ctx, span := tracing.Start(ctx, "timeline.GetUpcoming")
+	defer span.End()
+
+	entries, err := s.repo.GetUpcomingEvents(ctx, profileID, 30)
+	if err != nil {
+		return nil, err
+	}
+	return s.mapEntries(entries), nil
+}
+
+func (s *Service) mapEntries(entries []ProfileTimelineEntry) []TimelineEventResponse {
+	resp := make([]TimelineEventResponse, len(entries))
+	for i, e := range entries {
 		desc := ""
 		if e.Description != nil {
 			desc = *e.Description
 		}
-
-		// Fill show title if not present in event title but show is loaded
-		// Note: Event title usually contains episode title. Show title is in e.Show.Title
-
 		resp[i] = TimelineEventResponse{
-			ID:            e.ID,
+			ID:            e.EventID,
 			ShowID:        e.ShowID,
-			ShowTitle:     e.Show.Title,
+			ShowTitle:     e.ShowTitle,
+			ShowPosterURL: e.ShowPosterURL,
+			Network:       e.Network,
+			IsFavorite:    e.IsFavorite,
 			EventType:     e.EventType,
 			Title:         e.Title,
 			Description:   desc,
@@ -108,3 +184,20 @@ func (s *Service) mapEvents(events []TimelineEvent) []TimelineEventResponse {
 	}
 	return resp
 }
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(token string) (*cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
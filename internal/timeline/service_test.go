@@ -0,0 +1,24 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := cursor{Date: time.Date(2026, 3, 14, 21, 0, 0, 123456000, time.UTC), ID: uuid.New()}
+
+	got, err := decodeCursor(encodeCursor(want))
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if !got.Date.Equal(want.Date) || got.ID != want.ID {
+		t.Fatalf("got %+v, want %+v", *got, want)
+	}
+
+	if _, err := decodeCursor("not a cursor"); err == nil {
+		t.Fatal("expected error for malformed cursor")
+	}
+}
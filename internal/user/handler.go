@@ -2,6 +2,7 @@ package user
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -25,7 +26,7 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	profile, err := h.svc.GetProfile(userID)
+	profile, err := h.svc.GetProfile(r.Context(), userID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -47,7 +48,7 @@ func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.UpdateProfile(userID, req); err != nil {
+	if err := h.svc.UpdateProfile(r.Context(), userID, req); err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -62,13 +63,15 @@ func (h *Handler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	profileID, _ := context.ProfileID(r.Context())
+
 	var req RegisterDeviceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if err := h.svc.RegisterDevice(userID, req); err != nil {
+	if err := h.svc.RegisterDevice(r.Context(), userID, profileID, req); err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -91,10 +94,107 @@ func (h *Handler) UnregisterDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.UnregisterDevice(userID, deviceID); err != nil {
+	if err := h.svc.UnregisterDevice(r.Context(), userID, deviceID); err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	profiles, err := h.svc.ListProfiles(r.Context(), userID)
+	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	httputil.JSON(w, http.StatusOK, profiles)
+}
+
+func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+	profileID, _ := context.ProfileID(r.Context())
+
+	var req CreateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	profile, err := h.svc.CreateProfile(r.Context(), userID, profileID, req)
+	if err != nil {
+		httputil.Error(w, profileErrorStatus(err), err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, profile)
+}
+
+func (h *Handler) EditProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+	actingProfileID, _ := context.ProfileID(r.Context())
+
+	profileID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid profile ID")
+		return
+	}
+
+	var req EditProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	profile, err := h.svc.EditProfile(r.Context(), userID, actingProfileID, profileID, req)
+	if err != nil {
+		httputil.Error(w, profileErrorStatus(err), err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, profile)
+}
+
+func (h *Handler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+	actingProfileID, _ := context.ProfileID(r.Context())
+
+	profileID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid profile ID")
+		return
+	}
+
+	if err := h.svc.DeleteProfile(r.Context(), userID, actingProfileID, profileID); err != nil {
+		httputil.Error(w, profileErrorStatus(err), err.Error())
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func profileErrorStatus(err error) int {
+	if errors.Is(err, ErrPrimaryOnly) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
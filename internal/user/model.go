@@ -7,22 +7,95 @@ import (
 )
 
 type User struct {
-	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	Email        string    `gorm:"type:text;not null;uniqueIndex"`
-	Username     string    `gorm:"type:text;not null;uniqueIndex"`
-	PasswordHash string    `gorm:"type:text;not null"`
-	Timezone     string    `gorm:"type:text;not null;default:'UTC'"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	Devices      []UserDevice `gorm:"foreignKey:UserID"`
+	ID                 uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Email              string    `gorm:"type:text;not null;uniqueIndex"`
+	EmailVerifiedAt    *time.Time
+	EmailNotifications bool       `gorm:"not null;default:false"`
+	Username           string     `gorm:"type:text;not null;uniqueIndex"`
+	PasswordHash       string     `gorm:"type:text;not null"`
+	Timezone           string     `gorm:"type:text;not null;default:'UTC'"`
+	Language           string     `gorm:"type:text;not null;default:'en'"`
+	Role               string     `gorm:"type:text;not null;default:'user'"`
+	TOTPSecret         *string    `gorm:"column:totp_secret;type:text"`
+	TOTPEnabledAt      *time.Time `gorm:"column:totp_enabled_at"`
+	TOTPLastStep       int64      `gorm:"column:totp_last_step;not null;default:0"`
+	DisabledAt         *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Devices            []UserDevice `gorm:"foreignKey:UserID"`
 }
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// ValidRole reports whether role is one of the account roles.
+func ValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
+}
+
+// Languages are the notification languages, by ISO 639-1 code.
+var Languages = []string{"en", "es", "fr", "de"}
+
+// ValidLanguage reports whether notifications can be written in lang.
+func ValidLanguage(lang string) bool {
+	for _, l := range Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// TwoFactorEnabled reports whether TOTP enrollment has been confirmed.
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != nil
+}
+
+// CanReceiveEmail reports whether notification email may be sent, given
+// whether the deployment requires a verified address first.
+func (u *User) CanReceiveEmail(requireVerified bool) bool {
+	if !u.EmailNotifications {
+		return false
+	}
+	return !requireVerified || u.EmailVerifiedAt != nil
+}
+
+// Device platforms. web, ios and android devices hold an FCM token;
+// webpush devices hold a Web Push subscription, with the endpoint as the
+// token.
+const (
+	PlatformWeb     = "web"
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWebPush = "webpush"
+)
+
 type UserDevice struct {
-	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
-	DeviceToken string    `gorm:"type:text;not null" json:"device_token"`
-	Platform    string    `gorm:"type:text;not null" json:"platform"`
-	IsActive    bool      `gorm:"not null;default:true" json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	ProfileID   *uuid.UUID `gorm:"type:uuid" json:"profile_id,omitempty"` // nil receives every profile's pushes
+	DeviceToken string     `gorm:"type:text;not null" json:"device_token"`
+	Platform    string     `gorm:"type:text;not null" json:"platform"`
+	PushP256DH  *string    `gorm:"column:push_p256dh;type:text" json:"-"`
+	PushAuth    *string    `gorm:"type:text" json:"-"`
+	IsActive    bool       `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Profile is one household member sharing an account's login. Tracking,
+// watchlist, history and notifications are kept per profile. The primary
+// profile shares the account's ID.
+type Profile struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID           uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Name             string    `gorm:"type:text;not null" json:"name"`
+	Avatar           *string   `gorm:"type:text" json:"avatar,omitempty"`
+	MaxContentRating *string   `gorm:"type:text" json:"max_content_rating,omitempty"`
+	IsPrimary        bool      `gorm:"not null;default:false" json:"is_primary"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,54 +17,160 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Create(user *User) error {
-	return r.db.Create(user).Error
+// Create inserts the account together with its primary profile.
+func (r *Repository) Create(ctx context.Context, user *User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&Profile{
+			ID:        user.ID,
+			UserID:    user.ID,
+			Name:      user.Username,
+			IsPrimary: true,
+		}).Error
+	})
 }
 
-func (r *Repository) FindByID(id uuid.UUID) (*User, error) {
+func (r *Repository) FindByID(ctx context.Context, id uuid.UUID) (*User, error) {
 	var user User
-	if err := r.db.First(&user, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *Repository) FindByEmail(email string) (*User, error) {
+func (r *Repository) FindByEmail(ctx context.Context, email string) (*User, error) {
 	var user User
-	if err := r.db.First(&user, "email = ?", email).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&user, "email = ?", email).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *Repository) Update(user *User) error {
-	return r.db.Save(user).Error
+func (r *Repository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
 }
 
-func (r *Repository) AddDevice(device *UserDevice) error {
-	return r.db.Clauses(clause.OnConflict{
+func (r *Repository) Update(ctx context.Context, user *User) error {
+	return r.db.WithContext(ctx).Save(user).Error
+}
+
+// Delete removes the account; everything it owns cascades.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&User{}, "id = ?", id).Error
+}
+
+func (r *Repository) CountByRole(ctx context.Context, role string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&User{}).Where("role = ? AND disabled_at IS NULL", role).Count(&count).Error
+	return count, err
+}
+
+// PromoteAdmins makes the accounts with the given emails admins, returning
+// how many changed. Only verified addresses count; otherwise whoever
+// registers an admin's email first would be promoted.
+func (r *Repository) PromoteAdmins(ctx context.Context, emails []string) (int64, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).Model(&User{}).
+		Where("email IN ? AND role <> ? AND email_verified_at IS NOT NULL", emails, RoleAdmin).
+		Update("role", RoleAdmin)
+	return result.RowsAffected, result.Error
+}
+
+// AdvanceTOTPStep records the time step of an accepted authenticator code.
+// It reports false when that step or a later one was already used, which
+// stops two requests from redeeming the same code concurrently.
+func (r *Repository) AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&User{}).Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *Repository) AddDevice(ctx context.Context, device *UserDevice) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns: []clause.Column{{Name: "user_id"}, {Name: "device_token"}},
 		DoUpdates: clause.Assignments(map[string]interface{}{
-			"platform":   device.Platform,
-			"is_active":  true,
-			"updated_at": time.Now(),
+			"profile_id":  device.ProfileID,
+			"platform":    device.Platform,
+			"push_p256dh": device.PushP256DH,
+			"push_auth":   device.PushAuth,
+			"is_active":   true,
+			"updated_at":  time.Now(),
 		}),
 	}).Create(device).Error
 }
 
-func (r *Repository) RemoveDevice(deviceID, userID uuid.UUID) error {
-	return r.db.Delete(&UserDevice{}, "id = ? AND user_id = ?", deviceID, userID).Error
+func (r *Repository) RemoveDevice(ctx context.Context, deviceID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&UserDevice{}, "id = ? AND user_id = ?", deviceID, userID).Error
 }
 
-func (r *Repository) DeactivateDevice(deviceID uuid.UUID) error {
-	return r.db.Model(&UserDevice{}).Where("id = ?", deviceID).
+func (r *Repository) DeactivateDevice(ctx context.Context, deviceID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&UserDevice{}).Where("id = ?", deviceID).
 		Updates(map[string]interface{}{"is_active": false, "updated_at": time.Now()}).Error
 }
 
-func (r *Repository) GetDevices(userID uuid.UUID) ([]UserDevice, error) {
+func (r *Repository) GetDevices(ctx context.Context, userID uuid.UUID) ([]UserDevice, error) {
 	var devices []UserDevice
-	if err := r.db.Find(&devices, "user_id = ?", userID).Error; err != nil {
+	if err := r.db.WithContext(ctx).Find(&devices, "user_id = ?", userID).Error; err != nil {
 		return nil, err
 	}
 	return devices, nil
 }
+
+// GetDevicesForProfile returns the devices that should receive pushes meant
+// for profileID: its own devices plus those shared by the whole account.
+func (r *Repository) GetDevicesForProfile(ctx context.Context, userID, profileID uuid.UUID) ([]UserDevice, error) {
+	var devices []UserDevice
+	err := r.db.WithContext(ctx).Where("user_id = ? AND (profile_id IS NULL OR profile_id = ?)", userID, profileID).
+		Find(&devices).Error
+	if err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (r *Repository) GetProfiles(ctx context.Context, userID uuid.UUID) ([]Profile, error) {
+	var profiles []Profile
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("is_primary DESC, created_at ASC").Find(&profiles).Error
+	return profiles, err
+}
+
+func (r *Repository) FindProfile(ctx context.Context, userID, profileID uuid.UUID) (*Profile, error) {
+	var profile Profile
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", profileID, userID).First(&profile).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *Repository) FindProfileByID(ctx context.Context, profileID uuid.UUID) (*Profile, error) {
+	var profile Profile
+	if err := r.db.WithContext(ctx).First(&profile, "id = ?", profileID).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *Repository) CountProfiles(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Profile{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *Repository) CreateProfile(ctx context.Context, profile *Profile) error {
+	return r.db.WithContext(ctx).Create(profile).Error
+}
+
+func (r *Repository) UpdateProfile(ctx context.Context, profile *Profile) error {
+	return r.db.WithContext(ctx).Save(profile).Error
+}
+
+func (r *Repository) DeleteProfile(ctx context.Context, userID, profileID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ? AND user_id = ? AND is_primary = ?", profileID, userID, false).
+		Delete(&Profile{}).Error
+}
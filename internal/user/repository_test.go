@@ -0,0 +1,50 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB builds statements with the Postgres dialect without a server.
+// Each Update's SQL is passed to capture.
+func dryRunDB(t *testing.T, capture func(sql string, vars []interface{})) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		capture(tx.Statement.SQL.String(), tx.Statement.Vars)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPromoteAdminsRequiresVerifiedEmail(t *testing.T) {
+	var statements []string
+	repo := NewRepository(dryRunDB(t, func(sql string, _ []interface{}) {
+		statements = append(statements, sql)
+	}))
+
+	if _, err := repo.PromoteAdmins(context.Background(), []string{"admin@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 1 {
+		t.Fatalf("expected one update, got %d", len(statements))
+	}
+	// An account that registered the address without verifying it must not
+	// match, so it keeps the user role.
+	if !strings.Contains(statements[0], "email_verified_at IS NOT NULL") {
+		t.Fatalf("promotion does not require a verified email: %s", statements[0])
+	}
+}
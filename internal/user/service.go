@@ -1,10 +1,14 @@
 package user
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/webpush"
 )
 
 type Service struct {
@@ -16,45 +20,95 @@ func NewService(repo *Repository) *Service {
 }
 
 type UserProfile struct {
-	ID       uuid.UUID    `json:"id"`
-	Email    string       `json:"email"`
-	Username string       `json:"username"`
-	Timezone string       `json:"timezone"`
-	Devices  []UserDevice `json:"devices"`
+	ID                 uuid.UUID    `json:"id"`
+	Email              string       `json:"email"`
+	EmailVerified      bool         `json:"email_verified"`
+	EmailNotifications bool         `json:"email_notifications"`
+	TwoFactorEnabled   bool         `json:"two_factor_enabled"`
+	Role               string       `json:"role"`
+	Username           string       `json:"username"`
+	Timezone           string       `json:"timezone"`
+	Language           string       `json:"language"`
+	Devices            []UserDevice `json:"devices"`
 }
 
+// UpdateProfileRequest edits account settings. Email address changes go
+// through /auth/email/change so the new address is confirmed first.
 type UpdateProfileRequest struct {
-	Timezone string `json:"timezone"`
-	Username string `json:"username"`
+	Timezone           string `json:"timezone"`
+	Language           string `json:"language"`
+	Username           string `json:"username"`
+	EmailNotifications *bool  `json:"email_notifications"`
 }
 
+// RegisterDeviceRequest registers an FCM token, or for the webpush
+// platform the browser's PushSubscription.toJSON() as subscription.
 type RegisterDeviceRequest struct {
-	DeviceToken string `json:"device_token"`
-	Platform    string `json:"platform"`
+	DeviceToken  string               `json:"device_token"`
+	Platform     string               `json:"platform"`
+	Subscription *WebPushSubscription `json:"subscription"`
+	AllProfiles  bool                 `json:"all_profiles"` // shared household devices
 }
 
-func (s *Service) GetProfile(userID uuid.UUID) (*UserProfile, error) {
-	u, err := s.repo.FindByID(userID)
+type WebPushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256DH string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+type CreateProfileRequest struct {
+	Name             string  `json:"name"`
+	Avatar           *string `json:"avatar"`
+	MaxContentRating *string `json:"max_content_rating"`
+}
+
+type EditProfileRequest struct {
+	Name             *string `json:"name"`
+	Avatar           *string `json:"avatar"`
+	MaxContentRating *string `json:"max_content_rating"` // "" removes the limit
+}
+
+const maxProfiles = 6
+
+// ErrPrimaryOnly is returned when a secondary profile tries to manage the
+// household; otherwise a child profile could lift its own rating limit.
+var ErrPrimaryOnly = errors.New("only the primary profile can manage profiles")
+
+func (s *Service) GetProfile(ctx context.Context, userID uuid.UUID) (*UserProfile, error) {
+	ctx, span := tracing.Start(ctx, "user.GetProfile")
+	defer span.End()
+
+	u, err := s.repo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	devices, err := s.repo.GetDevices(userID)
+	devices, err := s.repo.GetDevices(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &UserProfile{
-		ID:       u.ID,
-		Email:    u.Email,
-		Username: u.Username,
-		Timezone: u.Timezone,
-		Devices:  devices,
+		ID:                 u.ID,
+		Email:              u.Email,
+		EmailVerified:      u.EmailVerifiedAt != nil,
+		EmailNotifications: u.EmailNotifications,
+		TwoFactorEnabled:   u.TwoFactorEnabled(),
+		Role:               u.Role,
+		Username:           u.Username,
+		Timezone:           u.Timezone,
+		Language:           u.Language,
+		Devices:            devices,
 	}, nil
 }
 
-func (s *Service) UpdateProfile(userID uuid.UUID, req UpdateProfileRequest) error {
-	u, err := s.repo.FindByID(userID)
+func (s *Service) UpdateProfile(ctx context.Context, userID uuid.UUID, req UpdateProfileRequest) error {
+	ctx, span := tracing.Start(ctx, "user.UpdateProfile")
+	defer span.End()
+
+	u, err := s.repo.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -65,37 +119,241 @@ func (s *Service) UpdateProfile(userID uuid.UUID, req UpdateProfileRequest) erro
 		}
 		u.Timezone = req.Timezone
 	}
+	if req.Language != "" {
+		if !ValidLanguage(req.Language) {
+			return errors.New("language must be one of " + strings.Join(Languages, ", "))
+		}
+		u.Language = req.Language
+	}
 	if req.Username != "" {
 		if len(req.Username) < 3 || len(req.Username) > 30 {
 			return errors.New("username must be 3-30 characters")
 		}
 		u.Username = req.Username
 	}
+	if req.EmailNotifications != nil {
+		u.EmailNotifications = *req.EmailNotifications
+	}
 
-	return s.repo.Update(u)
+	return s.repo.Update(ctx, u)
 }
 
-func (s *Service) RegisterDevice(userID uuid.UUID, req RegisterDeviceRequest) error {
-	if req.DeviceToken == "" {
-		return errors.New("device_token is required")
-	}
-	if req.Platform != "web" && req.Platform != "ios" && req.Platform != "android" {
-		return errors.New("platform must be web, ios, or android")
-	}
-	// Simple upsert logic: if token exists for user, update platform/active, else create
-	// For now, assume create. A proper upsert query would be better.
+func (s *Service) RegisterDevice(ctx context.Context, userID, profileID uuid.UUID, req RegisterDeviceRequest) error {
+	ctx, span := tracing.Start(ctx, "user.RegisterDevice")
+	defer span.End()
 
-	// Check if device exists
-	// Implementation simplified for MVP
 	device := &UserDevice{
 		UserID:      userID,
 		DeviceToken: req.DeviceToken,
 		Platform:    req.Platform,
 		IsActive:    true,
 	}
-	return s.repo.AddDevice(device)
+	switch req.Platform {
+	case PlatformWeb, PlatformIOS, PlatformAndroid:
+		if req.DeviceToken == "" {
+			return errors.New("device_token is required")
+		}
+	case PlatformWebPush:
+		if err := validateSubscription(req.Subscription); err != nil {
+			return err
+		}
+		device.DeviceToken = req.Subscription.Endpoint
+		device.PushP256DH = &req.Subscription.Keys.P256DH
+		device.PushAuth = &req.Subscription.Keys.Auth
+	default:
+		return errors.New("platform must be web, ios, android, or webpush")
+	}
+	// Re-registering a token or endpoint updates the existing device.
+	if !req.AllProfiles {
+		device.ProfileID = &profileID
+	}
+	return s.repo.AddDevice(ctx, device)
+}
+
+func (s *Service) UnregisterDevice(ctx context.Context, userID, deviceID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "user.UnregisterDevice")
+	defer span.End()
+
+	return s.repo.RemoveDevice(ctx, deviceID, userID)
+}
+
+func (s *Service) ListProfiles(ctx context.Context, userID uuid.UUID) ([]Profile, error) {
+	ctx, span := tracing.Start(ctx, "user.ListProfiles")
+	defer span.End()
+
+	return s.repo.GetProfiles(ctx, userID)
+}
+
+func (s *Service) CreateProfile(ctx context.Context, userID, actingProfileID uuid.UUID, req CreateProfileRequest) (*Profile, error) {
+	ctx, span := tracing.Start(ctx, "user.CreateProfile")
+	defer span.End()
+
+	if err := s.requirePrimary(ctx, userID, actingProfileID); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 30 {
+		return nil, errors.New("name must be 1-30 characters")
+	}
+	count, err := s.repo.CountProfiles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxProfiles {
+		return nil, errors.New("an account can have at most 6 profiles")
+	}
+	limit, err := normalizeContentRating(req.MaxContentRating)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{
+		UserID:           userID,
+		Name:             name,
+		Avatar:           req.Avatar,
+		MaxContentRating: limit,
+	}
+	if err := s.repo.CreateProfile(ctx, profile); err != nil {
+		return nil, errors.New("profile name already in use")
+	}
+	return profile, nil
+}
+
+// EditProfile updates a household profile. It is distinct from
+// UpdateProfile, which edits the account itself.
+func (s *Service) EditProfile(ctx context.Context, userID, actingProfileID, profileID uuid.UUID, req EditProfileRequest) (*Profile, error) {
+	ctx, span := tracing.Start(ctx, "user.EditProfile")
+	defer span.End()
+
+	if err := s.requirePrimary(ctx, userID, actingProfileID); err != nil {
+		return nil, err
+	}
+	profile, err := s.repo.FindProfile(ctx, userID, profileID)
+	if err != nil {
+		return nil, errors.New("profile not found")
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > 30 {
+			return nil, errors.New("name must be 1-30 characters")
+		}
+		profile.Name = name
+	}
+	if req.Avatar != nil {
+		profile.Avatar = req.Avatar
+	}
+	if req.MaxContentRating != nil {
+		if profile.IsPrimary && *req.MaxContentRating != "" {
+			return nil, errors.New("the primary profile cannot have a content rating limit")
+		}
+		limit, err := normalizeContentRating(req.MaxContentRating)
+		if err != nil {
+			return nil, err
+		}
+		profile.MaxContentRating = limit
+	}
+	profile.UpdatedAt = time.Now()
+	if err := s.repo.UpdateProfile(ctx, profile); err != nil {
+		return nil, errors.New("profile name already in use")
+	}
+	return profile, nil
+}
+
+func (s *Service) DeleteProfile(ctx context.Context, userID, actingProfileID, profileID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "user.DeleteProfile")
+	defer span.End()
+
+	if err := s.requirePrimary(ctx, userID, actingProfileID); err != nil {
+		return err
+	}
+	profile, err := s.repo.FindProfile(ctx, userID, profileID)
+	if err != nil {
+		return errors.New("profile not found")
+	}
+	if profile.IsPrimary {
+		return errors.New("the primary profile cannot be deleted")
+	}
+	return s.repo.DeleteProfile(ctx, userID, profileID)
 }
 
-func (s *Service) UnregisterDevice(userID, deviceID uuid.UUID) error {
-	return s.repo.RemoveDevice(deviceID, userID)
+// CheckContentRating reports whether a show with the given OMDb rating may be
+// shown to profileID.
+func (s *Service) CheckContentRating(ctx context.Context, profileID uuid.UUID, rated string) error {
+	ctx, span := tracing.Start(ctx, "user.CheckContentRating")
+	defer span.End()
+
+	if !RatingAllowed(s.ContentRatingLimit(ctx, profileID), rated) {
+		return errors.New("show exceeds this profile's content rating limit")
+	}
+	return nil
+}
+
+// ContentRatingLimit returns profileID's maximum content rating, or "" when
+// it has none. Callers checking many titles look it up once and use
+// RatingAllowed.
+func (s *Service) ContentRatingLimit(ctx context.Context, profileID uuid.UUID) string {
+	profile, err := s.repo.FindProfileByID(ctx, profileID)
+	if err != nil || profile.MaxContentRating == nil {
+		return ""
+	}
+	return *profile.MaxContentRating
+}
+
+func (s *Service) requirePrimary(ctx context.Context, userID, actingProfileID uuid.UUID) error {
+	profile, err := s.repo.FindProfile(ctx, userID, actingProfileID)
+	if err != nil || !profile.IsPrimary {
+		return ErrPrimaryOnly
+	}
+	return nil
+}
+
+// contentRatingLevels puts the MPAA and US TV ratings OMDb reports in its
+// Rated field on one scale, so either kind can cap a profile.
+var contentRatingLevels = map[string]int{
+	"TV-Y":  1,
+	"TV-Y7": 2,
+	"G":     3,
+	"TV-G":  3,
+	"PG":    4,
+	"TV-PG": 4,
+	"PG-13": 5,
+	"TV-14": 5,
+	"R":     6,
+	"TV-MA": 6,
+	"NC-17": 7,
+}
+
+// RatingAllowed reports whether rated is at or below limit. Titles OMDb has
+// not rated ("N/A", "Not Rated", missing) pass, since most TMDB-only shows
+// would otherwise disappear from restricted profiles.
+func RatingAllowed(limit, rated string) bool {
+	ceiling, ok := contentRatingLevels[strings.ToUpper(strings.TrimSpace(limit))]
+	if !ok {
+		return true
+	}
+	level, ok := contentRatingLevels[strings.ToUpper(strings.TrimSpace(rated))]
+	if !ok {
+		return true
+	}
+	return level <= ceiling
+}
+
+func normalizeContentRating(rating *string) (*string, error) {
+	if rating == nil || strings.TrimSpace(*rating) == "" {
+		return nil, nil
+	}
+	normalized := strings.ToUpper(strings.TrimSpace(*rating))
+	if _, ok := contentRatingLevels[normalized]; !ok {
+		return nil, errors.New("max_content_rating must be an MPAA or US TV rating such as PG-13 or TV-14")
+	}
+	return &normalized, nil
+}
+
+// validateSubscription checks a Web Push subscription before it is stored,
+// so a malformed one fails here rather than at every dispatch.
+func validateSubscription(sub *WebPushSubscription) error {
+	if sub == nil {
+		return errors.New("subscription is required for webpush")
+	}
+	return webpush.ValidateSubscription(sub.Endpoint, sub.Keys.P256DH, sub.Keys.Auth)
 }
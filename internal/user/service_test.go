@@ -0,0 +1,80 @@
+package user
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRatingAllowed(t *testing.T) {
+	cases := []struct {
+		limit, rated string
+		want         bool
+	}{
+		{"", "R", true},
+		{"PG-13", "PG", true},
+		{"PG-13", "TV-14", true},
+		{"PG-13", "R", false},
+		{"TV-PG", "TV-MA", false},
+		{"tv-y7", "TV-Y", true},
+		{"PG", "N/A", true},
+		{"PG", "", true},
+	}
+	for _, tc := range cases {
+		if got := RatingAllowed(tc.limit, tc.rated); got != tc.want {
+			t.Errorf("RatingAllowed(%q, %q) = %v, want %v", tc.limit, tc.rated, got, tc.want)
+		}
+	}
+}
+
+func TestCanReceiveEmail(t *testing.T) {
+	verified := time.Now()
+	cases := []struct {
+		name            string
+		user            User
+		requireVerified bool
+		want            bool
+	}{
+		{"opted out", User{EmailVerifiedAt: &verified}, true, false},
+		{"verified", User{EmailNotifications: true, EmailVerifiedAt: &verified}, true, true},
+		{"unverified and required", User{EmailNotifications: true}, true, false},
+		{"unverified and not required", User{EmailNotifications: true}, false, true},
+	}
+	for _, tc := range cases {
+		if got := tc.user.CanReceiveEmail(tc.requireVerified); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestValidateSubscription(t *testing.T) {
+	valid := func() *WebPushSubscription {
+		sub := &WebPushSubscription{Endpoint: "https://fcm.googleapis.com/fcm/send/abc"}
+		sub.Keys.P256DH = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+		sub.Keys.Auth = "BTBZMqHH6r4Tts7J_aSIgg=="
+		return sub
+	}
+	if err := validateSubscription(valid()); err != nil {
+		t.Fatalf("valid subscription rejected: %v", err)
+	}
+
+	broken := []func(*WebPushSubscription){
+		func(s *WebPushSubscription) { s.Endpoint = "http://push.example.com/abc" },
+		func(s *WebPushSubscription) { s.Endpoint = "" },
+		func(s *WebPushSubscription) { s.Endpoint = "https://localhost/push" },
+		func(s *WebPushSubscription) { s.Endpoint = "https://10.0.0.7/push" },
+		func(s *WebPushSubscription) { s.Endpoint = "https://[::1]:8443/push" },
+		func(s *WebPushSubscription) { s.Endpoint = "https://169.254.169.254/latest/meta-data" },
+		func(s *WebPushSubscription) { s.Keys.P256DH = "BTBZMqHH6r4Tts7J_aSIgg" },
+		func(s *WebPushSubscription) { s.Keys.Auth = "c2hvcnQ" },
+	}
+	for i, breakIt := range broken {
+		sub := valid()
+		breakIt(sub)
+		if err := validateSubscription(sub); err == nil {
+			t.Errorf("case %d: invalid subscription accepted", i)
+		}
+	}
+	if err := validateSubscription(nil); err == nil {
+		t.Error("missing subscription accepted")
+	}
+}
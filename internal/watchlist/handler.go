@@ -2,6 +2,8 @@ package watchlist
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -19,13 +21,13 @@ func NewHandler(svc *Service) *Handler {
 }
 
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
 
-	items, err := h.svc.List(userID)
+	items, err := h.svc.List(r.Context(), profileID)
 	if err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -40,6 +42,7 @@ func (h *Handler) Add(w http.ResponseWriter, r *http.Request) {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
 	var req AddRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -47,7 +50,7 @@ func (h *Handler) Add(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.Add(r.Context(), userID, req); err != nil {
+	if err := h.svc.Add(r.Context(), userID, profileID, req); err != nil {
 		httputil.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -56,7 +59,7 @@ func (h *Handler) Add(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
@@ -76,7 +79,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.Update(r.Context(), userID, showID, req); err != nil {
+	if err := h.svc.Update(r.Context(), profileID, showID, req); err != nil {
 		httputil.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -85,7 +88,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) Remove(w http.ResponseWriter, r *http.Request) {
-	userID, ok := context.UserID(r.Context())
+	profileID, ok := context.ProfileID(r.Context())
 	if !ok {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
@@ -99,7 +102,7 @@ func (h *Handler) Remove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.Remove(r.Context(), userID, showID); err != nil {
+	if err := h.svc.Remove(r.Context(), profileID, showID); err != nil {
 		httputil.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -113,6 +116,7 @@ func (h *Handler) StartTracking(w http.ResponseWriter, r *http.Request) {
 		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
 		return
 	}
+	profileID, _ := context.ProfileID(r.Context())
 
 	vars := mux.Vars(r)
 	showIDStr := vars["show_id"]
@@ -122,10 +126,297 @@ func (h *Handler) StartTracking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.StartTracking(r.Context(), userID, showID); err != nil {
+	if err := h.svc.StartTracking(r.Context(), userID, profileID, showID); err != nil {
 		httputil.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	httputil.JSON(w, http.StatusOK, map[string]string{"message": "Tracking started"})
 }
+
+func (h *Handler) GetLists(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	lists, err := h.svc.GetLists(r.Context(), userID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, lists)
+}
+
+func (h *Handler) CreateList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	var req CreateListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	list, err := h.svc.CreateList(r.Context(), userID, req)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, list)
+}
+
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	listID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+
+	list, err := h.svc.GetList(r.Context(), userID, listID, r.URL.Query().Get("tag"))
+	if err != nil {
+		httputil.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) UpdateList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	listID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+
+	var req UpdateListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	list, err := h.svc.UpdateList(r.Context(), userID, listID, req)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) DeleteList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	listID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+
+	if err := h.svc.DeleteList(r.Context(), userID, listID); err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) RotateShareLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	listID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+
+	list, err := h.svc.RotateShareLink(r.Context(), userID, listID)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) AddListItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	listID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+
+	var req AddListItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.svc.AddListItem(r.Context(), userID, listID, req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, map[string]string{"message": "Added to list"})
+}
+
+func (h *Handler) UpdateListItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+	showID, err := uuid.Parse(vars["show_id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid show ID")
+		return
+	}
+
+	var req UpdateListItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.svc.UpdateListItem(r.Context(), userID, listID, showID, req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "List item updated"})
+}
+
+func (h *Handler) RemoveListItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	listID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+	showID, err := uuid.Parse(vars["show_id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid show ID")
+		return
+	}
+
+	if err := h.svc.RemoveListItem(r.Context(), userID, listID, showID); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ReorderList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	listID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid list ID")
+		return
+	}
+
+	var req ReorderListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.svc.ReorderList(r.Context(), userID, listID, req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "List reordered"})
+}
+
+func (h *Handler) CloneList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := context.UserID(r.Context())
+	if !ok {
+		httputil.Error(w, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	var req CloneListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		httputil.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	list, err := h.svc.CloneList(r.Context(), userID, mux.Vars(r)["slug"], req)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, list)
+}
+
+func (h *Handler) GetSharedList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.svc.GetSharedList(r.Context(), mux.Vars(r)["slug"])
+	if err != nil {
+		httputil.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	httputil.JSONWithCache(w, r, http.StatusOK, list, 60, 300)
+}
+
+func (h *Handler) GetPublicLists(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.svc.GetPublicLists(r.Context(), mux.Vars(r)["username"])
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputil.JSONWithCache(w, r, http.StatusOK, lists, 60, 300)
+}
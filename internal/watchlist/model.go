@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/tashifkhan/bingebeacon/internal/show"
 )
 
@@ -16,13 +17,14 @@ const (
 )
 
 type Item struct {
-	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	UserID   uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
-	ShowID   uuid.UUID `gorm:"type:uuid;not null" json:"show_id"`
-	Priority Priority  `gorm:"type:watchlist_priority;not null;default:'medium'" json:"priority"`
-	Notes    *string   `gorm:"type:text" json:"notes"`
-	AddedAt  time.Time `gorm:"not null;default:now()" json:"added_at"`
-	Show     show.Show `gorm:"foreignKey:ShowID" json:"show"`
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	ProfileID uuid.UUID `gorm:"type:uuid;not null" json:"profile_id"`
+	ShowID    uuid.UUID `gorm:"type:uuid;not null" json:"show_id"`
+	Priority  Priority  `gorm:"type:watchlist_priority;not null;default:'medium'" json:"priority"`
+	Notes     *string   `gorm:"type:text" json:"notes"`
+	AddedAt   time.Time `gorm:"not null;default:now()" json:"added_at"`
+	Show      show.Show `gorm:"foreignKey:ShowID" json:"show"`
 }
 
 // TableName overrides the table name used by User to `watchlist_items`
@@ -40,3 +42,91 @@ type UpdateRequest struct {
 	Priority Priority `json:"priority" validate:"omitempty,oneof=high medium low"`
 	Notes    *string  `json:"notes"`
 }
+
+type Visibility string
+
+const (
+	VisibilityPrivate  Visibility = "private"
+	VisibilityUnlisted Visibility = "unlisted"
+	VisibilityPublic   Visibility = "public"
+)
+
+type CustomList struct {
+	ID           uuid.UUID        `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID       uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name         string           `gorm:"type:text;not null" json:"name"`
+	Description  *string          `gorm:"type:text" json:"description"`
+	Visibility   Visibility       `gorm:"type:text;not null;default:'private'" json:"visibility"`
+	ShareSlug    *string          `gorm:"type:text;uniqueIndex" json:"share_slug,omitempty"`
+	ClonedFromID *uuid.UUID       `gorm:"type:uuid" json:"cloned_from_id,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+	Items        []CustomListItem `gorm:"foreignKey:ListID" json:"items,omitempty"`
+}
+
+type CustomListItem struct {
+	ID       uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ListID   uuid.UUID      `gorm:"type:uuid;not null;index" json:"list_id"`
+	ShowID   uuid.UUID      `gorm:"type:uuid;not null" json:"show_id"`
+	Position int            `gorm:"not null" json:"position"`
+	Notes    *string        `gorm:"type:text" json:"notes"`
+	Tags     pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"tags"`
+	AddedAt  time.Time      `gorm:"not null;default:now()" json:"added_at"`
+	Show     show.Show      `gorm:"foreignKey:ShowID" json:"show"`
+}
+
+type CreateListRequest struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Visibility  Visibility `json:"visibility"`
+}
+
+type UpdateListRequest struct {
+	Name        *string    `json:"name"`
+	Description *string    `json:"description"`
+	Visibility  Visibility `json:"visibility"`
+}
+
+type AddListItemRequest struct {
+	ShowID uuid.UUID `json:"show_id"`
+	Notes  string    `json:"notes"`
+	Tags   []string  `json:"tags"`
+}
+
+type UpdateListItemRequest struct {
+	Notes *string   `json:"notes"`
+	Tags  *[]string `json:"tags"`
+}
+
+type ReorderListRequest struct {
+	ShowIDs []uuid.UUID `json:"show_ids"`
+}
+
+type CloneListRequest struct {
+	Name string `json:"name"`
+}
+
+// PublicList is the read-only view served to anonymous visitors of a share
+// link; it omits owner IDs and anything else that is account-internal.
+type PublicList struct {
+	Name        string           `json:"name"`
+	Description *string          `json:"description"`
+	Owner       string           `json:"owner"`
+	ShareSlug   string           `json:"share_slug"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	Items       []PublicListItem `json:"items"`
+}
+
+type PublicListSummary struct {
+	Name        string    `json:"name"`
+	Description *string   `json:"description"`
+	ShareSlug   string    `json:"share_slug"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type PublicListItem struct {
+	Position int       `json:"position"`
+	Notes    *string   `json:"notes"`
+	Tags     []string  `json:"tags"`
+	Show     show.Show `json:"show"`
+}
@@ -1,6 +1,9 @@
 package watchlist
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -13,30 +16,170 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) List(userID uuid.UUID) ([]Item, error) {
+func (r *Repository) List(ctx context.Context, profileID uuid.UUID) ([]Item, error) {
 	var items []Item
 	order := "CASE priority WHEN 'high' THEN 1 WHEN 'medium' THEN 2 WHEN 'low' THEN 3 END, added_at DESC"
-	err := r.db.Preload("Show").Where("user_id = ?", userID).Order(order).Find(&items).Error
+	err := r.db.WithContext(ctx).Preload("Show").Where("profile_id = ?", profileID).Order(order).Find(&items).Error
 	return items, err
 }
 
-func (r *Repository) Add(item *Item) error {
-	return r.db.Create(item).Error
+func (r *Repository) Add(ctx context.Context, item *Item) error {
+	return r.db.WithContext(ctx).Create(item).Error
 }
 
-func (r *Repository) Update(item *Item) error {
-	return r.db.Save(item).Error
+func (r *Repository) Update(ctx context.Context, item *Item) error {
+	return r.db.WithContext(ctx).Save(item).Error
 }
 
-func (r *Repository) Delete(userID, showID uuid.UUID) error {
-	return r.db.Where("user_id = ? AND show_id = ?", userID, showID).Delete(&Item{}).Error
+func (r *Repository) Delete(ctx context.Context, profileID, showID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("profile_id = ? AND show_id = ?", profileID, showID).Delete(&Item{}).Error
 }
 
-func (r *Repository) Find(userID, showID uuid.UUID) (*Item, error) {
+func (r *Repository) Find(ctx context.Context, profileID, showID uuid.UUID) (*Item, error) {
 	var item Item
-	err := r.db.Where("user_id = ? AND show_id = ?", userID, showID).First(&item).Error
+	err := r.db.WithContext(ctx).Where("profile_id = ? AND show_id = ?", profileID, showID).First(&item).Error
 	if err != nil {
 		return nil, err
 	}
 	return &item, nil
 }
+
+func (r *Repository) CreateList(ctx context.Context, list *CustomList) error {
+	return r.db.WithContext(ctx).Create(list).Error
+}
+
+func (r *Repository) GetLists(ctx context.Context, userID uuid.UUID) ([]CustomList, error) {
+	var lists []CustomList
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("updated_at DESC").Find(&lists).Error
+	return lists, err
+}
+
+func (r *Repository) FindList(ctx context.Context, userID, listID uuid.UUID) (*CustomList, error) {
+	var list CustomList
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", listID, userID).First(&list).Error; err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *Repository) GetListWithItems(ctx context.Context, userID, listID uuid.UUID, tag string) (*CustomList, error) {
+	var list CustomList
+	err := r.db.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		if tag != "" {
+			db = db.Where("? = ANY(tags)", tag)
+		}
+		return db.Order("position ASC")
+	}).Preload("Items.Show").Where("id = ? AND user_id = ?", listID, userID).First(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// FindSharedList resolves a share slug to a list that is visible to anyone
+// holding the link. Private lists never resolve, even with a stale slug.
+func (r *Repository) FindSharedList(ctx context.Context, slug string) (*CustomList, string, error) {
+	var list CustomList
+	err := r.db.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Preload("Items.Show").
+		Where("share_slug = ? AND visibility IN ?", slug, []Visibility{VisibilityUnlisted, VisibilityPublic}).
+		First(&list).Error
+	if err != nil {
+		return nil, "", err
+	}
+	var owner string
+	if err := r.db.WithContext(ctx).Table("users").Select("username").Where("id = ?", list.UserID).Scan(&owner).Error; err != nil {
+		return nil, "", err
+	}
+	return &list, owner, nil
+}
+
+func (r *Repository) GetPublicListsByUsername(ctx context.Context, username string) ([]CustomList, error) {
+	var lists []CustomList
+	err := r.db.WithContext(ctx).Table("custom_lists").
+		Select("custom_lists.*").
+		Joins("INNER JOIN users ON users.id = custom_lists.user_id").
+		Where("users.username = ? AND custom_lists.visibility = ?", username, VisibilityPublic).
+		Order("custom_lists.updated_at DESC").
+		Find(&lists).Error
+	return lists, err
+}
+
+func (r *Repository) UpdateList(ctx context.Context, list *CustomList) error {
+	return r.db.WithContext(ctx).Save(list).Error
+}
+
+func (r *Repository) DeleteList(ctx context.Context, userID, listID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ? AND user_id = ?", listID, userID).Delete(&CustomList{}).Error
+}
+
+func (r *Repository) TouchList(ctx context.Context, listID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&CustomList{}).Where("id = ?", listID).Update("updated_at", time.Now()).Error
+}
+
+// AddListItem appends the item after the current last position.
+func (r *Repository) AddListItem(ctx context.Context, item *CustomListItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var maxPosition int
+		if err := tx.Model(&CustomListItem{}).Where("list_id = ?", item.ListID).
+			Select("COALESCE(MAX(position), 0)").Scan(&maxPosition).Error; err != nil {
+			return err
+		}
+		item.Position = maxPosition + 1
+		return tx.Create(item).Error
+	})
+}
+
+func (r *Repository) FindListItem(ctx context.Context, listID, showID uuid.UUID) (*CustomListItem, error) {
+	var item CustomListItem
+	if err := r.db.WithContext(ctx).Where("list_id = ? AND show_id = ?", listID, showID).First(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *Repository) UpdateListItem(ctx context.Context, item *CustomListItem) error {
+	return r.db.WithContext(ctx).Save(item).Error
+}
+
+func (r *Repository) DeleteListItem(ctx context.Context, listID, showID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("list_id = ? AND show_id = ?", listID, showID).Delete(&CustomListItem{}).Error
+}
+
+// ReorderList rewrites positions so they follow showIDs. Items not named in
+// showIDs keep their relative order and are placed after the named ones.
+func (r *Repository) ReorderList(ctx context.Context, listID uuid.UUID, showIDs []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var items []CustomListItem
+		if err := tx.Where("list_id = ?", listID).Order("position ASC").Find(&items).Error; err != nil {
+			return err
+		}
+		positions := reorderPositions(items, showIDs)
+		for _, item := range items {
+			newPosition := positions[item.ID]
+			if newPosition == item.Position {
+				continue
+			}
+			if err := tx.Model(&CustomListItem{}).Where("id = ?", item.ID).
+				Update("position", newPosition).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CloneList copies source and its items into a new private list owned by
+// clone.UserID.
+func (r *Repository) CloneList(ctx context.Context, source *CustomList, clone *CustomList) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(clone).Error; err != nil {
+			return err
+		}
+		if len(source.Items) == 0 {
+			return nil
+		}
+		return tx.Omit("Show").CreateInBatches(cloneItems(source, clone.ID), 100).Error
+	})
+}
@@ -2,38 +2,60 @@ package watchlist
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/tashifkhan/bingebeacon/internal/alert"
+	"github.com/tashifkhan/bingebeacon/internal/pkg/tracing"
 	"github.com/tashifkhan/bingebeacon/internal/show"
+	"github.com/tashifkhan/bingebeacon/internal/social"
+	"github.com/tashifkhan/bingebeacon/internal/user"
 )
 
 type Service struct {
-	repo     *Repository
-	showRepo *show.Repository
-	alertSvc *alert.Service
+	repo      *Repository
+	showRepo  *show.Repository
+	alertSvc  *alert.Service
+	socialSvc *social.Service
+	userSvc   *user.Service
 }
 
-func NewService(repo *Repository, showRepo *show.Repository, alertSvc *alert.Service) *Service {
+func NewService(repo *Repository, showRepo *show.Repository, alertSvc *alert.Service, socialSvc *social.Service, userSvc *user.Service) *Service {
 	return &Service{
-		repo:     repo,
-		showRepo: showRepo,
-		alertSvc: alertSvc,
+		repo:      repo,
+		showRepo:  showRepo,
+		alertSvc:  alertSvc,
+		socialSvc: socialSvc,
+		userSvc:   userSvc,
 	}
 }
 
-func (s *Service) List(userID uuid.UUID) ([]Item, error) {
-	return s.repo.List(userID)
+func (s *Service) List(ctx context.Context, profileID uuid.UUID) ([]Item, error) {
+	ctx, span := tracing.Start(ctx, "watchlist.List")
+	defer span.End()
+
+	return s.repo.List(ctx, profileID)
 }
 
-func (s *Service) Add(ctx context.Context, userID uuid.UUID, req AddRequest) error {
+func (s *Service) Add(ctx context.Context, userID, profileID uuid.UUID, req AddRequest) error {
+	ctx, span := tracing.Start(ctx, "watchlist.Add")
+	defer span.End()
+
 	if req.ShowID == uuid.Nil {
 		return errors.New("show_id required")
 	}
-	if _, err := s.showRepo.FindByID(req.ShowID); err != nil {
+	target, err := s.showRepo.FindByID(ctx, req.ShowID)
+	if err != nil {
 		return errors.New("show not found")
 	}
+	if err := s.userSvc.CheckContentRating(ctx, profileID, target.Rated()); err != nil {
+		return err
+	}
 
 	priority := req.Priority
 	if priority == "" {
@@ -46,20 +68,24 @@ func (s *Service) Add(ctx context.Context, userID uuid.UUID, req AddRequest) err
 	}
 
 	item := &Item{
-		UserID:   userID,
-		ShowID:   req.ShowID,
-		Priority: priority,
-		Notes:    notes,
+		UserID:    userID,
+		ProfileID: profileID,
+		ShowID:    req.ShowID,
+		Priority:  priority,
+		Notes:     notes,
 	}
 
-	if err := s.repo.Add(item); err != nil {
+	if err := s.repo.Add(ctx, item); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *Service) Update(ctx context.Context, userID, showID uuid.UUID, req UpdateRequest) error {
-	item, err := s.repo.Find(userID, showID)
+func (s *Service) Update(ctx context.Context, profileID, showID uuid.UUID, req UpdateRequest) error {
+	ctx, span := tracing.Start(ctx, "watchlist.Update")
+	defer span.End()
+
+	item, err := s.repo.Find(ctx, profileID, showID)
 	if err != nil {
 		return errors.New("watchlist item not found")
 	}
@@ -69,26 +95,456 @@ func (s *Service) Update(ctx context.Context, userID, showID uuid.UUID, req Upda
 	if req.Notes != nil {
 		item.Notes = req.Notes
 	}
-	return s.repo.Update(item)
+	return s.repo.Update(ctx, item)
 }
 
-func (s *Service) Remove(ctx context.Context, userID, showID uuid.UUID) error {
-	return s.repo.Delete(userID, showID)
+func (s *Service) Remove(ctx context.Context, profileID, showID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "watchlist.Remove")
+	defer span.End()
+
+	return s.repo.Delete(ctx, profileID, showID)
 }
 
-func (s *Service) StartTracking(ctx context.Context, userID, showID uuid.UUID) error {
+func (s *Service) StartTracking(ctx context.Context, userID, profileID, showID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "watchlist.StartTracking")
+	defer span.End()
+
 	// Ensure watchlist entry exists
-	if _, err := s.repo.Find(userID, showID); err != nil {
+	if _, err := s.repo.Find(ctx, profileID, showID); err != nil {
 		return errors.New("watchlist item not found")
 	}
 	// Ensure show exists
-	if _, err := s.showRepo.FindByID(showID); err != nil {
+	if _, err := s.showRepo.FindByID(ctx, showID); err != nil {
 		return errors.New("show not found")
 	}
 	// Create tracking entry
-	if err := s.alertSvc.TrackShow(ctx, userID, alert.TrackRequest{ShowID: &showID}); err != nil {
+	if err := s.alertSvc.TrackShow(ctx, userID, profileID, alert.TrackRequest{ShowID: &showID}); err != nil {
 		return err
 	}
 	// Remove from watchlist
-	return s.repo.Delete(userID, showID)
+	return s.repo.Delete(ctx, profileID, showID)
+}
+
+const maxListTags = 10
+
+func (s *Service) GetLists(ctx context.Context, userID uuid.UUID) ([]CustomList, error) {
+	ctx, span := tracing.Start(ctx, "watchlist.GetLists")
+	defer span.End()
+
+	return s.repo.GetLists(ctx, userID)
+}
+
+func (s *Service) CreateList(ctx context.Context, userID uuid.UUID, req CreateListRequest) (*CustomList, error) {
+	ctx, span := tracing.Start(ctx, "watchlist.CreateList")
+	defer span.End()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, errors.New("name must be 1-100 characters")
+	}
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = VisibilityPrivate
+	}
+
+	list := &CustomList{
+		UserID: userID,
+		Name:   name,
+	}
+	if req.Description != "" {
+		description := req.Description
+		list.Description = &description
+	}
+	if err := setVisibility(list, visibility); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateList(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *Service) GetList(ctx context.Context, userID, listID uuid.UUID, tag string) (*CustomList, error) {
+	ctx, span := tracing.Start(ctx, "watchlist.GetList")
+	defer span.End()
+
+	list, err := s.repo.GetListWithItems(ctx, userID, listID, strings.ToLower(strings.TrimSpace(tag)))
+	if err != nil {
+		return nil, errors.New("list not found")
+	}
+	return list, nil
+}
+
+func (s *Service) UpdateList(ctx context.Context, userID, listID uuid.UUID, req UpdateListRequest) (*CustomList, error) {
+	ctx, span := tracing.Start(ctx, "watchlist.UpdateList")
+	defer span.End()
+
+	list, err := s.repo.FindList(ctx, userID, listID)
+	if err != nil {
+		return nil, errors.New("list not found")
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > 100 {
+			return nil, errors.New("name must be 1-100 characters")
+		}
+		list.Name = name
+	}
+	if req.Description != nil {
+		list.Description = req.Description
+	}
+	wasPublic := list.Visibility == VisibilityPublic
+	if req.Visibility != "" {
+		if err := setVisibility(list, req.Visibility); err != nil {
+			return nil, err
+		}
+	}
+	list.UpdatedAt = time.Now()
+	if err := s.repo.UpdateList(ctx, list); err != nil {
+		return nil, err
+	}
+	// Followers only see public lists; withdraw what was announced.
+	if wasPublic && list.Visibility != VisibilityPublic {
+		_ = s.socialSvc.RemoveBySource(ctx, userID, listID)
+	}
+	return list, nil
+}
+
+// RotateShareLink issues a new share slug, invalidating any link handed out
+// before. It is how owners revoke an unlisted link without going private.
+func (s *Service) RotateShareLink(ctx context.Context, userID, listID uuid.UUID) (*CustomList, error) {
+	ctx, span := tracing.Start(ctx, "watchlist.RotateShareLink")
+	defer span.End()
+
+	list, err := s.repo.FindList(ctx, userID, listID)
+	if err != nil {
+		return nil, errors.New("list not found")
+	}
+	if list.Visibility == VisibilityPrivate {
+		return nil, errors.New("private lists cannot be shared")
+	}
+	slug, err := newShareSlug()
+	if err != nil {
+		return nil, err
+	}
+	list.ShareSlug = &slug
+	list.UpdatedAt = time.Now()
+	if err := s.repo.UpdateList(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *Service) DeleteList(ctx context.Context, userID, listID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "watchlist.DeleteList")
+	defer span.End()
+
+	if err := s.repo.DeleteList(ctx, userID, listID); err != nil {
+		return err
+	}
+	_ = s.socialSvc.RemoveBySource(ctx, userID, listID)
+	return nil
+}
+
+func (s *Service) AddListItem(ctx context.Context, userID, listID uuid.UUID, req AddListItemRequest) error {
+	ctx, span := tracing.Start(ctx, "watchlist.AddListItem")
+	defer span.End()
+
+	list, err := s.repo.FindList(ctx, userID, listID)
+	if err != nil {
+		return errors.New("list not found")
+	}
+	if req.ShowID == uuid.Nil {
+		return errors.New("show_id required")
+	}
+	if _, err := s.showRepo.FindByID(ctx, req.ShowID); err != nil {
+		return errors.New("show not found")
+	}
+	if _, err := s.repo.FindListItem(ctx, listID, req.ShowID); err == nil {
+		return errors.New("show already in list")
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+
+	item := &CustomListItem{
+		ListID: listID,
+		ShowID: req.ShowID,
+		Tags:   tags,
+	}
+	if req.Notes != "" {
+		notes := req.Notes
+		item.Notes = &notes
+	}
+	if err := s.repo.AddListItem(ctx, item); err != nil {
+		return err
+	}
+	// Only public lists reach followers' feeds; unlisted ones are for
+	// whoever has the link.
+	if list.Visibility == VisibilityPublic {
+		_ = s.socialSvc.Record(ctx, &social.Activity{
+			UserID:   userID,
+			Type:     social.ActivityListed,
+			ShowID:   req.ShowID,
+			SourceID: &list.ID,
+			ListName: &list.Name,
+		})
+	}
+	return s.repo.TouchList(ctx, listID)
+}
+
+func (s *Service) UpdateListItem(ctx context.Context, userID, listID, showID uuid.UUID, req UpdateListItemRequest) error {
+	ctx, span := tracing.Start(ctx, "watchlist.UpdateListItem")
+	defer span.End()
+
+	if _, err := s.repo.FindList(ctx, userID, listID); err != nil {
+		return errors.New("list not found")
+	}
+	item, err := s.repo.FindListItem(ctx, listID, showID)
+	if err != nil {
+		return errors.New("list item not found")
+	}
+	if req.Notes != nil {
+		item.Notes = req.Notes
+	}
+	if req.Tags != nil {
+		tags, err := normalizeTags(*req.Tags)
+		if err != nil {
+			return err
+		}
+		item.Tags = tags
+	}
+	if err := s.repo.UpdateListItem(ctx, item); err != nil {
+		return err
+	}
+	return s.repo.TouchList(ctx, listID)
+}
+
+func (s *Service) RemoveListItem(ctx context.Context, userID, listID, showID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "watchlist.RemoveListItem")
+	defer span.End()
+
+	if _, err := s.repo.FindList(ctx, userID, listID); err != nil {
+		return errors.New("list not found")
+	}
+	if err := s.repo.DeleteListItem(ctx, listID, showID); err != nil {
+		return err
+	}
+	_ = s.socialSvc.RemoveShowBySource(ctx, userID, listID, showID)
+	return s.repo.TouchList(ctx, listID)
+}
+
+func (s *Service) ReorderList(ctx context.Context, userID, listID uuid.UUID, req ReorderListRequest) error {
+	ctx, span := tracing.Start(ctx, "watchlist.ReorderList")
+	defer span.End()
+
+	if _, err := s.repo.FindList(ctx, userID, listID); err != nil {
+		return errors.New("list not found")
+	}
+	if err := validateReorder(req.ShowIDs); err != nil {
+		return err
+	}
+	if err := s.repo.ReorderList(ctx, listID, req.ShowIDs); err != nil {
+		return err
+	}
+	return s.repo.TouchList(ctx, listID)
+}
+
+func (s *Service) GetSharedList(ctx context.Context, slug string) (*PublicList, error) {
+	ctx, span := tracing.Start(ctx, "watchlist.GetSharedList")
+	defer span.End()
+
+	list, owner, err := s.repo.FindSharedList(ctx, slug)
+	if err != nil {
+		return nil, errors.New("list not found")
+	}
+	return toPublicList(list, owner), nil
+}
+
+func (s *Service) GetPublicLists(ctx context.Context, username string) ([]PublicListSummary, error) {
+	ctx, span := tracing.Start(ctx, "watchlist.GetPublicLists")
+	defer span.End()
+
+	lists, err := s.repo.GetPublicListsByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]PublicListSummary, len(lists))
+	for i, list := range lists {
+		summaries[i] = PublicListSummary{
+			Name:        list.Name,
+			Description: list.Description,
+			ShareSlug:   show.SafeString(list.ShareSlug),
+			UpdatedAt:   list.UpdatedAt,
+		}
+	}
+	return summaries, nil
+}
+
+// CloneList copies a shared list into the caller's account as a new private
+// list. The original is referenced through ClonedFromID.
+func (s *Service) CloneList(ctx context.Context, userID uuid.UUID, slug string, req CloneListRequest) (*CustomList, error) {
+	ctx, span := tracing.Start(ctx, "watchlist.CloneList")
+	defer span.End()
+
+	source, _, err := s.repo.FindSharedList(ctx, slug)
+	if err != nil {
+		return nil, errors.New("list not found")
+	}
+	clone, err := newClone(source, userID, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CloneList(ctx, source, clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+func toPublicList(list *CustomList, owner string) *PublicList {
+	items := make([]PublicListItem, len(list.Items))
+	for i, item := range list.Items {
+		items[i] = PublicListItem{
+			Position: item.Position,
+			Notes:    item.Notes,
+			Tags:     item.Tags,
+			Show:     item.Show,
+		}
+	}
+	return &PublicList{
+		Name:        list.Name,
+		Description: list.Description,
+		Owner:       owner,
+		ShareSlug:   show.SafeString(list.ShareSlug),
+		UpdatedAt:   list.UpdatedAt,
+		Items:       items,
+	}
+}
+
+func validVisibility(v Visibility) bool {
+	return v == VisibilityPrivate || v == VisibilityUnlisted || v == VisibilityPublic
+}
+
+// setVisibility changes who can see a list. Sharing mints a slug when the
+// list has none; going private drops it, so sharing again hands out a new
+// link instead of reviving the one the owner revoked.
+func setVisibility(list *CustomList, visibility Visibility) error {
+	if !validVisibility(visibility) {
+		return errors.New("visibility must be private, unlisted, or public")
+	}
+	list.Visibility = visibility
+	if visibility == VisibilityPrivate {
+		list.ShareSlug = nil
+		return nil
+	}
+	if list.ShareSlug == nil {
+		slug, err := newShareSlug()
+		if err != nil {
+			return err
+		}
+		list.ShareSlug = &slug
+	}
+	return nil
+}
+
+func validateReorder(showIDs []uuid.UUID) error {
+	if len(showIDs) == 0 {
+		return errors.New("show_ids required")
+	}
+	seen := make(map[uuid.UUID]bool, len(showIDs))
+	for _, id := range showIDs {
+		if seen[id] {
+			return errors.New("show_ids must not contain duplicates")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// reorderPositions maps item IDs to their new positions: the named shows
+// first, in the given order, then the rest in their current order. items
+// must be sorted by position.
+func reorderPositions(items []CustomListItem, showIDs []uuid.UUID) map[uuid.UUID]int {
+	ranked := make(map[uuid.UUID]int, len(showIDs))
+	for i, id := range showIDs {
+		ranked[id] = i + 1
+	}
+	positions := make(map[uuid.UUID]int, len(items))
+	next := len(showIDs)
+	for _, item := range items {
+		position, ok := ranked[item.ShowID]
+		if !ok {
+			next++
+			position = next
+		}
+		positions[item.ID] = position
+	}
+	return positions
+}
+
+// newClone is a private copy of source for userID, named name or after the
+// original. It carries no share slug of its own.
+func newClone(source *CustomList, userID uuid.UUID, name string) (*CustomList, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = source.Name
+	}
+	if len(name) > 100 {
+		return nil, errors.New("name must be 1-100 characters")
+	}
+	return &CustomList{
+		UserID:       userID,
+		Name:         name,
+		Description:  source.Description,
+		Visibility:   VisibilityPrivate,
+		ClonedFromID: &source.ID,
+	}, nil
+}
+
+// cloneItems copies source's items into listID, keeping order, notes and
+// tags.
+func cloneItems(source *CustomList, listID uuid.UUID) []CustomListItem {
+	items := make([]CustomListItem, len(source.Items))
+	for i, item := range source.Items {
+		items[i] = CustomListItem{
+			ListID:   listID,
+			ShowID:   item.ShowID,
+			Position: item.Position,
+			Notes:    item.Notes,
+			Tags:     item.Tags,
+		}
+	}
+	return items
+}
+
+// normalizeTags lowercases, trims and de-duplicates free-form tags so that
+// filtering by tag is case-insensitive.
+func normalizeTags(tags []string) ([]string, error) {
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > 32 {
+			return nil, errors.New("tags must be at most 32 characters")
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	if len(result) > maxListTags {
+		return nil, fmt.Errorf("at most %d tags per item", maxListTags)
+	}
+	return result, nil
+}
+
+func newShareSlug() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
@@ -0,0 +1,135 @@
+package watchlist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestSetVisibilityMintsAndRevokesSlug(t *testing.T) {
+	list := &CustomList{Name: "Comfort rewatches", Visibility: VisibilityPrivate}
+
+	if err := setVisibility(list, VisibilityUnlisted); err != nil {
+		t.Fatal(err)
+	}
+	if list.ShareSlug == nil || *list.ShareSlug == "" {
+		t.Fatal("sharing a list must mint a slug")
+	}
+	first := *list.ShareSlug
+
+	// Moving between shared visibilities keeps the link.
+	if err := setVisibility(list, VisibilityPublic); err != nil {
+		t.Fatal(err)
+	}
+	if list.ShareSlug == nil || *list.ShareSlug != first {
+		t.Fatal("unlisted to public must keep the existing slug")
+	}
+
+	// Going private revokes it; sharing again issues a different one.
+	if err := setVisibility(list, VisibilityPrivate); err != nil {
+		t.Fatal(err)
+	}
+	if list.ShareSlug != nil {
+		t.Fatalf("private list kept slug %q", *list.ShareSlug)
+	}
+	if err := setVisibility(list, VisibilityUnlisted); err != nil {
+		t.Fatal(err)
+	}
+	if list.ShareSlug == nil || *list.ShareSlug == first {
+		t.Fatal("re-sharing a list must not revive the revoked slug")
+	}
+
+	if err := setVisibility(list, "friends"); err == nil {
+		t.Fatal("unknown visibility accepted")
+	}
+	if list.Visibility != VisibilityUnlisted {
+		t.Fatalf("rejected visibility changed the list to %q", list.Visibility)
+	}
+}
+
+func TestNewClone(t *testing.T) {
+	slug := "abc123"
+	description := "Slow-burn sci-fi"
+	source := &CustomList{
+		ID: uuid.New(), UserID: uuid.New(), Name: "Sci-fi", Description: &description,
+		Visibility: VisibilityPublic, ShareSlug: &slug,
+	}
+	userID := uuid.New()
+
+	clone, err := newClone(source, userID, "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.UserID != userID || clone.Name != "Sci-fi" || clone.Description != &description {
+		t.Fatalf("unexpected clone %+v", clone)
+	}
+	if clone.Visibility != VisibilityPrivate || clone.ShareSlug != nil {
+		t.Fatal("clones must start private without a share link")
+	}
+	if clone.ClonedFromID == nil || *clone.ClonedFromID != source.ID {
+		t.Fatal("clone must reference its source")
+	}
+
+	if clone, err := newClone(source, userID, " My sci-fi "); err != nil || clone.Name != "My sci-fi" {
+		t.Fatalf("custom name: %v, %+v", err, clone)
+	}
+	if _, err := newClone(source, userID, strings.Repeat("x", 101)); err == nil {
+		t.Fatal("over-long name accepted")
+	}
+}
+
+func TestCloneItemsKeepsOrderNotesAndTags(t *testing.T) {
+	notes := "start with season 2"
+	source := &CustomList{Items: []CustomListItem{
+		{ID: uuid.New(), ListID: uuid.New(), ShowID: uuid.New(), Position: 1, Tags: pq.StringArray{"cozy"}},
+		{ID: uuid.New(), ListID: uuid.New(), ShowID: uuid.New(), Position: 2, Notes: &notes},
+	}}
+	listID := uuid.New()
+
+	items := cloneItems(source, listID)
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	for i, item := range items {
+		original := source.Items[i]
+		if item.ID != uuid.Nil || item.ListID != listID {
+			t.Fatalf("item %d must be a new row in the clone: %+v", i, item)
+		}
+		if item.ShowID != original.ShowID || item.Position != original.Position || item.Notes != original.Notes {
+			t.Fatalf("item %d not copied: %+v", i, item)
+		}
+	}
+	if len(items[0].Tags) != 1 || items[0].Tags[0] != "cozy" {
+		t.Fatalf("tags not copied: %v", items[0].Tags)
+	}
+}
+
+func TestReorderPositions(t *testing.T) {
+	shows := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	items := make([]CustomListItem, len(shows))
+	for i, showID := range shows {
+		items[i] = CustomListItem{ID: uuid.New(), ShowID: showID, Position: i + 1}
+	}
+
+	// The named shows move to the front in the given order; the rest keep
+	// their relative order behind them.
+	positions := reorderPositions(items, []uuid.UUID{shows[3], shows[1]})
+	want := []int{3, 2, 4, 1}
+	for i, item := range items {
+		if positions[item.ID] != want[i] {
+			t.Errorf("show %d: position %d, want %d", i, positions[item.ID], want[i])
+		}
+	}
+
+	if err := validateReorder(nil); err == nil {
+		t.Error("empty reorder accepted")
+	}
+	if err := validateReorder([]uuid.UUID{shows[0], shows[0]}); err == nil {
+		t.Error("duplicate show_ids accepted")
+	}
+	if err := validateReorder(shows); err != nil {
+		t.Errorf("valid reorder rejected: %v", err)
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS custom_list_items;
+DROP TABLE IF EXISTS custom_lists;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS custom_lists (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name            TEXT NOT NULL,
+    description     TEXT,
+    visibility      TEXT NOT NULL DEFAULT 'private'
+                        CHECK (visibility IN ('private', 'unlisted', 'public')),
+    share_slug      TEXT UNIQUE,
+    cloned_from_id  UUID REFERENCES custom_lists(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_custom_lists_user ON custom_lists(user_id);
+CREATE INDEX IF NOT EXISTS idx_custom_lists_public ON custom_lists(user_id) WHERE visibility = 'public';
+
+CREATE TABLE IF NOT EXISTS custom_list_items (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    list_id         UUID NOT NULL REFERENCES custom_lists(id) ON DELETE CASCADE,
+    show_id         UUID NOT NULL REFERENCES shows(id) ON DELETE CASCADE,
+    position        INTEGER NOT NULL,
+    notes           TEXT,
+    tags            TEXT[] NOT NULL DEFAULT '{}',
+    added_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(list_id, show_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_custom_list_items_position ON custom_list_items(list_id, position);
+CREATE INDEX IF NOT EXISTS idx_custom_list_items_tags ON custom_list_items USING gin (tags);
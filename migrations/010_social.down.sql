@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS activities;
+DROP TABLE IF EXISTS social_settings;
+DROP TABLE IF EXISTS follows;
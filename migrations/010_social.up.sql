@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status          TEXT NOT NULL DEFAULT 'pending'
+                        CHECK (status IN ('pending', 'accepted')),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    accepted_at     TIMESTAMPTZ,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows(followee_id, status);
+
+CREATE TABLE IF NOT EXISTS social_settings (
+    user_id         UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    is_private      BOOLEAN NOT NULL DEFAULT FALSE,
+    share_watched   BOOLEAN NOT NULL DEFAULT TRUE,
+    share_ratings   BOOLEAN NOT NULL DEFAULT TRUE,
+    share_tracking  BOOLEAN NOT NULL DEFAULT TRUE,
+    share_lists     BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS activities (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type            TEXT NOT NULL CHECK (type IN ('watched', 'rated', 'tracked', 'listed')),
+    show_id         UUID NOT NULL REFERENCES shows(id) ON DELETE CASCADE,
+    source_id       UUID,
+    season_number   INTEGER,
+    episode_number  INTEGER,
+    rating          INTEGER,
+    notes           TEXT,
+    list_name       TEXT,
+    metadata        JSONB DEFAULT '{}',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_activities_user_created ON activities(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_activities_source ON activities(source_id) WHERE source_id IS NOT NULL;
@@ -0,0 +1,33 @@
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS profile_id;
+ALTER TABLE user_devices DROP COLUMN IF EXISTS profile_id;
+
+DROP INDEX IF EXISTS idx_notifications_profile;
+DROP INDEX IF EXISTS idx_notifications_profile_event_unique;
+DELETE FROM notifications WHERE profile_id <> user_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_user_event_unique
+    ON notifications(user_id, timeline_event_id)
+    WHERE timeline_event_id IS NOT NULL;
+ALTER TABLE notifications DROP COLUMN IF EXISTS profile_id;
+
+-- Only the primary profile's rows survive a rollback.
+DROP INDEX IF EXISTS idx_history_profile_show;
+DELETE FROM watch_history_entries WHERE profile_id <> user_id;
+ALTER TABLE watch_history_entries DROP CONSTRAINT IF EXISTS watch_history_entries_profile_episode_key;
+ALTER TABLE watch_history_entries ADD UNIQUE (user_id, show_id, season_number, episode_number);
+CREATE INDEX IF NOT EXISTS idx_history_user_show ON watch_history_entries(user_id, show_id);
+ALTER TABLE watch_history_entries DROP COLUMN IF EXISTS profile_id;
+
+DROP INDEX IF EXISTS idx_watchlist_profile_priority;
+DELETE FROM watchlist_items WHERE profile_id <> user_id;
+ALTER TABLE watchlist_items DROP CONSTRAINT IF EXISTS watchlist_items_profile_show_key;
+ALTER TABLE watchlist_items ADD CONSTRAINT watchlist_items_user_id_show_id_key UNIQUE (user_id, show_id);
+CREATE INDEX IF NOT EXISTS idx_watchlist_user_priority ON watchlist_items(user_id, priority);
+ALTER TABLE watchlist_items DROP COLUMN IF EXISTS profile_id;
+
+DROP INDEX IF EXISTS idx_tracked_profile;
+DELETE FROM user_tracked_shows WHERE profile_id <> user_id;
+ALTER TABLE user_tracked_shows DROP CONSTRAINT IF EXISTS user_tracked_shows_profile_show_key;
+ALTER TABLE user_tracked_shows ADD CONSTRAINT user_tracked_shows_user_id_show_id_key UNIQUE (user_id, show_id);
+ALTER TABLE user_tracked_shows DROP COLUMN IF EXISTS profile_id;
+
+DROP TABLE IF EXISTS profiles;
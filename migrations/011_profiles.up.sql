@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS profiles (
+    id                  UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id             UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name                TEXT NOT NULL,
+    avatar              TEXT,
+    max_content_rating  TEXT,
+    is_primary          BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profiles_primary ON profiles(user_id) WHERE is_primary;
+
+-- Every account's primary profile shares the account's ID, so rows and tokens
+-- from before profiles existed resolve to it without a lookup.
+INSERT INTO profiles (id, user_id, name, is_primary)
+SELECT id, id, username, TRUE FROM users
+ON CONFLICT DO NOTHING;
+
+-- Tracking
+ALTER TABLE user_tracked_shows ADD COLUMN IF NOT EXISTS profile_id UUID REFERENCES profiles(id) ON DELETE CASCADE;
+UPDATE user_tracked_shows SET profile_id = user_id WHERE profile_id IS NULL;
+ALTER TABLE user_tracked_shows ALTER COLUMN profile_id SET NOT NULL;
+ALTER TABLE user_tracked_shows DROP CONSTRAINT IF EXISTS user_tracked_shows_user_id_show_id_key;
+ALTER TABLE user_tracked_shows ADD CONSTRAINT user_tracked_shows_profile_show_key UNIQUE (profile_id, show_id);
+CREATE INDEX IF NOT EXISTS idx_tracked_profile ON user_tracked_shows(profile_id);
+
+-- Watchlist
+ALTER TABLE watchlist_items ADD COLUMN IF NOT EXISTS profile_id UUID REFERENCES profiles(id) ON DELETE CASCADE;
+UPDATE watchlist_items SET profile_id = user_id WHERE profile_id IS NULL;
+ALTER TABLE watchlist_items ALTER COLUMN profile_id SET NOT NULL;
+ALTER TABLE watchlist_items DROP CONSTRAINT IF EXISTS watchlist_items_user_id_show_id_key;
+ALTER TABLE watchlist_items ADD CONSTRAINT watchlist_items_profile_show_key UNIQUE (profile_id, show_id);
+DROP INDEX IF EXISTS idx_watchlist_user_priority;
+CREATE INDEX IF NOT EXISTS idx_watchlist_profile_priority ON watchlist_items(profile_id, priority);
+
+-- Watch history. The original unique constraint name was truncated by
+-- Postgres, so look it up instead of spelling it out.
+ALTER TABLE watch_history_entries ADD COLUMN IF NOT EXISTS profile_id UUID REFERENCES profiles(id) ON DELETE CASCADE;
+UPDATE watch_history_entries SET profile_id = user_id WHERE profile_id IS NULL;
+ALTER TABLE watch_history_entries ALTER COLUMN profile_id SET NOT NULL;
+DO $$
+DECLARE
+    constraint_name TEXT;
+BEGIN
+    SELECT conname INTO constraint_name FROM pg_constraint
+    WHERE conrelid = 'watch_history_entries'::regclass AND contype = 'u';
+    IF constraint_name IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE watch_history_entries DROP CONSTRAINT %I', constraint_name);
+    END IF;
+END $$;
+ALTER TABLE watch_history_entries ADD CONSTRAINT watch_history_entries_profile_episode_key
+    UNIQUE (profile_id, show_id, season_number, episode_number);
+DROP INDEX IF EXISTS idx_history_user_show;
+CREATE INDEX IF NOT EXISTS idx_history_profile_show ON watch_history_entries(profile_id, show_id);
+
+-- Notifications
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS profile_id UUID REFERENCES profiles(id) ON DELETE CASCADE;
+UPDATE notifications SET profile_id = user_id WHERE profile_id IS NULL;
+ALTER TABLE notifications ALTER COLUMN profile_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_notifications_user_event_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_profile_event_unique
+    ON notifications(profile_id, timeline_event_id)
+    WHERE timeline_event_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_profile ON notifications(profile_id);
+
+-- Devices with no profile receive pushes for every profile on the account.
+ALTER TABLE user_devices ADD COLUMN IF NOT EXISTS profile_id UUID REFERENCES profiles(id) ON DELETE CASCADE;
+
+-- Refresh tokens remember the selected profile across rotation.
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS profile_id UUID REFERENCES profiles(id) ON DELETE CASCADE;
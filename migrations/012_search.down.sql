@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_shows_imdb_rating;
+DROP INDEX IF EXISTS idx_shows_premiere_date;
+DROP INDEX IF EXISTS idx_shows_network;
+DROP INDEX IF EXISTS idx_shows_genres;
+DROP INDEX IF EXISTS idx_shows_search_titles_trgm;
+DROP INDEX IF EXISTS idx_shows_search_vector;
+
+DROP TRIGGER IF EXISTS shows_search_refresh ON shows;
+DROP FUNCTION IF EXISTS shows_search_refresh();
+
+ALTER TABLE shows DROP COLUMN IF EXISTS imdb_rating;
+ALTER TABLE shows DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE shows DROP COLUMN IF EXISTS search_titles;
+ALTER TABLE shows DROP COLUMN IF EXISTS alternative_titles;
@@ -0,0 +1,37 @@
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS alternative_titles TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS search_titles TEXT;
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS imdb_rating NUMERIC(3,1);
+
+-- Generated columns cannot use array_to_string (it is only STABLE), so the
+-- search columns are kept current by a trigger instead. Titles use the
+-- 'simple' config so names are not stemmed; overviews use 'english'.
+CREATE OR REPLACE FUNCTION shows_search_refresh() RETURNS trigger AS $$
+BEGIN
+    NEW.search_titles := concat_ws(' ', NEW.title, array_to_string(NEW.alternative_titles, ' '));
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(array_to_string(NEW.alternative_titles, ' '), '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(NEW.overview, '')), 'C');
+    NEW.imdb_rating := CASE
+        WHEN NEW.ratings->>'imdb_rating' ~ '^[0-9]+(\.[0-9]+)?$' THEN (NEW.ratings->>'imdb_rating')::NUMERIC(3,1)
+        ELSE NULL
+    END;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS shows_search_refresh ON shows;
+CREATE TRIGGER shows_search_refresh
+    BEFORE INSERT OR UPDATE OF title, alternative_titles, overview, ratings ON shows
+    FOR EACH ROW EXECUTE FUNCTION shows_search_refresh();
+
+-- Backfill existing rows through the trigger.
+UPDATE shows SET title = title;
+
+CREATE INDEX IF NOT EXISTS idx_shows_search_vector ON shows USING gin (search_vector);
+CREATE INDEX IF NOT EXISTS idx_shows_search_titles_trgm ON shows USING gin (search_titles gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_shows_genres ON shows USING gin (genres);
+CREATE INDEX IF NOT EXISTS idx_shows_network ON shows(network);
+CREATE INDEX IF NOT EXISTS idx_shows_premiere_date ON shows(premiere_date);
+CREATE INDEX IF NOT EXISTS idx_shows_imdb_rating ON shows(imdb_rating);
@@ -0,0 +1,23 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_notifications BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Accounts created through an identity provider proved their address there.
+UPDATE users SET email_verified_at = NOW()
+WHERE email_verified_at IS NULL
+  AND EXISTS (SELECT 1 FROM user_identities ui WHERE ui.user_id = users.id AND ui.email = users.email);
+
+-- Single-use tokens for password reset, email verification and email
+-- change. Only a SHA-256 hash of each token is stored.
+CREATE TABLE IF NOT EXISTS account_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('password_reset', 'email_verify', 'email_change')),
+    token_hash TEXT NOT NULL UNIQUE,
+    new_email TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_tokens_user_purpose ON account_tokens(user_id, purpose);
+CREATE INDEX IF NOT EXISTS idx_account_tokens_expires ON account_tokens(expires_at);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS mfa_recovery_codes;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS mfa_verified;
+
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS scheduler_job_runs;
+DROP TABLE IF EXISTS scheduler_jobs;
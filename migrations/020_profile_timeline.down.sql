@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS profile_timeline_show_sync ON shows;
+DROP FUNCTION IF EXISTS profile_timeline_show_sync();
+DROP TRIGGER IF EXISTS profile_timeline_tracking_sync ON user_tracked_shows;
+DROP FUNCTION IF EXISTS profile_timeline_tracking_sync();
+DROP TRIGGER IF EXISTS profile_timeline_event_sync ON timeline_events;
+DROP FUNCTION IF EXISTS profile_timeline_event_sync();
+
+DROP TABLE IF EXISTS profile_timeline;
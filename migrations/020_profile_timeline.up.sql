@@ -0,0 +1,130 @@
+-- profile_timeline is each profile's timeline precomputed: one row per
+-- (profile, event) for every event of every tracked show, carrying the show
+-- fields the calendar filters and renders with. Triggers keep it current as
+-- events are created or rescheduled, shows are tracked, untracked or
+-- favourited, and show metadata changes, so reads never join.
+CREATE TABLE IF NOT EXISTS profile_timeline (
+    profile_id      UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    event_id        UUID NOT NULL REFERENCES timeline_events(id) ON DELETE CASCADE,
+    show_id         UUID NOT NULL,
+    event_type      TEXT NOT NULL,
+    title           TEXT NOT NULL,
+    description     TEXT,
+    event_date      TIMESTAMPTZ NOT NULL,
+    season_number   INTEGER,
+    episode_number  INTEGER,
+    metadata        JSONB NOT NULL DEFAULT '{}',
+    is_favorite     BOOLEAN NOT NULL DEFAULT FALSE,
+    show_title      TEXT NOT NULL,
+    show_poster_url TEXT,
+    network         TEXT,
+    genres          TEXT[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY (profile_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_timeline_date ON profile_timeline(profile_id, event_date, event_id);
+CREATE INDEX IF NOT EXISTS idx_profile_timeline_favorite ON profile_timeline(profile_id, event_date, event_id) WHERE is_favorite;
+CREATE INDEX IF NOT EXISTS idx_profile_timeline_event ON profile_timeline(event_id);
+CREATE INDEX IF NOT EXISTS idx_profile_timeline_show ON profile_timeline(show_id, profile_id);
+
+-- A new event fans out to every profile tracking its show; a rescheduled or
+-- edited one is rewritten in place. Deletes cascade through event_id.
+CREATE OR REPLACE FUNCTION profile_timeline_event_sync() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO profile_timeline (profile_id, event_id, show_id, event_type, title, description,
+            event_date, season_number, episode_number, metadata, is_favorite,
+            show_title, show_poster_url, network, genres)
+        SELECT uts.profile_id, NEW.id, NEW.show_id, NEW.event_type, NEW.title, NEW.description,
+            NEW.event_date, NEW.season_number, NEW.episode_number, COALESCE(NEW.metadata, '{}'), uts.is_favorite,
+            s.title, s.poster_url, s.network, COALESCE(s.genres, '{}')
+        FROM user_tracked_shows uts
+        JOIN shows s ON s.id = NEW.show_id
+        WHERE uts.show_id = NEW.show_id
+        ON CONFLICT (profile_id, event_id) DO NOTHING;
+    ELSE
+        UPDATE profile_timeline SET
+            event_type = NEW.event_type,
+            title = NEW.title,
+            description = NEW.description,
+            event_date = NEW.event_date,
+            season_number = NEW.season_number,
+            episode_number = NEW.episode_number,
+            metadata = COALESCE(NEW.metadata, '{}')
+        WHERE event_id = NEW.id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS profile_timeline_event_sync ON timeline_events;
+CREATE TRIGGER profile_timeline_event_sync
+    AFTER INSERT OR UPDATE OF event_type, title, description, event_date, season_number, episode_number, metadata
+    ON timeline_events
+    FOR EACH ROW EXECUTE FUNCTION profile_timeline_event_sync();
+
+-- Tracking a show copies in its events, untracking removes them, and
+-- favouriting flips the flag on the rows already there.
+CREATE OR REPLACE FUNCTION profile_timeline_tracking_sync() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        IF TG_OP = 'UPDATE' AND OLD.profile_id = NEW.profile_id AND OLD.show_id = NEW.show_id THEN
+            UPDATE profile_timeline SET is_favorite = NEW.is_favorite
+            WHERE profile_id = NEW.profile_id AND show_id = NEW.show_id
+              AND is_favorite IS DISTINCT FROM NEW.is_favorite;
+            RETURN NULL;
+        END IF;
+        DELETE FROM profile_timeline WHERE profile_id = OLD.profile_id AND show_id = OLD.show_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO profile_timeline (profile_id, event_id, show_id, event_type, title, description,
+            event_date, season_number, episode_number, metadata, is_favorite,
+            show_title, show_poster_url, network, genres)
+        SELECT NEW.profile_id, te.id, te.show_id, te.event_type, te.title, te.description,
+            te.event_date, te.season_number, te.episode_number, COALESCE(te.metadata, '{}'), NEW.is_favorite,
+            s.title, s.poster_url, s.network, COALESCE(s.genres, '{}')
+        FROM timeline_events te
+        JOIN shows s ON s.id = te.show_id
+        WHERE te.show_id = NEW.show_id
+        ON CONFLICT (profile_id, event_id) DO UPDATE SET is_favorite = EXCLUDED.is_favorite;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS profile_timeline_tracking_sync ON user_tracked_shows;
+CREATE TRIGGER profile_timeline_tracking_sync
+    AFTER INSERT OR DELETE OR UPDATE OF profile_id, show_id, is_favorite ON user_tracked_shows
+    FOR EACH ROW EXECUTE FUNCTION profile_timeline_tracking_sync();
+
+CREATE OR REPLACE FUNCTION profile_timeline_show_sync() RETURNS trigger AS $$
+BEGIN
+    UPDATE profile_timeline SET
+        show_title = NEW.title,
+        show_poster_url = NEW.poster_url,
+        network = NEW.network,
+        genres = COALESCE(NEW.genres, '{}')
+    WHERE show_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS profile_timeline_show_sync ON shows;
+CREATE TRIGGER profile_timeline_show_sync
+    AFTER UPDATE OF title, poster_url, network, genres ON shows
+    FOR EACH ROW
+    WHEN (OLD.title IS DISTINCT FROM NEW.title OR OLD.poster_url IS DISTINCT FROM NEW.poster_url
+        OR OLD.network IS DISTINCT FROM NEW.network OR OLD.genres IS DISTINCT FROM NEW.genres)
+    EXECUTE FUNCTION profile_timeline_show_sync();
+
+-- Backfill from the current tracking state.
+INSERT INTO profile_timeline (profile_id, event_id, show_id, event_type, title, description,
+    event_date, season_number, episode_number, metadata, is_favorite,
+    show_title, show_poster_url, network, genres)
+SELECT uts.profile_id, te.id, te.show_id, te.event_type, te.title, te.description,
+    te.event_date, te.season_number, te.episode_number, COALESCE(te.metadata, '{}'), uts.is_favorite,
+    s.title, s.poster_url, s.network, COALESCE(s.genres, '{}')
+FROM user_tracked_shows uts
+JOIN timeline_events te ON te.show_id = uts.show_id
+JOIN shows s ON s.id = uts.show_id
+ON CONFLICT (profile_id, event_id) DO NOTHING;
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_notifications_dead_letters;
+
+DELETE FROM notifications WHERE status = 'discarded';
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('pending', 'sent', 'failed', 'read'));
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS failed_at,
+    DROP COLUMN IF EXISTS error_class;
@@ -0,0 +1,15 @@
+-- Notifications in 'failed' form the dead-letter queue. error_class drives
+-- retries and admin filtering; 'discarded' hides a dead letter for good.
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS error_class TEXT,
+    ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('pending', 'sent', 'failed', 'read', 'discarded'));
+
+UPDATE notifications SET error_class = 'unknown', failed_at = created_at
+WHERE status = 'failed' AND error_class IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_dead_letters
+    ON notifications(error_class, failed_at) WHERE status = 'failed';
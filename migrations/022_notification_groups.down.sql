@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_notifications_pending_show;
+DROP INDEX IF EXISTS idx_notifications_group;
+
+UPDATE notifications SET status = 'sent', group_id = NULL WHERE status = 'grouped';
+DELETE FROM notifications WHERE payload->>'type' = 'episode_group';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('pending', 'sent', 'failed', 'read', 'discarded'));
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS group_id;
@@ -0,0 +1,14 @@
+-- A group notification stands in for several releases of one show sent
+-- together. Its members keep their timeline_event_id, point at it through
+-- group_id, and move to 'grouped' instead of being delivered themselves.
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS group_id UUID REFERENCES notifications(id) ON DELETE CASCADE;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('pending', 'sent', 'failed', 'read', 'discarded', 'grouped'));
+
+CREATE INDEX IF NOT EXISTS idx_notifications_group ON notifications(group_id) WHERE group_id IS NOT NULL;
+-- Finds pending siblings of a due notification when coalescing.
+CREATE INDEX IF NOT EXISTS idx_notifications_pending_show
+    ON notifications(profile_id, (payload->>'show_id'), scheduled_for) WHERE status = 'pending';
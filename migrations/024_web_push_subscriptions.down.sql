@@ -0,0 +1,5 @@
+DELETE FROM user_devices WHERE platform = 'webpush';
+
+ALTER TABLE user_devices DROP CONSTRAINT IF EXISTS user_devices_webpush_keys_check;
+ALTER TABLE user_devices DROP COLUMN IF EXISTS push_auth;
+ALTER TABLE user_devices DROP COLUMN IF EXISTS push_p256dh;
@@ -0,0 +1,5 @@
+ALTER TABLE user_devices ADD COLUMN IF NOT EXISTS push_p256dh TEXT;
+ALTER TABLE user_devices ADD COLUMN IF NOT EXISTS push_auth TEXT;
+
+ALTER TABLE user_devices ADD CONSTRAINT user_devices_webpush_keys_check
+    CHECK (platform <> 'webpush' OR (push_p256dh IS NOT NULL AND push_auth IS NOT NULL));